@@ -12,13 +12,25 @@ import (
 	"time"
 
 	"polis/gate/internal/bead"
+	"polis/gate/internal/cache"
 	"polis/gate/internal/city"
 	"polis/gate/internal/pipeline"
+	"polis/gate/internal/report"
 	"polis/gate/internal/verdict"
 )
 
 const defaultHistoryLimit = 20
 
+// Output formats for gate check.
+const (
+	formatPretty = "pretty"
+	formatJSON   = "json"
+	formatSARIF  = "sarif"
+)
+
+// maxPrettyIssues caps how many findings printPretty lists per gate.
+const maxPrettyIssues = 20
+
 var filterValueRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
 
 func main() {
@@ -43,6 +55,12 @@ func run(ctx context.Context, args []string) int {
 	if cmd == "history" {
 		return runHistory(args[1:])
 	}
+	if cmd == "baseline" {
+		return runBaseline(ctx, args[1:])
+	}
+	if cmd == "cache" {
+		return runCache(args[1:])
+	}
 
 	fmt.Fprintf(os.Stderr, "unknown command: %s\n", cmd)
 	printUsage()
@@ -50,8 +68,10 @@ func run(ctx context.Context, args []string) int {
 }
 
 func runCheck(ctx context.Context, args []string) int {
-	var repoPath, level, citizen string
-	var jsonOutput bool
+	var repoPath, level, citizen, junitPath string
+	format := formatPretty
+	var opts pipeline.Options
+	useCache := true
 
 	level = pipeline.LevelStandard
 	i := 0
@@ -65,7 +85,18 @@ func runCheck(ctx context.Context, args []string) int {
 			}
 			level = args[i]
 		case "--json":
-			jsonOutput = true
+			format = formatJSON
+		case "--format":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--format requires a value")
+				return 1
+			}
+			format = args[i]
+			if format != formatPretty && format != formatJSON && format != formatSARIF {
+				fmt.Fprintf(os.Stderr, "invalid --format %q: use pretty, json, or sarif\n", format)
+				return 1
+			}
 		case "--citizen":
 			i++
 			if i >= len(args) {
@@ -73,6 +104,45 @@ func runCheck(ctx context.Context, args []string) int {
 				return 1
 			}
 			citizen = args[i]
+		case "--junit":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--junit requires a value")
+				return 1
+			}
+			junitPath = args[i]
+		case "--fail-fast":
+			opts.FailFast = true
+		case "--no-cache":
+			useCache = false
+		case "--snapshot":
+			opts.Snapshot = true
+		case "--rev":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--rev requires a value")
+				return 1
+			}
+			opts.Rev = args[i]
+		case "--base":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--base requires a value")
+				return 1
+			}
+			opts.Base = args[i]
+		case "--concurrency":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--concurrency requires a value")
+				return 1
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "invalid --concurrency %q: use a positive integer\n", args[i])
+				return 1
+			}
+			opts.Concurrency = n
 		default:
 			if strings.HasPrefix(args[i], "-") {
 				fmt.Fprintf(os.Stderr, "unknown flag: %s\n", args[i])
@@ -97,20 +167,35 @@ func runCheck(ctx context.Context, args []string) int {
 
 	citizen = resolveCitizen(citizen)
 
-	v := pipeline.Run(ctx, repoPath, level, citizen)
+	// --no-cache neither reads nor writes the cache.
+	if dir, err := cache.DefaultDir(); err == nil && useCache {
+		opts.Cache = cache.Open(dir)
+	}
+
+	v := pipeline.RunWithOptions(ctx, repoPath, level, citizen, opts)
 
 	if beadID := bead.Record(v); beadID != "" {
 		v.Bead = beadID
 	}
 
-	if jsonOutput {
+	switch format {
+	case formatJSON:
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		enc.Encode(v)
-	} else {
+	case formatSARIF:
+		report.WriteSARIF(os.Stdout, v)
+	default:
 		printPretty(v)
 	}
 
+	if junitPath != "" {
+		if err := report.WriteJUnitFile(junitPath, v); err != nil {
+			fmt.Fprintf(os.Stderr, "write junit report: %v\n", err)
+			return verdict.ExitFail
+		}
+	}
+
 	return v.ExitCode
 }
 
@@ -190,6 +275,91 @@ func runCity(ctx context.Context, args []string) int {
 	return v.ExitCode
 }
 
+func runBaseline(ctx context.Context, args []string) int {
+	var repoPath string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			fmt.Fprintf(os.Stderr, "unknown flag: %s\n", arg)
+			return 1
+		}
+		if repoPath == "" {
+			repoPath = arg
+		}
+	}
+	if repoPath == "" {
+		fmt.Fprintln(os.Stderr, "repo path required: gate baseline <repo-path>")
+		return 1
+	}
+
+	res, err := pipeline.WriteBaseline(ctx, repoPath)
+	for _, note := range res.Notes {
+		fmt.Fprintln(os.Stderr, note)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gate baseline: %v\n", err)
+		return 1
+	}
+
+	total := 0
+	var parts []string
+	for _, scan := range res.Scans {
+		n := 0
+		for _, e := range res.Baseline.Findings {
+			if e.Gate == scan.Name {
+				n += e.Count
+			}
+		}
+		total += n
+		parts = append(parts, fmt.Sprintf("%s=%d", scan.Name, n))
+	}
+	fmt.Printf("wrote %s: %d findings (%s)\n", res.Path, total, strings.Join(parts, " "))
+	return 0
+}
+
+func runCache(args []string) int {
+	if len(args) == 0 || args[0] != "prune" {
+		fmt.Fprintln(os.Stderr, "usage: gate cache prune [--max-age <dur>] [--all]")
+		return 1
+	}
+	maxAge := cache.DefaultMaxAge
+	i := 1
+	for i < len(args) {
+		switch args[i] {
+		case "--max-age":
+			i++
+			if i >= len(args) {
+				fmt.Fprintln(os.Stderr, "--max-age requires a value")
+				return 1
+			}
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				fmt.Fprintf(os.Stderr, "invalid --max-age %q: use duration like 168h\n", args[i])
+				return 1
+			}
+			maxAge = d
+		case "--all":
+			maxAge = 0
+		default:
+			fmt.Fprintf(os.Stderr, "unknown argument: %s\n", args[i])
+			return 1
+		}
+		i++
+	}
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gate cache: %v\n", err)
+		return 1
+	}
+	stats, err := cache.Open(dir).Prune(maxAge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gate cache prune: %v\n", err)
+		return 1
+	}
+	fmt.Printf("pruned %d cached results (%d bytes) from %s\n", stats.Removed, stats.Bytes, dir)
+	return 0
+}
+
 func runHistory(args []string) int {
 	if _, err := exec.LookPath("br"); err != nil {
 		fmt.Fprintln(os.Stderr, "gate history requires br (beads) to be installed")
@@ -302,11 +472,21 @@ Usage:
   gate check <repo-path> [flags]
   gate city <repo-path> [flags]
   gate history [flags]
+  gate baseline <repo-path>
+  gate cache prune [flags]
 
 Check flags:
   --level quick|standard|deep   Check level (default: standard)
-  --json                        Output verdict as JSON
+  --json                        Output verdict as JSON (same as --format json)
+  --format pretty|json|sarif    Output format (default: pretty)
   --citizen <name>              Set actor name
+  --concurrency N               Max gates running at once (default: gate.toml, else 4)
+  --fail-fast                   Cancel remaining gates after the first failure
+  --junit <file>                Also write a JUnit XML report to file
+  --no-cache                    Run every gate without reading or writing the cache
+  --snapshot                    Check the staged index in a temporary worktree
+  --rev <commit>                Check this commit in a temporary worktree
+  --base <ref>                  Diff against the merge base with ref (default: gate.toml, else main/master)
 
 City flags:
   --install-at <path>           Also run split check against install path
@@ -318,32 +498,68 @@ City flags:
 History flags:
   --repo <name>                 Filter by repo name
   --citizen <name>              Filter by citizen
-  --limit N                     Max results (default: 20)`)
+  --limit N                     Max results (default: 20)
+
+Cache prune flags:
+  --max-age <dur>               Remove results older than this (default: 168h)
+  --all                         Remove every cached result
+
+Baseline:
+  Records current truthsayer and ubs findings in .gate-baseline.json.
+  Commit the file; check then fails only on findings not in it.`)
 }
 
 func printPretty(v verdict.Verdict) {
 	icon := "\033[32m✓ PASS\033[0m"
-	if !v.Pass {
+	if v.ExitCode == verdict.ExitReview {
+		icon = "\033[33m! REVIEW\033[0m"
+	} else if !v.Pass {
 		icon = "\033[31m✗ FAIL\033[0m"
 	}
 	fmt.Printf("\n%s  %s @ %s level  (score: %.2f)\n", icon, v.Repo, v.Level, v.Score)
-	fmt.Printf("citizen: %s\n\n", v.Citizen)
+	fmt.Printf("citizen: %s\n", v.Citizen)
+	if v.Head != "" {
+		fmt.Printf("commit: %s", shortSHA(v.Head))
+		if v.Base != "" {
+			fmt.Printf("  base: %s", shortSHA(v.Base))
+		}
+		fmt.Println()
+	}
+	fmt.Println()
 
 	for _, g := range v.Gates {
 		gIcon := "\033[32m✓\033[0m"
 		if g.Skipped {
 			gIcon = "\033[33m-\033[0m"
+		} else if g.Cancelled {
+			gIcon = "\033[90m⊘\033[0m"
 		} else if !g.Pass {
 			gIcon = "\033[31m✗\033[0m"
+		} else if g.Review {
+			gIcon = "\033[33m!\033[0m"
 		}
-		fmt.Printf("  %s %-20s %dms\n", gIcon, g.Name, g.DurationMs)
-		if !g.Pass && !g.Skipped && g.Output != "" {
+		cached := ""
+		if g.Cached {
+			cached = "  (cached)"
+		}
+		fmt.Printf("  %s %-20s %dms%s\n", gIcon, g.Name, g.DurationMs, cached)
+		if (!g.Pass || g.Review) && !g.Skipped && g.Output != "" {
 			for _, line := range strings.Split(g.Output, "\n") {
 				if line != "" {
 					fmt.Printf("    %s\n", line)
 				}
 			}
 		}
+		if !g.Pass && !g.Cancelled {
+			printIssues(g.Issues)
+		}
+	}
+	fmt.Printf("\n  total: %dms\n", v.DurationMs)
+	if len(v.ReviewReasons) > 0 {
+		fmt.Printf("\nneeds review:\n")
+		for _, r := range v.ReviewReasons {
+			fmt.Printf("  - %s\n", r)
+		}
 	}
 	if v.Bead != "" {
 		fmt.Printf("\nbead: %s\n", v.Bead)
@@ -351,6 +567,60 @@ func printPretty(v verdict.Verdict) {
 	fmt.Println()
 }
 
+// printIssues lists the findings of a failing gate, skipping those that
+// predate the diff or are accepted by the baseline.
+func printIssues(issues []verdict.Issue) {
+	shown, hidden := 0, 0
+	for _, is := range issues {
+		if is.PreExisting || is.Baselined {
+			continue
+		}
+		if shown == maxPrettyIssues {
+			hidden++
+			continue
+		}
+		shown++
+		line := is.Severity
+		if loc := is.Location(); loc != "" {
+			line += " " + loc
+		}
+		if src := issueSource(is); src != "" {
+			line += " [" + src + "]"
+		}
+		if is.Rule != "" {
+			line += " " + is.Rule
+		} else if is.Category != "" {
+			line += " " + is.Category
+		}
+		if is.Message != "" {
+			line += ": " + is.Message
+		}
+		fmt.Printf("    %s\n", line)
+	}
+	if hidden > 0 {
+		fmt.Printf("    ... %d more (see --json)\n", hidden)
+	}
+}
+
+// issueSource names the scanner and language behind an issue, if known.
+func issueSource(is verdict.Issue) string {
+	switch {
+	case is.Scanner != "" && is.Language != "" && is.Scanner != is.Language:
+		return is.Scanner + "/" + is.Language
+	case is.Scanner != "":
+		return is.Scanner
+	}
+	return is.Language
+}
+
+// shortSHA abbreviates a commit hash for display.
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
 func printPrettyCity(v city.Verdict) {
 	color := "\033[32m✓ PASS\033[0m"
 	if v.Status == "warn" {
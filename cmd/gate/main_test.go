@@ -11,7 +11,9 @@ import (
 	"strings"
 	"testing"
 
+	"polis/gate/internal/cache"
 	"polis/gate/internal/city"
+	"polis/gate/internal/report"
 	"polis/gate/internal/verdict"
 )
 
@@ -156,6 +158,13 @@ func TestRunCheck_FlagErrors(t *testing.T) {
 		{"--citizen without value", []string{"--citizen"}},
 		{"unknown flag", []string{"--bogus", "."}},
 		{"invalid level", []string{"--level", "extreme", "."}},
+		{"--concurrency without value", []string{"--concurrency"}},
+		{"--format without value", []string{"--format"}},
+		{"invalid format", []string{"--format", "xml", "."}},
+		{"--junit without value", []string{"--junit"}},
+		{"invalid concurrency", []string{"--concurrency", "0", "."}},
+		{"--rev without value", []string{"--rev"}},
+		{"--base without value", []string{"--base"}},
 	}
 
 	for _, tt := range tests {
@@ -168,6 +177,57 @@ func TestRunCheck_FlagErrors(t *testing.T) {
 	}
 }
 
+func TestRunBaseline_ArgErrors(t *testing.T) {
+	oldErr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = oldErr }()
+
+	if code := runBaseline(context.Background(), nil); code != 1 {
+		t.Fatalf("runBaseline with no repo = %d, want 1", code)
+	}
+	if code := runBaseline(context.Background(), []string{"--bogus", "."}); code != 1 {
+		t.Fatalf("runBaseline with unknown flag = %d, want 1", code)
+	}
+}
+
+func TestRunCache_ArgErrors(t *testing.T) {
+	oldErr := os.Stderr
+	os.Stderr, _ = os.Open(os.DevNull)
+	defer func() { os.Stderr = oldErr }()
+
+	for _, args := range [][]string{nil, {"clear"}, {"prune", "--max-age"}, {"prune", "--max-age", "soon"}, {"prune", "--bogus"}} {
+		if code := runCache(args); code != 1 {
+			t.Fatalf("runCache(%v) = %d, want 1", args, code)
+		}
+	}
+}
+
+func TestRunCache_Prune(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	store := cache.Open(filepath.Join(cacheHome, "gate"))
+	store.Put(cache.Key("a"), verdict.GateResult{Name: "tests", Pass: true})
+	store.Put(cache.Key("b"), verdict.GateResult{Name: "lint", Pass: true})
+
+	output := captureStdout(t, func() {
+		if code := runCache([]string{"prune"}); code != 0 {
+			t.Errorf("prune = %d, want 0", code)
+		}
+	})
+	if !strings.HasPrefix(output, "pruned 0 cached results") {
+		t.Fatalf("fresh entries should survive the default prune, got %q", output)
+	}
+
+	output = captureStdout(t, func() {
+		if code := runCache([]string{"prune", "--all"}); code != 0 {
+			t.Errorf("prune --all = %d, want 0", code)
+		}
+	})
+	if !strings.HasPrefix(output, "pruned 2 cached results") {
+		t.Fatalf("unexpected output %q", output)
+	}
+}
+
 func TestRunCity_MissingRepo(t *testing.T) {
 	oldErr := os.Stderr
 	os.Stderr, _ = os.Open(os.DevNull)
@@ -209,6 +269,18 @@ func TestRunCity_FlagErrors(t *testing.T) {
 
 // --- helpers ---
 
+// TestMain keeps check runs from reading or filling the user's result cache.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gate-cache-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CACHE_HOME", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func captureStdout(t *testing.T, fn func()) string {
 	t.Helper()
 	oldOut := os.Stdout
@@ -302,6 +374,60 @@ func TestRunCheck_E2E_FailingGoProject(t *testing.T) {
 	}
 }
 
+func TestRunCheck_E2E_SARIFOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "go.mod", "module passing\n\ngo 1.21\n")
+	writeTestFile(t, dir, "main.go", "package main\nfunc main() {}\n")
+
+	output := captureStdout(t, func() {
+		runCheck(context.Background(), []string{"--level", "quick", "--format", "sarif", dir})
+	})
+
+	var log report.SARIFLog
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &log); err != nil {
+		t.Fatalf("failed to parse SARIF output: %v\nraw: %s", err, output)
+	}
+	if log.Version != report.SARIFVersion || len(log.Runs) < 2 {
+		t.Fatalf("expected a run per gate, got %+v", log)
+	}
+	if log.Runs[0].Tool.Driver.Name != "tests" {
+		t.Errorf("expected tests run first, got %q", log.Runs[0].Tool.Driver.Name)
+	}
+}
+
+func TestRunCheck_E2E_JUnitReport(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "go.mod", "module passing\n\ngo 1.21\n")
+	writeTestFile(t, dir, "main.go", "package main\nfunc main() {}\n")
+	junitPath := filepath.Join(t.TempDir(), "gate.xml")
+
+	captureStdout(t, func() {
+		if code := runCheck(context.Background(), []string{"--level", "quick", "--junit", junitPath, dir}); code != 0 {
+			t.Errorf("expected exit 0, got %d", code)
+		}
+	})
+
+	data, err := os.ReadFile(junitPath)
+	if err != nil {
+		t.Fatalf("expected junit report: %v", err)
+	}
+	if !strings.Contains(string(data), `<testcase name="tests" classname="gate.quick"`) {
+		t.Fatalf("expected tests gate case, got:\n%s", data)
+	}
+}
+
+func TestRunCheck_JUnitWriteError(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "README.md", "nothing to check\n")
+	badPath := filepath.Join(t.TempDir(), "missing", "gate.xml")
+
+	captureStdout(t, func() {
+		if code := runCheck(context.Background(), []string{"--level", "quick", "--junit", badPath, dir}); code != 1 {
+			t.Errorf("expected exit 1 when the report cannot be written, got %d", code)
+		}
+	})
+}
+
 func TestRunCheck_E2E_PrettyOutput(t *testing.T) {
 	dir := t.TempDir()
 	writeTestFile(t, dir, "go.mod", "module prettytest\n\ngo 1.21\n")
@@ -370,10 +496,14 @@ func TestPrintPretty_PassVerdict(t *testing.T) {
 			{Name: "tests", Pass: true, DurationMs: 42},
 			{Name: "lint:go vet", Pass: true, DurationMs: 10},
 		},
+		DurationMs: 45,
 	}
 
 	output := captureStdout(t, func() { printPretty(v) })
 
+	if !strings.Contains(output, "total: 45ms") {
+		t.Errorf("expected total duration in output, got: %s", output)
+	}
 	if !strings.Contains(output, "PASS") {
 		t.Errorf("expected PASS in output, got: %s", output)
 	}
@@ -413,6 +543,57 @@ func TestPrintPretty_FailVerdictShowsOutput(t *testing.T) {
 	}
 }
 
+func TestPrintPretty_FailVerdictListsIssues(t *testing.T) {
+	issues := []verdict.Issue{
+		{Rule: "trace-gaps.no-stderr-capture", Severity: "error", File: "cmd/main.go", Line: 12, Column: 3, Message: "exec without stderr"},
+		{Rule: "bad-defaults.magic-number", Severity: "warning", File: "old.go", Line: 4, PreExisting: true},
+	}
+	for i := 0; i < maxPrettyIssues+2; i++ {
+		issues = append(issues, verdict.Issue{Severity: "info", Message: "noise"})
+	}
+	v := verdict.Verdict{
+		Level: "standard",
+		Repo:  "fail-repo",
+		Gates: []verdict.GateResult{
+			{Name: "truthsayer", Pass: false, Output: "new in diff: errors=1", Issues: issues},
+		},
+	}
+
+	output := captureStdout(t, func() { printPretty(v) })
+
+	if !strings.Contains(output, "error cmd/main.go:12:3 trace-gaps.no-stderr-capture: exec without stderr") {
+		t.Errorf("expected issue line, got: %s", output)
+	}
+	if strings.Contains(output, "old.go") {
+		t.Errorf("pre-existing issues should be hidden, got: %s", output)
+	}
+	if !strings.Contains(output, "... 3 more (see --json)") {
+		t.Errorf("expected truncation note, got: %s", output)
+	}
+}
+
+func TestPrintPretty_IssueAttribution(t *testing.T) {
+	v := verdict.Verdict{
+		Level: "deep",
+		Repo:  "fail-repo",
+		Gates: []verdict.GateResult{
+			{Name: "ubs", Pass: false, Issues: []verdict.Issue{
+				{Severity: "error", File: "lib/db.py", Line: 7, Scanner: "ubs-python", Language: "python", Category: "resource-leak", Message: "file handle never closed"},
+				{Severity: "error", File: "main.go", Line: 3, Language: "golang", Category: "nil-deref"},
+			}},
+		},
+	}
+
+	output := captureStdout(t, func() { printPretty(v) })
+
+	if !strings.Contains(output, "error lib/db.py:7 [ubs-python/python] resource-leak: file handle never closed") {
+		t.Errorf("expected attributed issue, got: %s", output)
+	}
+	if !strings.Contains(output, "error main.go:3 [golang] nil-deref") {
+		t.Errorf("expected language-only attribution, got: %s", output)
+	}
+}
+
 func TestPrintPretty_SkippedGate(t *testing.T) {
 	v := verdict.Verdict{
 		Pass: true,
@@ -451,6 +632,27 @@ func TestPrintPretty_WithBeadID(t *testing.T) {
 	}
 }
 
+func TestPrintPretty_ReviewVerdict(t *testing.T) {
+	v := verdict.Verdict{
+		Pass:     true,
+		Repo:     "review-repo",
+		ExitCode: verdict.ExitReview,
+		Gates: []verdict.GateResult{
+			{Name: "tests", Pass: true},
+			{Name: "fragility", Pass: true, Review: true, Output: "internal/auth: score=0.80 (fragile)"},
+		},
+	}
+
+	output := captureStdout(t, func() { printPretty(v) })
+
+	if !strings.Contains(output, "REVIEW") {
+		t.Errorf("expected REVIEW in output, got: %s", output)
+	}
+	if !strings.Contains(output, "internal/auth: score=0.80") {
+		t.Errorf("expected review gate detail in output, got: %s", output)
+	}
+}
+
 // --- printPrettyCity ---
 
 func TestPrintPrettyCity_PassVerdict(t *testing.T) {
@@ -519,9 +721,9 @@ func TestPrintPrettyCity_FailVerdict(t *testing.T) {
 
 func TestPrintPrettyCity_WithBeadID(t *testing.T) {
 	v := city.Verdict{
-		Status: "pass",
-		Repo:   "bead-city",
-		Bead:   "pol-99",
+		Status:  "pass",
+		Repo:    "bead-city",
+		Bead:    "pol-99",
 		Summary: city.Summary{Pass: 1},
 	}
 
@@ -561,3 +763,24 @@ func TestRunHistory_FlagErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestRunCheck_NoCacheLeavesCacheUntouched(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	dir := t.TempDir()
+	writeTestFile(t, dir, "gate.toml", "[gate]\nschema_version = 1\n\n[levels]\nquick = [\"tests\"]\n\n[tests]\ncommand = [\"true\"]\n")
+	mustRunGit(t, dir, "init", "-q", "-b", "main")
+	mustRunGit(t, dir, "config", "user.email", "gate-tests@example.com")
+	mustRunGit(t, dir, "config", "user.name", "gate-tests")
+	mustRunGit(t, dir, "add", ".")
+	mustRunGit(t, dir, "commit", "-q", "-m", "init")
+
+	captureStdout(t, func() {
+		if code := runCheck(context.Background(), []string{"--level", "quick", "--json", "--no-cache", dir}); code != 0 {
+			t.Errorf("expected exit 0, got %d", code)
+		}
+	})
+	if _, err := os.Stat(filepath.Join(cacheHome, "gate")); !os.IsNotExist(err) {
+		t.Fatalf("--no-cache wrote to the cache: %v", err)
+	}
+}
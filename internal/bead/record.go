@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"slices"
 	"sort"
 	"strings"
 
@@ -19,31 +20,54 @@ var (
 )
 
 // Record creates a bead for a gate check verdict.
-// Fail-only: pass verdicts create no bead (and auto-resolve any open fail bead).
-// Dedup: fail verdicts reuse an existing open fail bead if one exists.
+// Fail/review-only: pass verdicts create no bead (and auto-resolve any open
+// fail or review bead); review verdicts auto-resolve any open fail bead.
+// Dedup: fail and review verdicts reuse an existing open bead of the same
+// status, relabelled with the commits just checked; later runs' flaky tests
+// are added to it as comments.
 func Record(v verdict.Verdict) string {
 	if _, err := lookPath("br"); err != nil {
 		return ""
 	}
 
-	status := "pass"
-	if !v.Pass {
-		status = "fail"
-	}
+	status := checkStatus(v)
 	title := fmt.Sprintf("%s gate %s: %s", v.Repo, v.Level, status)
 
-	// Pass: resolve any open fail bead, create nothing.
-	if v.Pass {
-		resolveOpenFailBead(v.Repo, v.Level, title)
+	switch status {
+	case "pass":
+		// Resolve any open fail or review bead, create nothing.
+		reason := passingReason(title)
+		resolveOpenFailBead(v.Repo, v.Level, reason)
+		resolveOpenBead(v.Repo, v.Level, "review", reason)
 		return ""
+	case "review":
+		// Nothing fails any more, so the fail bead is resolved, but the
+		// gate is not passing either.
+		resolveOpenFailBead(v.Repo, v.Level, fmt.Sprintf("Gate no longer failing (needs review): %s", title))
 	}
 
-	// Fail: deduplicate.
-	if existing := findOpenFailBead(v.Repo, v.Level); existing != "" {
-		return existing
+	// Fail or review: deduplicate against an open bead of the same status,
+	// appending this run's flaky tests so every run's are kept.
+	if existing := findOpenBeadResult(v.Repo, v.Level, status); existing.ID != "" {
+		relabelCommits(existing, v)
+		if flaky := verdict.FlakyTests(v.Gates); len(flaky) > 0 {
+			runCmd("br", "comments", "add", existing.ID, formatFlakyComment(v, flaky))
+		}
+		return existing.ID
 	}
 
 	labels := fmt.Sprintf("tool:gate,status:%s,repo:%s,level:%s", status, v.Repo, v.Level)
+	if validLabelValue(v.Head) {
+		labels += ",head:" + v.Head
+	}
+	if validLabelValue(v.Base) {
+		labels += ",base:" + v.Base
+	}
+	for _, area := range v.Areas {
+		if validLabelValue(area) {
+			labels += ",area:" + area
+		}
+	}
 	description := formatCheckDescription(v)
 	return createWithBR(title, labels, description, v.Citizen)
 }
@@ -60,7 +84,7 @@ func RecordCity(v city.Verdict, citizen string) string {
 
 	// Non-fail (pass/warn): resolve any open fail bead, create nothing.
 	if v.Status != "fail" {
-		resolveOpenFailBead(v.Repo, "", title)
+		resolveOpenFailBead(v.Repo, "", passingReason(title))
 		return ""
 	}
 
@@ -74,14 +98,54 @@ func RecordCity(v city.Verdict, citizen string) string {
 	return createWithBR(title, labels, description, citizen)
 }
 
+// AreaFailures counts past fail beads, open or closed, whose verdict touched
+// area in repo. It returns 0 when br is unavailable.
+func AreaFailures(repo, area string) int {
+	if _, err := lookPath("br"); err != nil {
+		return 0
+	}
+	if !validLabelValue(area) {
+		return 0
+	}
+	total := 0
+	for _, status := range []string{"open", "closed"} {
+		out, err := runCmd("br", "search", "gate",
+			"--label", "tool:gate",
+			"--label", "repo:"+repo,
+			"--label", "status:fail",
+			"--label", "area:"+area,
+			"--status", status,
+			"--json",
+		)
+		if err != nil {
+			continue
+		}
+		var results []brSearchResult
+		if err := json.Unmarshal(out, &results); err == nil {
+			total += len(results)
+		}
+	}
+	return total
+}
+
 // findOpenFailBead searches for an existing open fail bead for the given repo.
 // For check verdicts pass the level; for city verdicts pass "" (searches kind:city instead).
 func findOpenFailBead(repo, level string) string {
+	return findOpenBead(repo, level, "fail")
+}
+
+// findOpenBead searches for an existing open bead with the given status label.
+func findOpenBead(repo, level, status string) string {
+	return findOpenBeadResult(repo, level, status).ID
+}
+
+// findOpenBeadResult is findOpenBead returning the bead's labels too.
+func findOpenBeadResult(repo, level, status string) brSearchResult {
 	args := []string{
 		"search", "gate",
 		"--label", "tool:gate",
 		"--label", "repo:" + repo,
-		"--label", "status:fail",
+		"--label", "status:" + status,
 		"--status", "open",
 		"--json",
 	}
@@ -92,34 +156,73 @@ func findOpenFailBead(repo, level string) string {
 	}
 	out, err := runCmd("br", args...)
 	if err != nil {
-		return ""
+		return brSearchResult{}
+	}
+	return parseFirstBead(string(out))
+}
+
+// relabelCommits points a reused bead's head: and base: labels at the
+// commits of v, replacing those of the run that created it.
+func relabelCommits(b brSearchResult, v verdict.Verdict) {
+	args := []string{"update", b.ID}
+	for _, c := range []struct{ prefix, sha string }{{"head:", v.Head}, {"base:", v.Base}} {
+		if !validLabelValue(c.sha) {
+			continue
+		}
+		want := c.prefix + c.sha
+		if slices.Contains(b.Labels, want) {
+			continue
+		}
+		for _, l := range b.Labels {
+			if strings.HasPrefix(l, c.prefix) {
+				args = append(args, "--remove-label", l)
+			}
+		}
+		args = append(args, "--add-label", want)
+	}
+	if len(args) > 2 {
+		runCmd("br", args...)
 	}
-	return parseFirstBeadID(string(out))
+}
+
+// passingReason is the close reason for a bead resolved by a passing run.
+func passingReason(summary string) string {
+	return fmt.Sprintf("Gate now passing: %s", summary)
 }
 
 // resolveOpenFailBead finds and closes any open fail bead for the given repo.
-func resolveOpenFailBead(repo, level, summary string) {
-	id := findOpenFailBead(repo, level)
+func resolveOpenFailBead(repo, level, reason string) {
+	resolveOpenBead(repo, level, "fail", reason)
+}
+
+// resolveOpenBead finds and closes any open bead with the given status
+// label, giving reason as the close reason.
+func resolveOpenBead(repo, level, status, reason string) {
+	id := findOpenBead(repo, level, status)
 	if id == "" {
 		return
 	}
-	reason := fmt.Sprintf("Gate now passing: %s", summary)
 	runCmd("br", "close", id, "--reason", reason)
 }
 
 type brSearchResult struct {
-	ID string `json:"id"`
+	ID     string   `json:"id"`
+	Labels []string `json:"labels"`
 }
 
 func parseFirstBeadID(jsonOutput string) string {
+	return parseFirstBead(jsonOutput).ID
+}
+
+func parseFirstBead(jsonOutput string) brSearchResult {
 	var results []brSearchResult
 	if err := json.Unmarshal([]byte(jsonOutput), &results); err != nil {
-		return ""
+		return brSearchResult{}
 	}
 	if len(results) == 0 {
-		return ""
+		return brSearchResult{}
 	}
-	return results[0].ID
+	return results[0]
 }
 
 func createWithBR(title, labels, description, citizen string) string {
@@ -146,20 +249,58 @@ func createWithBR(title, labels, description, citizen string) string {
 
 func formatCheckDescription(v verdict.Verdict) string {
 	var lines []string
-	lines = append(lines, fmt.Sprintf("gate check verdict: %s", boolStatus(v.Pass)))
+	lines = append(lines, fmt.Sprintf("gate check verdict: %s", checkStatus(v)))
 	lines = append(lines, fmt.Sprintf("repo: %s", v.Repo))
 	lines = append(lines, fmt.Sprintf("level: %s", v.Level))
+	if v.Head != "" {
+		lines = append(lines, fmt.Sprintf("head: %s", v.Head))
+	}
+	if v.Base != "" {
+		lines = append(lines, fmt.Sprintf("base: %s", v.Base))
+	}
+	if len(v.ReviewReasons) > 0 {
+		lines = append(lines, "review:")
+		for _, r := range v.ReviewReasons {
+			lines = append(lines, "- "+r)
+		}
+	}
+	if flaky := verdict.FlakyTests(v.Gates); len(flaky) > 0 {
+		lines = append(lines, "flaky:")
+		for _, name := range flaky {
+			lines = append(lines, "- "+name)
+		}
+	}
 	lines = append(lines, "checks:")
 	for _, g := range v.Gates {
 		status := boolStatus(g.Pass)
 		if g.Skipped {
 			status = "skip"
 		}
+		if g.Cancelled {
+			status = "cancelled"
+		}
+		if g.Review {
+			status = "review"
+		}
 		lines = append(lines, fmt.Sprintf("- %s: %s (%dms)", g.Name, status, g.DurationMs))
 	}
 	return strings.Join(lines, "\n")
 }
 
+// formatFlakyComment records the flaky tests of a run that reused an open
+// bead, in the description's "flaky:" format.
+func formatFlakyComment(v verdict.Verdict, flaky []string) string {
+	lines := []string{fmt.Sprintf("gate check verdict: %s", checkStatus(v))}
+	if v.Head != "" {
+		lines = append(lines, fmt.Sprintf("head: %s", v.Head))
+	}
+	lines = append(lines, "flaky:")
+	for _, name := range flaky {
+		lines = append(lines, "- "+name)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func formatCityDescription(v city.Verdict) string {
 	var lines []string
 	lines = append(lines, fmt.Sprintf("gate city verdict: %s", v.Status))
@@ -174,6 +315,22 @@ func formatCityDescription(v city.Verdict) string {
 	return strings.Join(lines, "\n")
 }
 
+// validLabelValue rejects values that would split or break a br label list.
+func validLabelValue(v string) bool {
+	return v != "" && !strings.ContainsAny(v, ", \t\n")
+}
+
+// checkStatus maps a check verdict to pass, review, or fail.
+func checkStatus(v verdict.Verdict) string {
+	switch {
+	case v.ExitCode == verdict.ExitReview:
+		return "review"
+	case v.Pass:
+		return "pass"
+	}
+	return "fail"
+}
+
 func boolStatus(pass bool) string {
 	if pass {
 		return "pass"
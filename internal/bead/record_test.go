@@ -128,6 +128,7 @@ func TestFormatCheckDescription_IncludesGates(t *testing.T) {
 			{Name: "tests", Pass: true, DurationMs: 100},
 			{Name: "lint:go vet", Pass: false, DurationMs: 50},
 			{Name: "truthsayer", Pass: true, Skipped: true, DurationMs: 0},
+			{Name: "ubs", Pass: false, Cancelled: true, DurationMs: 5},
 		},
 	}
 
@@ -151,6 +152,27 @@ func TestFormatCheckDescription_IncludesGates(t *testing.T) {
 	if !strings.Contains(out, "- truthsayer: skip") {
 		t.Fatalf("expected truthsayer skip, got: %q", out)
 	}
+	if !strings.Contains(out, "- ubs: cancelled") {
+		t.Fatalf("expected ubs cancelled, got: %q", out)
+	}
+}
+
+func TestFormatCheckDescription_ListsFlakyTests(t *testing.T) {
+	v := verdict.Verdict{
+		Level:         "quick",
+		Repo:          "relay",
+		ExitCode:      verdict.ExitReview,
+		ReviewReasons: []string{"tests: flaky tests: TestRace"},
+		Gates: []verdict.GateResult{{Name: "tests", Pass: true, Review: true, Tests: []verdict.TestCase{
+			{Name: "TestOpen", Suite: "relay/store", Status: verdict.TestPass},
+			{Name: "TestRace", Suite: "relay/store", Status: verdict.TestPass, Attempts: 2, Flaky: true},
+		}}},
+	}
+
+	out := formatCheckDescription(v)
+	if !strings.Contains(out, "flaky:\n- tests: relay/store TestRace\n") {
+		t.Fatalf("expected flaky section, got: %q", out)
+	}
 }
 
 func TestFormatCheckDescription_PassVerdict(t *testing.T) {
@@ -584,3 +606,273 @@ func TestFindOpenFailBead_SearchLabels(t *testing.T) {
 		t.Fatalf("city search should not include level label, got: %s", joined)
 	}
 }
+
+func TestRecord_FailLabelsAreas(t *testing.T) {
+	defer resetHooksForTest()
+
+	var createArgs []string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "search" {
+			return []byte("[]"), nil
+		}
+		createArgs = append([]string{}, args...)
+		return []byte("pol-area\n"), nil
+	}
+
+	Record(verdict.Verdict{
+		Pass:  false,
+		Level: "deep",
+		Repo:  "relay",
+		Areas: []string{"internal/auth", "bad,area", "."},
+		Gates: []verdict.GateResult{{Name: "tests", Pass: false}},
+	})
+
+	joined := strings.Join(createArgs, " ")
+	if !strings.Contains(joined, "area:internal/auth") || !strings.Contains(joined, "area:.") {
+		t.Fatalf("expected area labels, got: %s", joined)
+	}
+	if strings.Contains(joined, "bad,area") {
+		t.Fatalf("area with comma should be dropped, got: %s", joined)
+	}
+}
+
+func TestRecord_FailLabelsCommits(t *testing.T) {
+	defer resetHooksForTest()
+
+	var createArgs []string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "search" {
+			return []byte("[]"), nil
+		}
+		createArgs = append([]string{}, args...)
+		return []byte("pol-rev\n"), nil
+	}
+
+	Record(verdict.Verdict{
+		Pass:  false,
+		Level: "standard",
+		Repo:  "relay",
+		Head:  "1111111111111111111111111111111111111111",
+		Base:  "2222222222222222222222222222222222222222",
+		Gates: []verdict.GateResult{{Name: "tests", Pass: false}},
+	})
+
+	joined := strings.Join(createArgs, " ")
+	for _, want := range []string{
+		"head:1111111111111111111111111111111111111111",
+		"base:2222222222222222222222222222222222222222",
+		"head: 1111111111111111111111111111111111111111",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected %q in create args, got: %s", want, joined)
+		}
+	}
+}
+
+func TestAreaFailures_CountsOpenAndClosed(t *testing.T) {
+	defer resetHooksForTest()
+
+	var searches [][]string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		searches = append(searches, append([]string{}, args...))
+		if strings.Contains(strings.Join(args, " "), "--status open") {
+			return []byte(`[{"id":"pol-1"}]`), nil
+		}
+		return []byte(`[{"id":"pol-2"},{"id":"pol-3"}]`), nil
+	}
+
+	if got := AreaFailures("relay", "internal/auth"); got != 3 {
+		t.Fatalf("expected 3 failures, got %d", got)
+	}
+	if len(searches) != 2 {
+		t.Fatalf("expected 2 searches, got %d", len(searches))
+	}
+	if !strings.Contains(strings.Join(searches[0], " "), "--label area:internal/auth") {
+		t.Fatalf("expected area label in search, got %v", searches[0])
+	}
+}
+
+func TestAreaFailures_NoBR(t *testing.T) {
+	defer resetHooksForTest()
+
+	lookPath = func(name string) (string, error) { return "", errors.New("missing") }
+	if got := AreaFailures("relay", "x"); got != 0 {
+		t.Fatalf("expected 0 without br, got %d", got)
+	}
+}
+
+func TestRecord_ReviewVerdictCreatesReviewBead(t *testing.T) {
+	defer resetHooksForTest()
+
+	var searchArgs, createArgs []string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "search" {
+			searchArgs = append([]string{}, args...)
+			return []byte("[]"), nil
+		}
+		createArgs = append([]string{}, args...)
+		return []byte("pol-review\n"), nil
+	}
+
+	id := Record(verdict.Verdict{
+		Pass:          true,
+		Level:         "deep",
+		Repo:          "relay",
+		ExitCode:      verdict.ExitReview,
+		ReviewReasons: []string{"risk: risk score 50 in review band [40, 75)"},
+		Gates:         []verdict.GateResult{{Name: "risk", Pass: true, Review: true}},
+	})
+
+	if id != "pol-review" {
+		t.Fatalf("expected pol-review, got %q", id)
+	}
+	if !strings.Contains(strings.Join(searchArgs, " "), "--label status:review") {
+		t.Fatalf("expected dedup search on review status, got %v", searchArgs)
+	}
+	joined := strings.Join(createArgs, " ")
+	if !strings.Contains(joined, "relay gate deep: review") || !strings.Contains(joined, "status:review") {
+		t.Fatalf("expected review title and label, got: %s", joined)
+	}
+	if !strings.Contains(joined, "- risk: review") || !strings.Contains(joined, "risk score 50") {
+		t.Fatalf("expected review details in description, got: %s", joined)
+	}
+}
+
+func TestRecord_PassClosesOpenReviewBead(t *testing.T) {
+	defer resetHooksForTest()
+
+	var closed []string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		if len(args) > 0 && args[0] == "search" {
+			if strings.Contains(strings.Join(args, " "), "status:review") {
+				return []byte(`[{"id":"pol-review"}]`), nil
+			}
+			return []byte("[]"), nil
+		}
+		if len(args) > 0 && args[0] == "close" {
+			closed = append(closed, args[1])
+		}
+		return []byte(""), nil
+	}
+
+	Record(verdict.Verdict{Pass: true, Level: "deep", Repo: "relay"})
+
+	if strings.Join(closed, ",") != "pol-review" {
+		t.Fatalf("expected review bead closed, got %v", closed)
+	}
+}
+
+func TestRecord_ReviewClosesOpenFailBead(t *testing.T) {
+	defer resetHooksForTest()
+
+	var closed []string
+	var reason string
+	var created bool
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		switch {
+		case len(args) > 0 && args[0] == "search":
+			if strings.Contains(strings.Join(args, " "), "status:fail") {
+				return []byte(`[{"id":"pol-fail"}]`), nil
+			}
+			return []byte("[]"), nil
+		case len(args) > 0 && args[0] == "close":
+			closed = append(closed, args[1])
+			reason = args[len(args)-1]
+		case len(args) > 0 && args[0] == "create":
+			created = true
+			return []byte("pol-review\n"), nil
+		}
+		return []byte(""), nil
+	}
+
+	id := Record(verdict.Verdict{Pass: true, Level: "deep", Repo: "relay", ExitCode: verdict.ExitReview})
+
+	if strings.Join(closed, ",") != "pol-fail" {
+		t.Fatalf("expected fail bead closed, got %v", closed)
+	}
+	if reason != "Gate no longer failing (needs review): relay gate deep: review" {
+		t.Fatalf("unexpected close reason %q", reason)
+	}
+	if !created || id != "pol-review" {
+		t.Fatalf("expected a review bead, got %q", id)
+	}
+}
+
+func TestRecord_ReviewDedupAppendsFlakyTests(t *testing.T) {
+	defer resetHooksForTest()
+
+	var commentArgs []string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		switch {
+		case len(args) > 0 && args[0] == "search":
+			if strings.Contains(strings.Join(args, " "), "status:review") {
+				return []byte(`[{"id":"pol-review"}]`), nil
+			}
+			return []byte("[]"), nil
+		case len(args) > 0 && args[0] == "comments":
+			commentArgs = append([]string{}, args...)
+		case len(args) > 0 && args[0] == "create":
+			t.Fatalf("second run should reuse the review bead: %v", args)
+		}
+		return []byte(""), nil
+	}
+
+	id := Record(verdict.Verdict{
+		Pass:     true,
+		Level:    "quick",
+		Repo:     "relay",
+		Head:     "abc123",
+		ExitCode: verdict.ExitReview,
+		Gates: []verdict.GateResult{{Name: "tests", Pass: true, Review: true, Tests: []verdict.TestCase{
+			{Name: "TestRace", Suite: "relay/store", Status: verdict.TestPass, Attempts: 2, Flaky: true},
+		}}},
+	})
+
+	if id != "pol-review" {
+		t.Fatalf("expected pol-review, got %q", id)
+	}
+	want := []string{"comments", "add", "pol-review", "gate check verdict: review\nhead: abc123\nflaky:\n- tests: relay/store TestRace"}
+	if strings.Join(commentArgs, "|") != strings.Join(want, "|") {
+		t.Fatalf("comment args = %q, want %q", commentArgs, want)
+	}
+}
+
+func TestRecord_FailDedupRelabelsCommits(t *testing.T) {
+	defer resetHooksForTest()
+
+	var updateArgs []string
+	lookPath = func(name string) (string, error) { return "/usr/bin/br", nil }
+	runCmd = func(name string, args ...string) ([]byte, error) {
+		switch {
+		case len(args) > 0 && args[0] == "search":
+			return []byte(`[{"id":"pol-fail","labels":["tool:gate","status:fail","head:1111111","base:2222222"]}]`), nil
+		case len(args) > 0 && args[0] == "update":
+			updateArgs = append([]string{}, args...)
+		case len(args) > 0 && args[0] == "create":
+			t.Fatalf("should reuse the fail bead: %v", args)
+		}
+		return []byte(""), nil
+	}
+
+	id := Record(verdict.Verdict{Level: "standard", Repo: "relay", Head: "3333333", Base: "2222222", ExitCode: verdict.ExitFail})
+	if id != "pol-fail" {
+		t.Fatalf("expected pol-fail, got %q", id)
+	}
+	want := "update pol-fail --remove-label head:1111111 --add-label head:3333333"
+	if got := strings.Join(updateArgs, " "); got != want {
+		t.Fatalf("update args = %q, want %q", got, want)
+	}
+
+	updateArgs = nil
+	Record(verdict.Verdict{Level: "standard", Repo: "relay", Head: "1111111", Base: "2222222", ExitCode: verdict.ExitFail})
+	if updateArgs != nil {
+		t.Fatalf("labels already match, got update %v", updateArgs)
+	}
+}
@@ -0,0 +1,154 @@
+// Package cache keeps gate results on disk, keyed by everything that can
+// change them, so repeated checks of the same tree reuse earlier work.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// DefaultMaxAge is how long `gate cache prune` keeps entries by default.
+const DefaultMaxAge = 7 * 24 * time.Hour
+
+// Store is a directory of cached gate results.
+type Store struct {
+	dir string
+}
+
+// entry is one cached result as stored on disk.
+type entry struct {
+	Key    string             `json:"key"`
+	Stored time.Time          `json:"stored"`
+	Result verdict.GateResult `json:"result"`
+}
+
+// PruneStats reports what Prune removed.
+type PruneStats struct {
+	Removed int
+	Bytes   int64
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/gate, falling back to the platform
+// user cache directory.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gate"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gate"), nil
+}
+
+// Open returns the store rooted at dir. The directory is created on the
+// first Put.
+func Open(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir is the store's root directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key hashes the JSON encoding of parts into a cache key.
+func Key(parts ...any) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, p := range parts {
+		if err := enc.Encode(p); err != nil {
+			// Only unencodable values such as channels fail; they never
+			// identify an input, so fold in their type instead.
+			fmt.Fprintf(h, "%T\n", p)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the result cached under key, marked as cached.
+func (s *Store) Get(key string) (verdict.GateResult, bool) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		return verdict.GateResult{}, false
+	}
+	var e entry
+	if json.Unmarshal(data, &e) != nil || e.Key != key {
+		return verdict.GateResult{}, false
+	}
+	e.Result.Cached = true
+	return e.Result, true
+}
+
+// Put stores r under key, replacing any earlier entry. The write is atomic,
+// so concurrent checks never read a partial entry.
+func (s *Store) Put(key string, r verdict.GateResult) error {
+	r.Cached = false
+	data, err := json.Marshal(entry{Key: key, Stored: time.Now().UTC(), Result: r})
+	if err != nil {
+		return err
+	}
+	target := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Prune removes entries stored more than maxAge ago, along with temp files
+// left by interrupted writes; zero removes everything.
+func (s *Store) Prune(maxAge time.Duration) (PruneStats, error) {
+	var stats PruneStats
+	cutoff := time.Now().Add(-maxAge)
+	root := filepath.Join(s.dir, "results")
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if maxAge > 0 && info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		stats.Removed++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, "results", key[:2], key+".json")
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func committedRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	gitRun(t, dir, "config", "user.email", "gate-tests@example.com")
+	gitRun(t, dir, "config", "user.name", "gate-tests")
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("build/\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644)
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "init")
+	return dir
+}
+
+func TestStore_PutGetPrune(t *testing.T) {
+	s := Open(t.TempDir())
+	key := Key("tree", "quick", "tests")
+	if _, ok := s.Get(key); ok {
+		t.Fatal("expected miss on empty store")
+	}
+
+	if err := s.Put(key, verdict.GateResult{Name: "tests", Pass: true, Output: "ok", DurationMs: 42}); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := s.Get(key)
+	if !ok || !r.Cached || r.Name != "tests" || !r.Pass || r.DurationMs != 42 {
+		t.Fatalf("unexpected cached result %+v (hit=%v)", r, ok)
+	}
+	if Key("tree", "quick", "lint") == key {
+		t.Fatal("different inputs produced the same key")
+	}
+
+	// Recent entries survive an age-based prune.
+	stats, err := s.Prune(time.Hour)
+	if err != nil || stats.Removed != 0 {
+		t.Fatalf("prune = %+v, %v", stats, err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(s.path(key), old, old)
+	stats, err = s.Prune(time.Hour)
+	if err != nil || stats.Removed != 1 || stats.Bytes == 0 {
+		t.Fatalf("prune = %+v, %v", stats, err)
+	}
+	if _, ok := s.Get(key); ok {
+		t.Fatal("expected pruned entry to be gone")
+	}
+
+	s.Put(key, verdict.GateResult{Name: "tests"})
+	if stats, err := s.Prune(0); err != nil || stats.Removed != 1 {
+		t.Fatalf("prune all = %+v, %v", stats, err)
+	}
+}
+
+func TestStore_PruneMissingDir(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "never-created"))
+	if stats, err := s.Prune(0); err != nil || stats.Removed != 0 {
+		t.Fatalf("prune = %+v, %v", stats, err)
+	}
+}
+
+func TestReadRepoState_TracksWorkingTree(t *testing.T) {
+	dir := committedRepo(t)
+	ctx := context.Background()
+
+	clean, err := ReadRepoState(ctx, dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clean.Tree != gitRun(t, dir, "rev-parse", "HEAD^{tree}") {
+		t.Fatalf("clean tree %s should match HEAD's tree", clean.Tree)
+	}
+	if clean.Head == "" || clean.Base != clean.Head {
+		t.Fatalf("expected head and main base, got %+v", clean)
+	}
+
+	// Ignored files do not change the tree.
+	os.MkdirAll(filepath.Join(dir, "build"), 0o755)
+	os.WriteFile(filepath.Join(dir, "build", "out.bin"), []byte("artifact"), 0o644)
+	if st, _ := ReadRepoState(ctx, dir, ""); st.Tree != clean.Tree {
+		t.Fatal("ignored file changed the tree")
+	}
+
+	// Edits and untracked files do.
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644)
+	edited, _ := ReadRepoState(ctx, dir, "")
+	if edited.Tree == clean.Tree {
+		t.Fatal("edit did not change the tree")
+	}
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0o644)
+	untracked, _ := ReadRepoState(ctx, dir, "")
+	if untracked.Tree == edited.Tree {
+		t.Fatal("untracked file did not change the tree")
+	}
+
+	// The repo's own index is left alone.
+	if staged := gitRun(t, dir, "diff", "--cached", "--name-only"); staged != "" {
+		t.Fatalf("index was modified: %q", staged)
+	}
+}
+
+func TestReadRepoState_NotARepo(t *testing.T) {
+	if _, err := ReadRepoState(context.Background(), t.TempDir(), ""); err == nil {
+		t.Fatal("expected an error outside a git repo")
+	}
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds each git call behind ReadRepoState.
+const gitTimeout = 60 * time.Second
+
+// defaultBaseRefs are tried, in order, when the config names no diff base;
+// they match the diff-aware gates.
+var defaultBaseRefs = []string{"main", "master"}
+
+// RepoState identifies the code a check sees.
+type RepoState struct {
+	// Tree is the git tree of the working copy, including uncommitted
+	// changes and untracked files that are not ignored.
+	Tree string `json:"tree"`
+	// Head and Base are the commits diff-aware gates compare.
+	Head string `json:"head"`
+	Base string `json:"base,omitempty"`
+}
+
+// ReadRepoState hashes the working tree of the git repo at dir without
+// touching its index: files are staged into a scratch copy of the index and
+// written as a tree. baseRef is the configured diff base; empty tries main,
+// then master.
+func ReadRepoState(ctx context.Context, dir, baseRef string) (RepoState, error) {
+	var st RepoState
+	head, err := git(ctx, dir, nil, "rev-parse", "--verify", "-q", "HEAD^{commit}")
+	if err != nil {
+		return st, fmt.Errorf("no commit to key the cache on: %w", err)
+	}
+	st.Head = head
+
+	refs := defaultBaseRefs
+	if baseRef != "" {
+		refs = []string{baseRef}
+	}
+	for _, ref := range refs {
+		if sha, err := git(ctx, dir, nil, "rev-parse", "--verify", "-q", ref+"^{commit}"); err == nil {
+			st.Base = sha
+			break
+		}
+	}
+
+	index, err := git(ctx, dir, nil, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return st, err
+	}
+	scratch, err := os.CreateTemp("", "gate-index-")
+	if err != nil {
+		return st, err
+	}
+	defer os.Remove(scratch.Name())
+	// Starting from the real index keeps git's stat cache, so unchanged
+	// files are not rehashed.
+	if src, err := os.Open(index); err == nil {
+		_, err = io.Copy(scratch, src)
+		src.Close()
+		if err != nil {
+			scratch.Close()
+			return st, err
+		}
+	}
+	if err := scratch.Close(); err != nil {
+		return st, err
+	}
+	if _, err := os.Stat(index); err != nil {
+		// git rejects an empty index file but builds one from nothing.
+		os.Remove(scratch.Name())
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + scratch.Name()}
+	if _, err := git(ctx, dir, env, "add", "--all"); err != nil {
+		return st, err
+	}
+	if st.Tree, err = git(ctx, dir, env, "write-tree"); err != nil {
+		return st, err
+	}
+	return st, nil
+}
+
+// ToolStamp identifies the installed version of each tool by the path,
+// size, and modification time of its binary, without running it. Missing
+// tools stamp as absent.
+func ToolStamp(tools ...string) map[string]string {
+	stamp := make(map[string]string, len(tools))
+	for _, t := range tools {
+		p, err := exec.LookPath(t)
+		if err != nil {
+			stamp[t] = "absent"
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			stamp[t] = "absent"
+			continue
+		}
+		stamp[t] = fmt.Sprintf("%s:%d:%d", p, info.Size(), info.ModTime().UnixNano())
+	}
+	return stamp
+}
+
+// FileDigest identifies the content of each file under dir, for inputs
+// such as ignored reports that the repo state does not cover. Files are
+// named relative to dir so copies of the repo share digests; unreadable
+// ones digest as absent.
+func FileDigest(dir string, paths ...string) map[string]string {
+	digest := make(map[string]string, len(paths))
+	for _, p := range paths {
+		name := p
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			digest[name] = "absent"
+			continue
+		}
+		sum := sha256.Sum256(data)
+		digest[name] = hex.EncodeToString(sum[:])
+	}
+	return digest
+}
+
+func git(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,775 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// FileName is the optional per-repo override file, read from the repo root.
+const FileName = "gate.toml"
+
+// maxTestRetries bounds tests.retries so a broken test cannot stall a check.
+const maxTestRetries = 5
+
+// SchemaVersion is the only gate.toml schema this build understands.
+const SchemaVersion = 1
+
+// DefaultConcurrency is how many gates run at once when gate.toml does not say.
+const DefaultConcurrency = 4
+
+// Gate names that may appear in [levels].
+const (
+	GateTests      = "tests"
+	GateLint       = "lint"
+	GateTruthsayer = "truthsayer"
+	GateUBS        = "ubs"
+	GateRisk       = "risk"
+	GateFragility  = "fragility"
+	GateCoverage   = "coverage"
+	GateFormat     = "format"
+)
+
+// Level names that may appear in [levels].
+const (
+	LevelQuick    = "quick"
+	LevelStandard = "standard"
+	LevelDeep     = "deep"
+)
+
+var knownGates = []string{GateTests, GateLint, GateTruthsayer, GateUBS, GateRisk, GateFragility, GateCoverage, GateFormat}
+
+var knownLevels = []string{LevelQuick, LevelStandard, LevelDeep}
+
+// Output parsers a custom gate may use.
+const (
+	ParserExit  = "exit"  // exit code only
+	ParserJSON  = "json"  // JSON findings
+	ParserSARIF = "sarif" // SARIF 2.1.0 log
+	ParserTAP   = "tap"   // Test Anything Protocol
+)
+
+var knownParsers = []string{ParserExit, ParserJSON, ParserSARIF, ParserTAP}
+
+// extraNameRe limits custom gate and plugin names to what reads well as a
+// gate, a bead label, and a binary name.
+var extraNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+type rawFile struct {
+	Gate       rawGate             `toml:"gate"`
+	Levels     map[string][]string `toml:"levels"`
+	Tests      rawTests            `toml:"tests"`
+	Lint       rawLint             `toml:"lint"`
+	Truthsayer rawScanner          `toml:"truthsayer"`
+	UBS        rawScanner          `toml:"ubs"`
+	Diff       rawDiff             `toml:"diff"`
+	Risk       rawRisk             `toml:"risk"`
+	Fragility  rawFragility        `toml:"fragility"`
+	Coverage   rawCoverage         `toml:"coverage"`
+	Format     rawFormat           `toml:"format"`
+	Custom     []rawCustom         `toml:"custom"`
+	Plugins    []rawPlugin         `toml:"plugin"`
+}
+
+type rawGate struct {
+	SchemaVersion *int     `toml:"schema_version"`
+	Required      []string `toml:"required"`
+	Concurrency   *int     `toml:"concurrency"`
+}
+
+type rawTests struct {
+	Command []string `toml:"command"`
+	Timeout string   `toml:"timeout"`
+	JUnit   []string `toml:"junit"`
+	Retries *int     `toml:"retries"`
+}
+
+type rawLint struct {
+	Timeout string      `toml:"timeout"`
+	Add     []rawLinter `toml:"add"`
+	Remove  []string    `toml:"remove"`
+}
+
+type rawLinter struct {
+	Name    string   `toml:"name"`
+	Command []string `toml:"command"`
+}
+
+type rawScanner struct {
+	Timeout        string `toml:"timeout"`
+	ReviewWarnings *int   `toml:"review_warnings"`
+}
+
+type rawDiff struct {
+	Base string `toml:"base"`
+}
+
+type rawRisk struct {
+	Timeout   string   `toml:"timeout"`
+	FailAt    *int     `toml:"fail_at"`
+	ReviewAt  *int     `toml:"review_at"`
+	Sensitive []string `toml:"sensitive"`
+}
+
+type rawFragility struct {
+	Timeout    string   `toml:"timeout"`
+	WindowDays *int     `toml:"window_days"`
+	Threshold  *float64 `toml:"threshold"`
+	MinSamples *int     `toml:"min_samples"`
+}
+
+type rawCoverage struct {
+	Timeout    string   `toml:"timeout"`
+	Min        *float64 `toml:"min"`
+	ChangedMin *float64 `toml:"changed_min"`
+	Reports    []string `toml:"reports"`
+}
+
+type rawFormat struct {
+	Timeout string `toml:"timeout"`
+}
+
+type rawCustom struct {
+	Name     string   `toml:"name"`
+	Command  []string `toml:"command"`
+	Dir      string   `toml:"dir"`
+	Timeout  string   `toml:"timeout"`
+	Levels   []string `toml:"levels"`
+	Required *bool    `toml:"required"`
+	Parser   string   `toml:"parser"`
+}
+
+type rawPlugin struct {
+	Name     string   `toml:"name"`
+	Path     string   `toml:"path"`
+	Timeout  string   `toml:"timeout"`
+	Levels   []string `toml:"levels"`
+	Required *bool    `toml:"required"`
+	Enabled  *bool    `toml:"enabled"`
+}
+
+// Config is validated gate.toml data merged over the built-in defaults.
+type Config struct {
+	SchemaVersion int
+	// Required lists gates that must actually run; a skipped required gate
+	// puts the verdict in review.
+	Required []string
+	// Concurrency caps how many gates run at once.
+	Concurrency int
+	Levels      map[string][]string
+	Tests       Tests
+	Lint        Lint
+	Truthsayer  Scanner
+	UBS         Scanner
+	Diff        Diff
+	Risk        Risk
+	Fragility   Fragility
+	Coverage    Coverage
+	Format      Format
+	// Custom lists the project-specific gates declared in [[custom]], in
+	// file order.
+	Custom []CustomGate
+	// Plugins lists the external gate plugins declared in [[plugin]], in
+	// file order, followed by any added by AddDiscoveredPlugins.
+	Plugins []Plugin
+}
+
+// Tests overrides the tests gate.
+type Tests struct {
+	// Command replaces the auto-detected test command when non-empty.
+	Command    []string
+	TimeoutSec int
+	// JUnit lists repo-relative globs where the test runner writes JUnit
+	// XML reports. Empty uses the common default locations.
+	JUnit []string
+	// Retries reruns failing tests up to this many times; tests that pass
+	// on retry are flaky and put the verdict in review. Zero disables it.
+	Retries int
+}
+
+// Lint overrides the auto-detected linter set.
+type Lint struct {
+	TimeoutSec int
+	Add        []Linter
+	Remove     []string
+}
+
+// Linter is an extra linter declared in [[lint.add]].
+type Linter struct {
+	Name    string
+	Command []string
+}
+
+// Scanner holds settings shared by the truthsayer and ubs gates.
+type Scanner struct {
+	TimeoutSec int
+	// ReviewWarnings puts a passing scan in review when it reports more
+	// warnings than this. Zero disables the check.
+	ReviewWarnings int
+}
+
+// Diff controls how diff-aware gates find the change under review.
+type Diff struct {
+	// Base is the ref whose merge base with HEAD starts the diff.
+	// Empty tries main, then master.
+	Base string
+}
+
+// Risk controls the deep-level risk gate.
+type Risk struct {
+	TimeoutSec int
+	// FailAt is the score (0-100) at or above which the risk gate fails.
+	FailAt int
+	// ReviewAt is the score at or above which a passing risk gate needs review.
+	ReviewAt int
+	// Sensitive lists extra path prefixes treated as sensitive.
+	Sensitive []string
+}
+
+// Fragility controls the deep-level fragility gate.
+type Fragility struct {
+	TimeoutSec int
+	// WindowDays limits git history to the last N days.
+	WindowDays int
+	// Threshold is the score (0-1) at or above which an area is fragile.
+	Threshold float64
+	// MinSamples is the fewest commits plus gate verdicts needed to judge an area.
+	MinSamples int
+}
+
+// Coverage controls the coverage gate.
+type Coverage struct {
+	TimeoutSec int
+	// Min is the total coverage percent below which the gate fails. Zero
+	// only reports coverage.
+	Min float64
+	// ChangedMin is the minimum percent for lines changed since the merge
+	// base with diff.base. Zero disables the check.
+	ChangedMin float64
+	// Reports lists repo-relative globs of Cobertura XML or lcov reports,
+	// merged with Go coverage. Empty checks the common default locations.
+	Reports []string
+}
+
+// Format controls the format gate.
+type Format struct {
+	TimeoutSec int
+}
+
+// CustomGate is a project-specific command declared in [[custom]].
+type CustomGate struct {
+	Name    string
+	Command []string
+	// Dir is the repo-relative working directory; empty is the repo root.
+	Dir        string
+	TimeoutSec int
+	// Levels lists the levels the gate runs at.
+	Levels []string
+	// Required gates fail the verdict when they fail and need review when
+	// their command is missing. Optional gates only put a failure in review.
+	Required bool
+	// Parser is how the output is read: exit, json, sarif, or tap.
+	Parser string
+}
+
+// Plugin is an external gate binary declared in [[plugin]].
+type Plugin struct {
+	Name string
+	// Path is the plugin binary, absolute or repo-relative. Empty finds
+	// gate-plugin-<name> on PATH.
+	Path       string
+	TimeoutSec int
+	// Levels lists the levels the plugin runs at.
+	Levels []string
+	// Required has the same meaning as for custom gates.
+	Required bool
+	// Disabled plugins never run, even when found on PATH.
+	Disabled bool
+}
+
+// AddDiscoveredPlugins adds the plugins found on PATH that gate.toml does
+// not declare, as optional plugins at every level with the default timeout.
+// Names that are not valid gate names, or that a custom gate already uses,
+// are ignored.
+func (c *Config) AddDiscoveredPlugins(names []string) {
+	for _, name := range names {
+		if _, err := parseExtraName("plugin", name); err != nil {
+			continue
+		}
+		if _, ok := c.CustomGate(name); ok {
+			continue
+		}
+		if _, ok := c.Plugin(name); ok {
+			continue
+		}
+		c.Plugins = append(c.Plugins, Plugin{Name: name, TimeoutSec: 60, Levels: append([]string(nil), knownLevels...)})
+	}
+}
+
+// CustomGate returns the custom gate with the given name.
+func (c Config) CustomGate(name string) (CustomGate, bool) {
+	for _, g := range c.Custom {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return CustomGate{}, false
+}
+
+// Plugin returns the plugin with the given name.
+func (c Config) Plugin(name string) (Plugin, bool) {
+	for _, p := range c.Plugins {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Plugin{}, false
+}
+
+// ContractError marks a malformed gate.toml.
+type ContractError struct {
+	Msg string
+}
+
+func (e ContractError) Error() string {
+	return e.Msg
+}
+
+// Default returns the zero-config settings used when gate.toml is absent.
+func Default() Config {
+	return Config{
+		SchemaVersion: SchemaVersion,
+		Concurrency:   DefaultConcurrency,
+		Levels: map[string][]string{
+			LevelQuick:    {GateTests, GateLint},
+			LevelStandard: {GateTests, GateLint, GateTruthsayer, GateUBS},
+			LevelDeep:     {GateTests, GateLint, GateTruthsayer, GateUBS, GateRisk, GateFragility},
+		},
+		Tests:      Tests{TimeoutSec: 120},
+		Lint:       Lint{TimeoutSec: 60},
+		Truthsayer: Scanner{TimeoutSec: 60},
+		UBS:        Scanner{TimeoutSec: 60},
+		Risk:       Risk{TimeoutSec: 60, FailAt: 75, ReviewAt: 40},
+		Fragility:  Fragility{TimeoutSec: 60, WindowDays: 90, Threshold: 0.5, MinSamples: 4},
+		Coverage:   Coverage{TimeoutSec: 300},
+		Format:     Format{TimeoutSec: 60},
+	}
+}
+
+// Load reads gate.toml from repoPath. A missing file yields Default().
+func Load(repoPath string) (Config, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: %v", err)}
+	}
+	return Parse(data)
+}
+
+// Parse validates gate.toml contents and merges them over Default().
+func Parse(data []byte) (Config, error) {
+	var raw rawFile
+	dec := toml.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		var strict *toml.StrictMissingError
+		if errors.As(err, &strict) {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: unknown key %s", unknownKeys(strict))}
+		}
+		return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml TOML: %v", err)}
+	}
+
+	if raw.Gate.SchemaVersion == nil {
+		return Config{}, ContractError{Msg: "invalid gate.toml: [gate].schema_version is required"}
+	}
+	if *raw.Gate.SchemaVersion != SchemaVersion {
+		return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: unsupported schema_version %d (expected %d)", *raw.Gate.SchemaVersion, SchemaVersion)}
+	}
+
+	cfg := Default()
+
+	for _, n := range raw.Gate.Required {
+		n = strings.TrimSpace(n)
+		if !contains(knownGates, n) {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: unknown gate %q in gate.required", n)}
+		}
+		cfg.Required = append(cfg.Required, n)
+	}
+
+	if raw.Gate.Concurrency != nil {
+		if *raw.Gate.Concurrency < 1 {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml gate.concurrency %d: must be at least 1", *raw.Gate.Concurrency)}
+		}
+		cfg.Concurrency = *raw.Gate.Concurrency
+	}
+
+	for level, names := range raw.Levels {
+		if !contains(knownLevels, level) {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: unknown level %q in [levels]", level)}
+		}
+		seen := make(map[string]bool, len(names))
+		list := make([]string, 0, len(names))
+		for _, n := range names {
+			n = strings.TrimSpace(n)
+			if !contains(knownGates, n) {
+				return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: unknown gate %q in levels.%s", n, level)}
+			}
+			if seen[n] {
+				return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml: duplicate gate %q in levels.%s", n, level)}
+			}
+			seen[n] = true
+			list = append(list, n)
+		}
+		cfg.Levels[level] = list
+	}
+
+	if len(raw.Tests.Command) > 0 {
+		cmd, err := normalizeCommand(raw.Tests.Command)
+		if err != nil {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml tests.command: %v", err)}
+		}
+		cfg.Tests.Command = cmd
+	}
+	if err := applyTimeout(&cfg.Tests.TimeoutSec, raw.Tests.Timeout, "tests.timeout"); err != nil {
+		return Config{}, err
+	}
+	for _, p := range raw.Tests.JUnit {
+		norm := strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+		if norm == "" || strings.HasPrefix(norm, "/") {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml tests.junit entry %q: must be a relative path or glob", p)}
+		}
+		if _, err := path.Match(norm, ""); err != nil {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml tests.junit entry %q: %v", p, err)}
+		}
+		cfg.Tests.JUnit = append(cfg.Tests.JUnit, norm)
+	}
+	if raw.Tests.Retries != nil {
+		if *raw.Tests.Retries < 0 || *raw.Tests.Retries > maxTestRetries {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml tests.retries %d: must be between 0 and %d", *raw.Tests.Retries, maxTestRetries)}
+		}
+		cfg.Tests.Retries = *raw.Tests.Retries
+	}
+
+	if err := applyTimeout(&cfg.Lint.TimeoutSec, raw.Lint.Timeout, "lint.timeout"); err != nil {
+		return Config{}, err
+	}
+	names := make(map[string]bool, len(raw.Lint.Add))
+	for _, l := range raw.Lint.Add {
+		name := strings.TrimSpace(l.Name)
+		if name == "" {
+			return Config{}, ContractError{Msg: "invalid gate.toml lint.add: name is required"}
+		}
+		if names[name] {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml lint.add: duplicate linter %q", name)}
+		}
+		names[name] = true
+		cmd, err := normalizeCommand(l.Command)
+		if err != nil {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml lint.add %q: command %v", name, err)}
+		}
+		cfg.Lint.Add = append(cfg.Lint.Add, Linter{Name: name, Command: cmd})
+	}
+	for _, r := range raw.Lint.Remove {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			return Config{}, ContractError{Msg: "invalid gate.toml lint.remove: linter name cannot be empty"}
+		}
+		cfg.Lint.Remove = append(cfg.Lint.Remove, r)
+	}
+
+	if err := applyScanner(&cfg.Truthsayer, raw.Truthsayer, "truthsayer"); err != nil {
+		return Config{}, err
+	}
+	if err := applyScanner(&cfg.UBS, raw.UBS, "ubs"); err != nil {
+		return Config{}, err
+	}
+
+	cfg.Diff.Base = strings.TrimSpace(raw.Diff.Base)
+
+	if err := applyTimeout(&cfg.Risk.TimeoutSec, raw.Risk.Timeout, "risk.timeout"); err != nil {
+		return Config{}, err
+	}
+	if raw.Risk.FailAt != nil {
+		if *raw.Risk.FailAt < 1 || *raw.Risk.FailAt > 100 {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml risk.fail_at %d: must be between 1 and 100", *raw.Risk.FailAt)}
+		}
+		cfg.Risk.FailAt = *raw.Risk.FailAt
+	}
+	if raw.Risk.ReviewAt != nil {
+		if *raw.Risk.ReviewAt < 1 || *raw.Risk.ReviewAt > 100 {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml risk.review_at %d: must be between 1 and 100", *raw.Risk.ReviewAt)}
+		}
+		cfg.Risk.ReviewAt = *raw.Risk.ReviewAt
+	}
+	if cfg.Risk.ReviewAt >= cfg.Risk.FailAt {
+		return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml risk.review_at %d: must be below risk.fail_at %d", cfg.Risk.ReviewAt, cfg.Risk.FailAt)}
+	}
+	for _, p := range raw.Risk.Sensitive {
+		norm := strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+		if norm == "" || strings.HasPrefix(norm, "/") {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml risk.sensitive entry %q: must be a relative path prefix", p)}
+		}
+		cfg.Risk.Sensitive = append(cfg.Risk.Sensitive, norm)
+	}
+
+	if err := applyTimeout(&cfg.Fragility.TimeoutSec, raw.Fragility.Timeout, "fragility.timeout"); err != nil {
+		return Config{}, err
+	}
+	if raw.Fragility.WindowDays != nil {
+		if *raw.Fragility.WindowDays < 1 {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml fragility.window_days %d: must be at least 1", *raw.Fragility.WindowDays)}
+		}
+		cfg.Fragility.WindowDays = *raw.Fragility.WindowDays
+	}
+	if raw.Fragility.Threshold != nil {
+		if *raw.Fragility.Threshold <= 0 || *raw.Fragility.Threshold > 1 {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml fragility.threshold %v: must be in (0, 1]", *raw.Fragility.Threshold)}
+		}
+		cfg.Fragility.Threshold = *raw.Fragility.Threshold
+	}
+	if raw.Fragility.MinSamples != nil {
+		if *raw.Fragility.MinSamples < 1 {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml fragility.min_samples %d: must be at least 1", *raw.Fragility.MinSamples)}
+		}
+		cfg.Fragility.MinSamples = *raw.Fragility.MinSamples
+	}
+
+	if err := applyTimeout(&cfg.Coverage.TimeoutSec, raw.Coverage.Timeout, "coverage.timeout"); err != nil {
+		return Config{}, err
+	}
+	if err := applyPercent(&cfg.Coverage.Min, raw.Coverage.Min, "coverage.min"); err != nil {
+		return Config{}, err
+	}
+	if err := applyPercent(&cfg.Coverage.ChangedMin, raw.Coverage.ChangedMin, "coverage.changed_min"); err != nil {
+		return Config{}, err
+	}
+	for _, p := range raw.Coverage.Reports {
+		norm := strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+		if norm == "" || strings.HasPrefix(norm, "/") {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml coverage.reports entry %q: must be a relative path or glob", p)}
+		}
+		if _, err := path.Match(norm, ""); err != nil {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml coverage.reports entry %q: %v", p, err)}
+		}
+		cfg.Coverage.Reports = append(cfg.Coverage.Reports, norm)
+	}
+
+	if err := applyTimeout(&cfg.Format.TimeoutSec, raw.Format.Timeout, "format.timeout"); err != nil {
+		return Config{}, err
+	}
+
+	// Custom gates and plugins share one namespace.
+	seen := make(map[string]bool, len(raw.Custom)+len(raw.Plugins))
+	for _, rc := range raw.Custom {
+		g, err := parseCustom(rc)
+		if err != nil {
+			return Config{}, err
+		}
+		if seen[g.Name] {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml custom: duplicate gate %q", g.Name)}
+		}
+		seen[g.Name] = true
+		cfg.Custom = append(cfg.Custom, g)
+	}
+	for _, rp := range raw.Plugins {
+		p, err := parsePlugin(rp)
+		if err != nil {
+			return Config{}, err
+		}
+		if seen[p.Name] {
+			return Config{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml plugin: duplicate gate %q", p.Name)}
+		}
+		seen[p.Name] = true
+		cfg.Plugins = append(cfg.Plugins, p)
+	}
+
+	return cfg, nil
+}
+
+// parseCustom validates one [[custom]] entry. Unset fields default to the
+// repo root, a 60s timeout, every level, required, and the exit parser.
+func parseCustom(rc rawCustom) (CustomGate, error) {
+	name, err := parseExtraName("custom", rc.Name)
+	if err != nil {
+		return CustomGate{}, err
+	}
+	key := "custom." + name
+
+	g := CustomGate{Name: name, TimeoutSec: 60, Required: true, Parser: ParserExit}
+	cmd, err := normalizeCommand(rc.Command)
+	if err != nil {
+		return CustomGate{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml %s.command: %v", key, err)}
+	}
+	g.Command = cmd
+
+	if d := strings.TrimSpace(strings.ReplaceAll(rc.Dir, "\\", "/")); d != "" {
+		clean := path.Clean(d)
+		if strings.HasPrefix(d, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+			return CustomGate{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml %s.dir %q: must be a path inside the repo", key, rc.Dir)}
+		}
+		if clean != "." {
+			g.Dir = clean
+		}
+	}
+
+	if err := applyTimeout(&g.TimeoutSec, rc.Timeout, key+".timeout"); err != nil {
+		return CustomGate{}, err
+	}
+	if g.Levels, err = parseExtraLevels(rc.Levels, key); err != nil {
+		return CustomGate{}, err
+	}
+	if rc.Required != nil {
+		g.Required = *rc.Required
+	}
+
+	if p := strings.ToLower(strings.TrimSpace(rc.Parser)); p != "" {
+		if !contains(knownParsers, p) {
+			return CustomGate{}, ContractError{Msg: fmt.Sprintf("invalid gate.toml %s.parser %q: use exit, json, sarif, or tap", key, rc.Parser)}
+		}
+		g.Parser = p
+	}
+	return g, nil
+}
+
+// parsePlugin validates one [[plugin]] entry. Unset fields default to
+// gate-plugin-<name> on PATH, a 60s timeout, every level, required, and
+// enabled.
+func parsePlugin(rp rawPlugin) (Plugin, error) {
+	name, err := parseExtraName("plugin", rp.Name)
+	if err != nil {
+		return Plugin{}, err
+	}
+	key := "plugin." + name
+
+	p := Plugin{Name: name, Path: strings.TrimSpace(rp.Path), TimeoutSec: 60, Required: true}
+	if err := applyTimeout(&p.TimeoutSec, rp.Timeout, key+".timeout"); err != nil {
+		return Plugin{}, err
+	}
+	if p.Levels, err = parseExtraLevels(rp.Levels, key); err != nil {
+		return Plugin{}, err
+	}
+	if rp.Required != nil {
+		p.Required = *rp.Required
+	}
+	if rp.Enabled != nil {
+		p.Disabled = !*rp.Enabled
+	}
+	return p, nil
+}
+
+// parseExtraName validates the name of a custom gate or plugin.
+func parseExtraName(section, raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return "", ContractError{Msg: fmt.Sprintf("invalid gate.toml %s: name is required", section)}
+	}
+	if !extraNameRe.MatchString(name) {
+		return "", ContractError{Msg: fmt.Sprintf("invalid gate.toml %s %q: name may only use letters, digits, '.', '_', and '-'", section, name)}
+	}
+	if contains(knownGates, name) {
+		return "", ContractError{Msg: fmt.Sprintf("invalid gate.toml %s %q: name is a built-in gate", section, name)}
+	}
+	return name, nil
+}
+
+// parseExtraLevels validates the levels a custom gate or plugin runs at.
+// Unset means every level.
+func parseExtraLevels(levels []string, key string) ([]string, error) {
+	if levels == nil {
+		return append([]string(nil), knownLevels...), nil
+	}
+	var out []string
+	for _, l := range levels {
+		l = strings.TrimSpace(l)
+		if !contains(knownLevels, l) {
+			return nil, ContractError{Msg: fmt.Sprintf("invalid gate.toml %s.levels: unknown level %q", key, l)}
+		}
+		if !contains(out, l) {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func applyScanner(dst *Scanner, raw rawScanner, section string) error {
+	if err := applyTimeout(&dst.TimeoutSec, raw.Timeout, section+".timeout"); err != nil {
+		return err
+	}
+	if raw.ReviewWarnings != nil {
+		if *raw.ReviewWarnings < 0 {
+			return ContractError{Msg: fmt.Sprintf("invalid gate.toml %s.review_warnings %d: must not be negative", section, *raw.ReviewWarnings)}
+		}
+		dst.ReviewWarnings = *raw.ReviewWarnings
+	}
+	return nil
+}
+
+// applyTimeout parses a duration string such as "90s" into whole seconds.
+// An empty value keeps the default already stored in dst.
+func applyTimeout(dst *int, value, key string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return ContractError{Msg: fmt.Sprintf("invalid gate.toml %s %q: use duration like 90s", key, value)}
+	}
+	if d < time.Second {
+		return ContractError{Msg: fmt.Sprintf("invalid gate.toml %s %q: must be at least 1s", key, value)}
+	}
+	*dst = int(d / time.Second)
+	return nil
+}
+
+func applyPercent(dst *float64, value *float64, key string) error {
+	if value == nil {
+		return nil
+	}
+	if *value < 0 || *value > 100 {
+		return ContractError{Msg: fmt.Sprintf("invalid gate.toml %s %v: must be between 0 and 100", key, *value)}
+	}
+	*dst = *value
+	return nil
+}
+
+func normalizeCommand(cmd []string) ([]string, error) {
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("cannot be empty")
+	}
+	out := make([]string, len(cmd))
+	copy(out, cmd)
+	out[0] = strings.TrimSpace(out[0])
+	if out[0] == "" {
+		return nil, fmt.Errorf("program name cannot be empty")
+	}
+	return out, nil
+}
+
+func unknownKeys(e *toml.StrictMissingError) string {
+	keys := make([]string, 0, len(e.Errors))
+	for _, de := range e.Errors {
+		keys = append(keys, strings.Join(de.Key(), "."))
+	}
+	return strings.Join(keys, ", ")
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoad_MissingFileUsesDefaults(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_ReadsRepoRoot(t *testing.T) {
+	dir := t.TempDir()
+	data := "[gate]\nschema_version = 1\n\n[tests]\ntimeout = \"5m\"\n"
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Tests.TimeoutSec != 300 {
+		t.Fatalf("expected tests timeout 300, got %d", cfg.Tests.TimeoutSec)
+	}
+}
+
+func TestParse_FullOverride(t *testing.T) {
+	cfg, err := Parse([]byte(`
+[gate]
+schema_version = 1
+required = ["truthsayer"]
+concurrency = 2
+
+[levels]
+quick = ["lint"]
+deep = ["tests", "risk"]
+
+[tests]
+command = ["go", "test", "-race", "./..."]
+timeout = "300s"
+junit = ["reports/*.xml"]
+retries = 2
+
+[lint]
+timeout = "90s"
+remove = ["shellcheck"]
+
+[[lint.add]]
+name = "staticcheck"
+command = ["staticcheck", "./..."]
+
+[truthsayer]
+timeout = "2m"
+review_warnings = 10
+
+[ubs]
+timeout = "45s"
+
+[diff]
+base = "origin/main"
+
+[risk]
+fail_at = 60
+review_at = 30
+sensitive = ["billing/"]
+
+[fragility]
+window_days = 30
+threshold = 0.4
+min_samples = 2
+
+[coverage]
+timeout = "10m"
+min = 70
+changed_min = 85.5
+reports = ["coverage/lcov.info"]
+
+[format]
+timeout = "30s"
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Levels[LevelQuick]; !reflect.DeepEqual(got, []string{"lint"}) {
+		t.Errorf("quick level = %v", got)
+	}
+	if got := cfg.Levels[LevelStandard]; !reflect.DeepEqual(got, Default().Levels[LevelStandard]) {
+		t.Errorf("standard level should keep default, got %v", got)
+	}
+	if got := cfg.Levels[LevelDeep]; !reflect.DeepEqual(got, []string{"tests", "risk"}) {
+		t.Errorf("deep level = %v", got)
+	}
+	if got := strings.Join(cfg.Tests.Command, " "); got != "go test -race ./..." {
+		t.Errorf("tests command = %q", got)
+	}
+	if !reflect.DeepEqual(cfg.Tests.JUnit, []string{"reports/*.xml"}) {
+		t.Errorf("tests junit = %v", cfg.Tests.JUnit)
+	}
+	if cfg.Tests.Retries != 2 {
+		t.Errorf("tests retries = %d", cfg.Tests.Retries)
+	}
+	if cfg.Tests.TimeoutSec != 300 || cfg.Lint.TimeoutSec != 90 || cfg.Truthsayer.TimeoutSec != 120 || cfg.UBS.TimeoutSec != 45 {
+		t.Errorf("unexpected timeouts: %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.Lint.Remove, []string{"shellcheck"}) {
+		t.Errorf("lint remove = %v", cfg.Lint.Remove)
+	}
+	if len(cfg.Lint.Add) != 1 || cfg.Lint.Add[0].Name != "staticcheck" {
+		t.Errorf("lint add = %+v", cfg.Lint.Add)
+	}
+	if !reflect.DeepEqual(cfg.Required, []string{"truthsayer"}) {
+		t.Errorf("required = %v", cfg.Required)
+	}
+	if cfg.Truthsayer.ReviewWarnings != 10 || cfg.UBS.ReviewWarnings != 0 {
+		t.Errorf("unexpected review_warnings: %+v %+v", cfg.Truthsayer, cfg.UBS)
+	}
+	if cfg.Concurrency != 2 {
+		t.Errorf("concurrency = %d", cfg.Concurrency)
+	}
+	if cfg.Risk.ReviewAt != 30 {
+		t.Errorf("risk review_at = %d", cfg.Risk.ReviewAt)
+	}
+	if cfg.Diff.Base != "origin/main" {
+		t.Errorf("diff base = %q", cfg.Diff.Base)
+	}
+	if cfg.Fragility.WindowDays != 30 || cfg.Fragility.Threshold != 0.4 || cfg.Fragility.MinSamples != 2 {
+		t.Errorf("unexpected fragility config: %+v", cfg.Fragility)
+	}
+	if cfg.Coverage.TimeoutSec != 600 || cfg.Coverage.Min != 70 || cfg.Coverage.ChangedMin != 85.5 || !reflect.DeepEqual(cfg.Coverage.Reports, []string{"coverage/lcov.info"}) {
+		t.Errorf("unexpected coverage config: %+v", cfg.Coverage)
+	}
+	if cfg.Format.TimeoutSec != 30 {
+		t.Errorf("format timeout = %d", cfg.Format.TimeoutSec)
+	}
+	if cfg.Risk.FailAt != 60 || !reflect.DeepEqual(cfg.Risk.Sensitive, []string{"billing/"}) {
+		t.Errorf("unexpected risk config: %+v", cfg.Risk)
+	}
+}
+
+func TestParse_CustomGates(t *testing.T) {
+	cfg, err := Parse([]byte(`[gate]
+schema_version = 1
+
+[[custom]]
+name = "migrations"
+command = ["./scripts/lint-migrations.sh", "--strict"]
+dir = "db/"
+timeout = "2m"
+levels = ["standard", "deep"]
+required = false
+parser = "SARIF"
+
+[[custom]]
+name = "license-headers"
+command = ["licensecheck"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Custom) != 2 {
+		t.Fatalf("custom = %+v", cfg.Custom)
+	}
+	want := CustomGate{
+		Name:       "migrations",
+		Command:    []string{"./scripts/lint-migrations.sh", "--strict"},
+		Dir:        "db",
+		TimeoutSec: 120,
+		Levels:     []string{LevelStandard, LevelDeep},
+		Required:   false,
+		Parser:     ParserSARIF,
+	}
+	if !reflect.DeepEqual(cfg.Custom[0], want) {
+		t.Fatalf("custom[0] = %+v, want %+v", cfg.Custom[0], want)
+	}
+	lic, ok := cfg.CustomGate("license-headers")
+	if !ok || lic.Dir != "" || lic.TimeoutSec != 60 || !lic.Required || lic.Parser != ParserExit ||
+		!reflect.DeepEqual(lic.Levels, []string{LevelQuick, LevelStandard, LevelDeep}) {
+		t.Fatalf("defaults not applied: %+v", lic)
+	}
+	if _, ok := cfg.CustomGate("tests"); ok {
+		t.Fatal("built-in gate reported as custom")
+	}
+}
+
+func TestParse_Plugins(t *testing.T) {
+	cfg, err := Parse([]byte(`[gate]
+schema_version = 1
+
+[[plugin]]
+name = "apicompat"
+timeout = "90s"
+levels = ["deep"]
+required = false
+
+[[plugin]]
+name = "local"
+path = "tools/gate-plugin-local"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Plugin{
+		{Name: "apicompat", TimeoutSec: 90, Levels: []string{LevelDeep}},
+		{Name: "local", Path: "tools/gate-plugin-local", TimeoutSec: 60, Levels: []string{LevelQuick, LevelStandard, LevelDeep}, Required: true},
+	}
+	if !reflect.DeepEqual(cfg.Plugins, want) {
+		t.Fatalf("plugins = %+v, want %+v", cfg.Plugins, want)
+	}
+	if p, ok := cfg.Plugin("local"); !ok || p.Path != "tools/gate-plugin-local" {
+		t.Fatalf("Plugin(local) = %+v, %v", p, ok)
+	}
+}
+
+func TestAddDiscoveredPlugins(t *testing.T) {
+	cfg, err := Parse([]byte(`[gate]
+schema_version = 1
+
+[[custom]]
+name = "migrations"
+command = ["true"]
+
+[[plugin]]
+name = "apicompat"
+enabled = false
+
+[[plugin]]
+name = "license"
+required = true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := cfg.Plugin("apicompat"); !p.Disabled {
+		t.Fatalf("expected enabled = false to disable apicompat, got %+v", p)
+	}
+
+	cfg.AddDiscoveredPlugins([]string{"apicompat", "license", "migrations", "tests", "bad name", "sbom"})
+	var names []string
+	for _, p := range cfg.Plugins {
+		names = append(names, p.Name)
+	}
+	if want := []string{"apicompat", "license", "sbom"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("plugins = %v, want %v", names, want)
+	}
+	want := Plugin{Name: "sbom", TimeoutSec: 60, Levels: []string{LevelQuick, LevelStandard, LevelDeep}}
+	if p, _ := cfg.Plugin("sbom"); !reflect.DeepEqual(p, want) {
+		t.Fatalf("discovered plugin = %+v, want %+v", p, want)
+	}
+	if p, _ := cfg.Plugin("license"); !p.Required {
+		t.Fatalf("expected the declared plugin kept required, got %+v", p)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"bad toml", "[gate\n", "invalid gate.toml TOML"},
+		{"missing schema", "[tests]\ntimeout = \"1s\"\n", "schema_version is required"},
+		{"wrong schema", "[gate]\nschema_version = 2\n", "unsupported schema_version 2"},
+		{"unknown key", "[gate]\nschema_version = 1\nfoo = 1\n", "unknown key gate.foo"},
+		{"unknown section", "[gate]\nschema_version = 1\n[bogus]\nx = 1\n", "unknown key bogus"},
+		{"unknown level", "[gate]\nschema_version = 1\n[levels]\nultra = [\"tests\"]\n", `unknown level "ultra"`},
+		{"unknown gate", "[gate]\nschema_version = 1\n[levels]\nquick = [\"fuzz\"]\n", `unknown gate "fuzz"`},
+		{"duplicate gate", "[gate]\nschema_version = 1\n[levels]\nquick = [\"tests\", \"tests\"]\n", `duplicate gate "tests"`},
+		{"bad timeout", "[gate]\nschema_version = 1\n[tests]\ntimeout = \"soon\"\n", "tests.timeout"},
+		{"sub-second timeout", "[gate]\nschema_version = 1\n[ubs]\ntimeout = \"10ms\"\n", "at least 1s"},
+		{"empty tests command", "[gate]\nschema_version = 1\n[tests]\ncommand = [\"\"]\n", "tests.command"},
+		{"absolute junit", "[gate]\nschema_version = 1\n[tests]\njunit = [\"/tmp/junit.xml\"]\n", "tests.junit"},
+		{"bad junit glob", "[gate]\nschema_version = 1\n[tests]\njunit = [\"reports/[.xml\"]\n", "tests.junit"},
+		{"too many retries", "[gate]\nschema_version = 1\n[tests]\nretries = 9\n", "tests.retries 9"},
+		{"coverage min range", "[gate]\nschema_version = 1\n[coverage]\nmin = 120\n", "coverage.min 120"},
+		{"coverage changed_min range", "[gate]\nschema_version = 1\n[coverage]\nchanged_min = -1\n", "coverage.changed_min -1"},
+		{"absolute coverage report", "[gate]\nschema_version = 1\n[coverage]\nreports = [\"/tmp/lcov.info\"]\n", "coverage.reports"},
+		{"linter without name", "[gate]\nschema_version = 1\n[[lint.add]]\ncommand = [\"x\"]\n", "name is required"},
+		{"linter without command", "[gate]\nschema_version = 1\n[[lint.add]]\nname = \"x\"\n", "command cannot be empty"},
+		{"duplicate linter", "[gate]\nschema_version = 1\n[[lint.add]]\nname = \"x\"\ncommand = [\"x\"]\n[[lint.add]]\nname = \"x\"\ncommand = [\"y\"]\n", `duplicate linter "x"`},
+		{"risk fail_at range", "[gate]\nschema_version = 1\n[risk]\nfail_at = 101\n", "risk.fail_at 101"},
+		{"risk review_at at fail_at", "[gate]\nschema_version = 1\n[risk]\nfail_at = 60\nreview_at = 60\n", "risk.review_at 60: must be below risk.fail_at 60"},
+		{"risk fail_at below default review_at", "[gate]\nschema_version = 1\n[risk]\nfail_at = 30\n", "risk.review_at 40: must be below risk.fail_at 30"},
+		{"absolute sensitive", "[gate]\nschema_version = 1\n[risk]\nsensitive = [\"/etc\"]\n", "risk.sensitive"},
+		{"fragility threshold", "[gate]\nschema_version = 1\n[fragility]\nthreshold = 1.5\n", "fragility.threshold"},
+		{"fragility window", "[gate]\nschema_version = 1\n[fragility]\nwindow_days = 0\n", "fragility.window_days"},
+		{"unknown required gate", "[gate]\nschema_version = 1\nrequired = [\"fuzz\"]\n", "gate.required"},
+		{"zero concurrency", "[gate]\nschema_version = 1\nconcurrency = 0\n", "gate.concurrency"},
+		{"negative review warnings", "[gate]\nschema_version = 1\n[ubs]\nreview_warnings = -1\n", "ubs.review_warnings"},
+		{"empty remove", "[gate]\nschema_version = 1\n[lint]\nremove = [\" \"]\n", "cannot be empty"},
+		{"custom without name", "[gate]\nschema_version = 1\n[[custom]]\ncommand = [\"x\"]\n", "custom: name is required"},
+		{"custom bad name", "[gate]\nschema_version = 1\n[[custom]]\nname = \"a b\"\ncommand = [\"x\"]\n", "name may only use"},
+		{"custom built-in name", "[gate]\nschema_version = 1\n[[custom]]\nname = \"lint\"\ncommand = [\"x\"]\n", "built-in gate"},
+		{"custom without command", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\n", "custom.x.command"},
+		{"custom dir outside repo", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\ncommand = [\"x\"]\ndir = \"../other\"\n", "custom.x.dir"},
+		{"custom unknown level", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\ncommand = [\"x\"]\nlevels = [\"ultra\"]\n", `unknown level "ultra"`},
+		{"custom unknown parser", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\ncommand = [\"x\"]\nparser = \"xml\"\n", "custom.x.parser"},
+		{"custom bad timeout", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\ncommand = [\"x\"]\ntimeout = \"soon\"\n", "custom.x.timeout"},
+		{"plugin without name", "[gate]\nschema_version = 1\n[[plugin]]\npath = \"x\"\n", "plugin: name is required"},
+		{"plugin built-in name", "[gate]\nschema_version = 1\n[[plugin]]\nname = \"risk\"\n", "built-in gate"},
+		{"plugin unknown level", "[gate]\nschema_version = 1\n[[plugin]]\nname = \"x\"\nlevels = [\"ultra\"]\n", "plugin.x.levels"},
+		{"plugin bad timeout", "[gate]\nschema_version = 1\n[[plugin]]\nname = \"x\"\ntimeout = \"10ms\"\n", "plugin.x.timeout"},
+		{"plugin named like custom", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\ncommand = [\"x\"]\n[[plugin]]\nname = \"x\"\n", `plugin: duplicate gate "x"`},
+		{"duplicate custom", "[gate]\nschema_version = 1\n[[custom]]\nname = \"x\"\ncommand = [\"x\"]\n[[custom]]\nname = \"x\"\ncommand = [\"y\"]\n", `duplicate gate "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			var ce ContractError
+			if !errors.As(err, &ce) {
+				t.Fatalf("expected ContractError, got %T", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q in error, got %q", tt.want, err.Error())
+			}
+		})
+	}
+}
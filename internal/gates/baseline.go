@@ -0,0 +1,201 @@
+package gates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+// BaselineFile is the committed list of accepted findings, read from the
+// repo root.
+const BaselineFile = ".gate-baseline.json"
+
+// baselineVersion is the only baseline format this build reads and writes.
+const baselineVersion = 1
+
+// Baseline lists finding fingerprints that scanner gates do not fail on.
+type Baseline struct {
+	Version  int             `json:"version"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is one accepted fingerprint. Count allows the same finding
+// to appear more than once, e.g. a repeated line in one file.
+type BaselineEntry struct {
+	Gate        string `json:"gate"`
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule,omitempty"`
+	File        string `json:"file,omitempty"`
+	Count       int    `json:"count"`
+}
+
+// LoadBaseline reads the baseline in dir. A missing file yields an empty
+// baseline.
+func LoadBaseline(dir string) (Baseline, error) {
+	data, err := os.ReadFile(filepath.Join(dir, BaselineFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Baseline{Version: baselineVersion}, nil
+	}
+	if err != nil {
+		return Baseline{}, fmt.Errorf("read %s: %w", BaselineFile, err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return Baseline{}, fmt.Errorf("invalid %s: %v", BaselineFile, err)
+	}
+	if b.Version != baselineVersion {
+		return Baseline{}, fmt.Errorf("invalid %s: unsupported version %d (expected %d)", BaselineFile, b.Version, baselineVersion)
+	}
+	return b, nil
+}
+
+// NewBaseline accepts every finding in results. Results must carry
+// fingerprinted issues, as returned by the truthsayer and ubs gates.
+func NewBaseline(results ...verdict.GateResult) Baseline {
+	type key struct{ gate, fp string }
+	counts := map[key]*BaselineEntry{}
+	for _, r := range results {
+		for _, is := range r.Issues {
+			if is.Fingerprint == "" {
+				continue
+			}
+			k := key{r.Name, is.Fingerprint}
+			if e, ok := counts[k]; ok {
+				e.Count++
+				continue
+			}
+			counts[k] = &BaselineEntry{Gate: r.Name, Fingerprint: is.Fingerprint, Rule: issueRule(is), File: is.File, Count: 1}
+		}
+	}
+
+	b := Baseline{Version: baselineVersion, Findings: []BaselineEntry{}}
+	for _, e := range counts {
+		b.Findings = append(b.Findings, *e)
+	}
+	sort.Slice(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.Gate != y.Gate {
+			return x.Gate < y.Gate
+		}
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		return x.Fingerprint < y.Fingerprint
+	})
+	return b
+}
+
+// Write stores the baseline in dir, replacing any existing file.
+func (b Baseline) Write(dir string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, BaselineFile), append(data, '\n'), 0o644)
+}
+
+// apply marks the issues of gate that the baseline accepts and returns how
+// many were marked. Each baseline entry absorbs at most Count issues.
+func (b Baseline) apply(gate string, issues []verdict.Issue) int {
+	remaining := map[string]int{}
+	for _, e := range b.Findings {
+		if e.Gate == gate {
+			remaining[e.Fingerprint] += max(e.Count, 1)
+		}
+	}
+	if len(remaining) == 0 {
+		return 0
+	}
+	var n int
+	for i := range issues {
+		fp := issues[i].Fingerprint
+		if fp != "" && remaining[fp] > 0 {
+			remaining[fp]--
+			issues[i].Baselined = true
+			n++
+		}
+	}
+	return n
+}
+
+// fingerprintIssues sets a line-independent fingerprint on each issue: a
+// hash of its rule, file, and the whitespace-normalized source line it
+// points at. Issues without a readable line fall back to their message.
+func fingerprintIssues(dir string, issues []verdict.Issue) {
+	lines := map[string][]string{}
+	for i := range issues {
+		is := &issues[i]
+		snippet := is.Message
+		if is.File != "" && is.Line > 0 {
+			src, ok := lines[is.File]
+			if !ok {
+				if data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(is.File))); err == nil {
+					src = strings.Split(string(data), "\n")
+				}
+				lines[is.File] = src
+			}
+			if is.Line <= len(src) {
+				snippet = src[is.Line-1]
+			}
+		}
+		sum := sha256.Sum256([]byte(issueRule(*is) + "\x00" + is.File + "\x00" + strings.Join(strings.Fields(snippet), " ")))
+		is.Fingerprint = hex.EncodeToString(sum[:16])
+	}
+}
+
+// issueRule identifies what kind of finding an issue is: its rule, or its
+// category for scanners without rule IDs.
+func issueRule(is verdict.Issue) string {
+	if is.Rule != "" {
+		return is.Rule
+	}
+	return is.Category
+}
+
+// tallyIssues counts the issues that still count against a gate. Issues
+// outside the diff count as pre-existing, accepted ones as suppressed; with
+// scoped set, the rest also count as new.
+func tallyIssues(issues []verdict.Issue, scoped bool) verdict.Findings {
+	var f verdict.Findings
+	for _, is := range issues {
+		switch {
+		case is.PreExisting:
+			f.PreExisting++
+		case is.Baselined:
+			f.Suppressed++
+		default:
+			if scoped {
+				f.New++
+			}
+			countSeverity(&f, is.Severity)
+		}
+	}
+	return f
+}
+
+// subtractBaselined takes the baselined issues out of counts parsed from a
+// tool's own summary, which may count findings it does not list. It returns
+// the remaining counts and how many baselined issues were errors.
+func subtractBaselined(f verdict.Findings, issues []verdict.Issue) (verdict.Findings, int) {
+	var gone verdict.Findings
+	for _, is := range issues {
+		if is.Baselined && !is.PreExisting {
+			gone.Suppressed++
+			countSeverity(&gone, is.Severity)
+		}
+	}
+	f.Errors = max(f.Errors-gone.Errors, 0)
+	f.Warnings = max(f.Warnings-gone.Warnings, 0)
+	f.Info = max(f.Info-gone.Info, 0)
+	f.Suppressed += gone.Suppressed
+	return f, gone.Errors
+}
@@ -0,0 +1,170 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+func TestFingerprintIssues_IgnoresLineShifts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc A() {\n\tpanic(1)\n}\n"), 0o644)
+	before := []verdict.Issue{{Rule: "r", File: "a.go", Line: 4}}
+	fingerprintIssues(dir, before)
+
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\n// doc\nfunc A() {\n    panic(1)   \n}\n"), 0o644)
+	after := []verdict.Issue{{Rule: "r", File: "a.go", Line: 5}, {Rule: "other", File: "a.go", Line: 5}}
+	fingerprintIssues(dir, after)
+
+	if before[0].Fingerprint == "" || before[0].Fingerprint != after[0].Fingerprint {
+		t.Fatalf("expected stable fingerprint, got %q and %q", before[0].Fingerprint, after[0].Fingerprint)
+	}
+	if after[1].Fingerprint == after[0].Fingerprint {
+		t.Fatal("different rules should fingerprint differently")
+	}
+}
+
+func TestBaseline_RoundTripAndApply(t *testing.T) {
+	dir := t.TempDir()
+	results := []verdict.GateResult{
+		{Name: "truthsayer", Issues: []verdict.Issue{{Fingerprint: "aa", Rule: "r1", File: "a.go"}, {Fingerprint: "aa", Rule: "r1", File: "a.go"}}},
+		{Name: "ubs", Issues: []verdict.Issue{{Fingerprint: "bb", Category: "leak", File: "b.py"}}},
+	}
+	if err := NewBaseline(results...).Write(dir); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	b, err := LoadBaseline(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(b.Findings) != 2 || b.Findings[0].Count != 2 || b.Findings[1].Rule != "leak" {
+		t.Fatalf("unexpected baseline: %+v", b)
+	}
+
+	issues := []verdict.Issue{{Fingerprint: "aa"}, {Fingerprint: "aa"}, {Fingerprint: "aa"}, {Fingerprint: "bb"}}
+	if n := b.apply("truthsayer", issues); n != 2 {
+		t.Fatalf("expected 2 suppressed, got %d", n)
+	}
+	if !issues[0].Baselined || !issues[1].Baselined || issues[2].Baselined || issues[3].Baselined {
+		t.Fatalf("unexpected baselined flags: %+v", issues)
+	}
+}
+
+func TestLoadBaseline_MissingAndInvalid(t *testing.T) {
+	dir := t.TempDir()
+	if b, err := LoadBaseline(dir); err != nil || len(b.Findings) != 0 {
+		t.Fatalf("missing baseline should be empty, got %+v %v", b, err)
+	}
+
+	os.WriteFile(filepath.Join(dir, BaselineFile), []byte(`{"version":9,"findings":[]}`), 0o644)
+	if _, err := LoadBaseline(dir); err == nil || !strings.Contains(err.Error(), "unsupported version 9") {
+		t.Fatalf("expected version error, got %v", err)
+	}
+}
+
+func TestRunTruthsayer_BaselineSuppressesKnownFindings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\nvar x = 1\nvar y = 2\n"), 0o644)
+	report := `{"findings":[
+		{"rule":"magic","severity":"error","file":"a.go","line":2},
+		{"rule":"magic","severity":"error","file":"a.go","line":3}
+	],"summary":{"errors":2}}`
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, report, nil
+	})
+
+	first := RunTruthsayer(context.Background(), dir, 30)
+	if first.Pass {
+		t.Fatal("expected failure before baselining")
+	}
+	known := first
+	known.Issues = first.Issues[:1]
+	if err := NewBaseline(known).Write(dir); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	r := RunTruthsayer(context.Background(), dir, 30)
+	if r.Pass {
+		t.Fatal("expected failure on the finding outside the baseline")
+	}
+	if r.Findings.Errors != 1 || r.Findings.Suppressed != 1 {
+		t.Fatalf("unexpected findings: %+v", r.Findings)
+	}
+	if !strings.Contains(r.Output, "1 baselined") {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
+
+	if err := NewBaseline(first).Write(dir); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	r = RunTruthsayer(context.Background(), dir, 30)
+	if !r.Pass || r.Findings.Errors != 0 || r.Findings.Suppressed != 2 {
+		t.Fatalf("expected pass with everything baselined, got %+v (%s)", r.Findings, r.Output)
+	}
+}
+
+func TestRunUBS_BaselineSuppressesKnownFindings(t *testing.T) {
+	dir := t.TempDir()
+	report := `{"scanners":[{"language":"golang","critical":1,"findings":[{"severity":"critical","category":"nil-deref","message":"boom"}]}],"totals":{"critical":1,"files":1}}`
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, report, nil
+	})
+
+	first := RunUBS(context.Background(), dir, 30)
+	NewBaseline(first).Write(dir)
+
+	r := RunUBS(context.Background(), dir, 30)
+	if !r.Pass || r.Findings.Suppressed != 1 || !r.Issues[0].Baselined {
+		t.Fatalf("expected baselined pass, got %+v %+v", r.Findings, r.Issues)
+	}
+}
+
+func TestRunBaseline_KeepsUnlistedSummaryCounts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\nvar x = 1\n"), 0o644)
+	truthsayer := `{"findings":[{"rule":"magic","severity":"error","file":"a.go","line":2}],"summary":{"errors":3,"warnings":2}}`
+	ubs := `{"scanners":[{"language":"golang","critical":2,"warning":1,"findings":[{"severity":"critical","category":"nil-deref","message":"boom"}]}]}`
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name == "ubs" {
+			return false, ubs, nil
+		}
+		return false, truthsayer, nil
+	})
+
+	NewBaseline(RunTruthsayer(context.Background(), dir, 30), RunUBS(context.Background(), dir, 30)).Write(dir)
+
+	r := RunTruthsayer(context.Background(), dir, 30)
+	if r.Pass || r.Findings.Errors != 2 || r.Findings.Warnings != 2 || r.Findings.Suppressed != 1 {
+		t.Fatalf("truthsayer: expected 2 unlisted errors to remain, got %+v (%s)", r.Findings, r.Output)
+	}
+	r = RunUBS(context.Background(), dir, 30)
+	if r.Pass || r.Findings.Errors != 1 || r.Findings.Warnings != 1 || r.Findings.Suppressed != 1 {
+		t.Fatalf("ubs: expected per-scanner counts minus the baselined finding, got %+v (%s)", r.Findings, r.Output)
+	}
+
+	// Baselining a warning does not explain away a failing exit code.
+	ubs = `{"findings":[{"severity":"warning","category":"style","message":"long"}],"totals":{"warning":1,"files":1}}`
+	NewBaseline(RunUBS(context.Background(), dir, 30)).Write(dir)
+	r = RunUBS(context.Background(), dir, 30)
+	if r.Pass || r.Findings.Warnings != 0 || r.Findings.Suppressed != 1 || !strings.Contains(r.Output, "cmd_pass=false") {
+		t.Fatalf("ubs: expected the exit code to still fail, got %+v (%s)", r.Findings, r.Output)
+	}
+}
+
+func TestRunUBS_InvalidBaselineFails(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, BaselineFile), []byte("not json"), 0o644)
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return true, `{"totals":{"files":1}}`, nil
+	})
+
+	r := RunUBS(context.Background(), dir, 30)
+	if r.Pass || !strings.Contains(r.Output, "invalid "+BaselineFile) {
+		t.Fatalf("expected invalid baseline failure, got %+v", r)
+	}
+}
@@ -0,0 +1,529 @@
+package gates
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// Coverage sources.
+const (
+	CoverageGo        = "go"
+	CoverageCobertura = "cobertura"
+	CoverageLcov      = "lcov"
+)
+
+// defaultCoverageReports are where coverage.py, pytest-cov, istanbul/nyc,
+// and jest write Cobertura XML or lcov when the repo does not configure
+// coverage.reports.
+var defaultCoverageReports = []string{
+	"coverage.xml",
+	"coverage/cobertura-coverage.xml",
+	"coverage/lcov.info",
+	"lcov.info",
+}
+
+// maxCoveragePackages caps how many under-covered packages the output names.
+const maxCoveragePackages = 5
+
+// CoverageOptions controls the coverage gate.
+type CoverageOptions struct {
+	// Base is the ref to diff against for changed-line coverage; empty
+	// tries main, then master.
+	Base string
+	// Min is the total percent below which the gate fails; zero only reports.
+	Min float64
+	// ChangedMin is the percent of changed coverable lines that must be
+	// covered; zero skips the diff.
+	ChangedMin float64
+	// Reports lists repo-relative globs of Cobertura XML or lcov reports.
+	// Empty checks the common default locations.
+	Reports []string
+}
+
+// coverUnit is one coverable span: a Go statement block, or a single line
+// from a Cobertura or lcov report.
+type coverUnit struct {
+	pkg        string
+	file       string // repo-relative
+	start, end int
+	weight     int
+	covered    bool
+}
+
+// RunCoverage measures test coverage of the repo at dir. Go repos run
+// `go test -coverprofile`; an existing Cobertura XML or lcov report is read
+// too, so a repo mixing Go with another language counts both. The gate fails
+// when total coverage, or coverage of the changed lines, is below the
+// configured minimum.
+func RunCoverage(ctx context.Context, dir string, timeoutSec int, opts CoverageOptions) verdict.GateResult {
+	start := time.Now()
+	if timeoutSec <= 0 {
+		timeoutSec = 300
+	}
+
+	var (
+		units   []coverUnit
+		sources []string
+		notes   []string
+	)
+	isGo := fileExists(filepath.Join(dir, "go.mod"))
+	if isGo {
+		goUnits, err := goCoverage(ctx, dir, timeoutSec)
+		if err != nil {
+			return verdict.GateResult{Name: "coverage", Pass: false, Output: err.Error(), DurationMs: time.Since(start).Milliseconds()}
+		}
+		units, sources = goUnits, []string{CoverageGo}
+	}
+	source, reportUnits, note, err := readCoverageReports(dir, opts.Reports)
+	if err != nil {
+		return verdict.GateResult{Name: "coverage", Pass: false, Output: err.Error(), DurationMs: time.Since(start).Milliseconds()}
+	}
+	if source != "" {
+		if isGo {
+			// The Go profile already counts Go files, e.g. when the report
+			// was converted from it.
+			reportUnits = slices.DeleteFunc(reportUnits, func(u coverUnit) bool { return strings.HasSuffix(u.file, ".go") })
+		}
+		units = append(units, reportUnits...)
+		sources = append(sources, source)
+	}
+	if note != "" {
+		notes = append(notes, note)
+	}
+	if len(sources) == 0 {
+		return verdict.GateResult{Name: "coverage", Pass: true, Skipped: true, Output: "no coverage report found (skipped)"}
+	}
+	source = strings.Join(sources, "+")
+
+	rep := summarizeCoverage(units)
+	rep.Source = source
+	rep.Unit = "lines"
+	switch {
+	case len(sources) > 1:
+		rep.Unit = "statements+lines"
+	case isGo:
+		rep.Unit = "statements"
+	}
+	rep.Min = opts.Min
+
+	if opts.ChangedMin > 0 {
+		changed, err := DiffChangedLines(ctx, dir, opts.Base, timeoutSec)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("changed lines not measured: %v", err))
+		} else {
+			rep.Changed = changedCoverage(units, changed)
+			rep.Changed.Min = opts.ChangedMin
+		}
+	}
+
+	pass := true
+	var b strings.Builder
+	fmt.Fprintf(&b, "coverage %.1f%% of %s (%d/%d, %s)", rep.Percent, rep.Unit, rep.Covered, rep.Total, source)
+	if opts.Min > 0 {
+		fmt.Fprintf(&b, ", min %g%%", opts.Min)
+		if rep.Percent < opts.Min {
+			pass = false
+			var low []string
+			for _, p := range rep.Packages {
+				if p.Percent < opts.Min && len(low) < maxCoveragePackages {
+					low = append(low, fmt.Sprintf("%s %.1f%%", p.Name, p.Percent))
+				}
+			}
+			if len(low) > 0 {
+				b.WriteString("\nlowest: " + strings.Join(low, ", "))
+			}
+		}
+	}
+	if c := rep.Changed; c != nil {
+		if c.Total == 0 {
+			b.WriteString("\nchanged lines: nothing coverable changed")
+		} else {
+			fmt.Fprintf(&b, "\nchanged lines %.1f%% (%d/%d), min %g%%", c.Percent, c.Covered, c.Total, c.Min)
+			if c.Percent < c.Min {
+				pass = false
+			}
+		}
+	}
+	for _, n := range notes {
+		b.WriteString("\n" + n)
+	}
+
+	return verdict.GateResult{
+		Name:       "coverage",
+		Pass:       pass,
+		Output:     b.String(),
+		DurationMs: time.Since(start).Milliseconds(),
+		Coverage:   &rep,
+	}
+}
+
+// goCoverage runs the Go tests with a cover profile and reads it. The run
+// waits for the tests gate's own go test in dir rather than racing it.
+func goCoverage(ctx context.Context, dir string, timeoutSec int) ([]coverUnit, error) {
+	tmp, err := os.MkdirTemp("", "gate-cover-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	profile := filepath.Join(tmp, "cover.out")
+
+	unlock := lockGoTest(dir)
+	ok, output, err := runCmd(ctx, dir, timeoutSec, "go", "test", "-covermode=set", "-coverprofile="+profile, "./...")
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		return nil, fmt.Errorf("go test failed; coverage not measured\n%s", tailLines(lines, maxTestOutputLines))
+	}
+	data, err := os.ReadFile(profile)
+	if err != nil {
+		return nil, fmt.Errorf("read cover profile: %w", err)
+	}
+	return parseGoCoverProfile(data, goModulePath(dir))
+}
+
+// goModulePath reads the module path from go.mod in dir.
+func goModulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) >= 2 && f[0] == "module" {
+			return strings.Trim(f[1], `"`)
+		}
+	}
+	return ""
+}
+
+// parseGoCoverProfile reads a Go cover profile. Files are named by import
+// path; those under module become repo-relative. Blocks reported more than
+// once count as covered if any report covers them.
+func parseGoCoverProfile(data []byte, module string) ([]coverUnit, error) {
+	var units []coverUnit
+	index := map[string]int{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// name.go:line.column,line.column numberOfStatements count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("invalid cover profile line %q", line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid cover profile line %q", line)
+		}
+		from, to, _ := strings.Cut(fields[0], ",")
+		startLine, _, _ := strings.Cut(from, ".")
+		endLine, _, _ := strings.Cut(to, ".")
+		s, err1 := strconv.Atoi(startLine)
+		e, err2 := strconv.Atoi(endLine)
+		stmts, err3 := strconv.Atoi(fields[1])
+		count, err4 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return nil, fmt.Errorf("invalid cover profile line %q", line)
+		}
+
+		importFile := line[:colon]
+		key := importFile + ":" + fields[0]
+		if i, ok := index[key]; ok {
+			units[i].covered = units[i].covered || count > 0
+			continue
+		}
+		file := importFile
+		if module != "" && strings.HasPrefix(importFile, module+"/") {
+			file = strings.TrimPrefix(importFile, module+"/")
+		}
+		index[key] = len(units)
+		units = append(units, coverUnit{
+			pkg:     path.Dir(importFile),
+			file:    file,
+			start:   s,
+			end:     e,
+			weight:  stmts,
+			covered: count > 0,
+		})
+	}
+	return units, sc.Err()
+}
+
+// CoverageReports lists the existing report files matching patterns, or
+// the default locations when patterns is empty, in pattern order.
+func CoverageReports(dir string, patterns []string) []string {
+	if len(patterns) == 0 {
+		patterns = defaultCoverageReports
+	}
+	var reports []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && !info.IsDir() && !slices.Contains(reports, m) {
+				reports = append(reports, m)
+			}
+		}
+	}
+	return reports
+}
+
+// readCoverageReports reads the first report matching patterns that is
+// newer than every source file it covers. When each report covers a file
+// changed since it was written, the first is read anyway and note says it
+// may be stale. source is empty when there is no report.
+func readCoverageReports(dir string, patterns []string) (source string, units []coverUnit, note string, err error) {
+	var staleSource string
+	var staleUnits []coverUnit
+	for _, report := range CoverageReports(dir, patterns) {
+		src, u, err := readCoverageReport(dir, report)
+		if err != nil {
+			return "", nil, "", err
+		}
+		changed := changedSince(dir, report, u)
+		if changed == "" {
+			return src, u, "", nil
+		}
+		if staleSource == "" {
+			rel, _ := filepath.Rel(dir, report)
+			staleSource, staleUnits = src, u
+			note = fmt.Sprintf("report %s may be stale: %s changed after it was written", filepath.ToSlash(rel), changed)
+		}
+	}
+	return staleSource, staleUnits, note, nil
+}
+
+// changedSince returns a file covered by units that was modified after
+// report was written, or "" when the report is at least as new as all of
+// them. Files that no longer exist are ignored.
+func changedSince(dir, report string, units []coverUnit) string {
+	info, err := os.Stat(report)
+	if err != nil {
+		return ""
+	}
+	seen := map[string]bool{}
+	for _, u := range units {
+		if seen[u.file] {
+			continue
+		}
+		seen[u.file] = true
+		if fi, err := os.Stat(filepath.Join(dir, filepath.FromSlash(u.file))); err == nil && fi.ModTime().After(info.ModTime()) {
+			return u.file
+		}
+	}
+	return ""
+}
+
+// readCoverageReport parses a Cobertura XML or lcov report, told apart by
+// content.
+func readCoverageReport(dir, report string) (string, []coverUnit, error) {
+	data, err := os.ReadFile(report)
+	if err != nil {
+		return "", nil, fmt.Errorf("read coverage report: %w", err)
+	}
+	name, _ := filepath.Rel(dir, report)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		units, err := parseCobertura(dir, data)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %v", filepath.ToSlash(name), err)
+		}
+		return CoverageCobertura, units, nil
+	}
+	units, err := parseLcov(dir, data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %v", filepath.ToSlash(name), err)
+	}
+	return CoverageLcov, units, nil
+}
+
+type coberturaReport struct {
+	Sources  []string `xml:"sources>source"`
+	Packages []struct {
+		Name    string `xml:"name,attr"`
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number int `xml:"number,attr"`
+				Hits   int `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// parseCobertura reads line coverage from a Cobertura XML report.
+func parseCobertura(dir string, data []byte) ([]coverUnit, error) {
+	var rep coberturaReport
+	if err := xml.Unmarshal(data, &rep); err != nil {
+		return nil, fmt.Errorf("invalid Cobertura XML: %v", err)
+	}
+	var units []coverUnit
+	index := map[string]int{}
+	for _, p := range rep.Packages {
+		for _, c := range p.Classes {
+			file := coverageFile(dir, rep.Sources, c.Filename)
+			pkg := p.Name
+			if pkg == "" {
+				pkg = path.Dir(file)
+			}
+			for _, l := range c.Lines {
+				units = addLineUnit(units, index, pkg, file, l.Number, l.Hits > 0)
+			}
+		}
+	}
+	return units, nil
+}
+
+// parseLcov reads line coverage (DA records) from an lcov tracefile.
+func parseLcov(dir string, data []byte) ([]coverUnit, error) {
+	var units []coverUnit
+	index := map[string]int{}
+	var file string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = coverageFile(dir, nil, strings.TrimPrefix(line, "SF:"))
+		case strings.HasPrefix(line, "DA:"):
+			f := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if file == "" || len(f) < 2 {
+				return nil, fmt.Errorf("invalid lcov record %q", line)
+			}
+			n, err1 := strconv.Atoi(f[0])
+			hits, err2 := strconv.ParseFloat(f[1], 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid lcov record %q", line)
+			}
+			units = addLineUnit(units, index, path.Dir(file), file, n, hits > 0)
+		case line == "end_of_record":
+			file = ""
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(units) == 0 && !bytes.Contains(data, []byte("SF:")) {
+		return nil, fmt.Errorf("not an lcov tracefile")
+	}
+	return units, nil
+}
+
+// addLineUnit records one line, merging repeats of the same file and line.
+func addLineUnit(units []coverUnit, index map[string]int, pkg, file string, line int, covered bool) []coverUnit {
+	key := file + ":" + strconv.Itoa(line)
+	if i, ok := index[key]; ok {
+		units[i].covered = units[i].covered || covered
+		return units
+	}
+	index[key] = len(units)
+	return append(units, coverUnit{pkg: pkg, file: file, start: line, end: line, weight: 1, covered: covered})
+}
+
+// coverageFile turns a report file name into a repo-relative path. Names
+// may be absolute, relative to dir, or relative to one of the report's
+// source roots.
+func coverageFile(dir string, sources []string, name string) string {
+	candidates := []string{name}
+	for _, s := range sources {
+		candidates = append(candidates, filepath.Join(strings.TrimSpace(s), name))
+	}
+	for _, c := range candidates {
+		abs := c
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(dir, c)
+		}
+		if !fileExists(abs) {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return cleanRepoPath(filepath.ToSlash(rel))
+		}
+	}
+	return cleanRepoPath(filepath.ToSlash(name))
+}
+
+// summarizeCoverage totals units overall and per package, lowest package
+// first.
+func summarizeCoverage(units []coverUnit) verdict.CoverageReport {
+	var rep verdict.CoverageReport
+	pkgs := map[string]*verdict.PackageCoverage{}
+	for _, u := range units {
+		p, ok := pkgs[u.pkg]
+		if !ok {
+			p = &verdict.PackageCoverage{Name: u.pkg}
+			pkgs[u.pkg] = p
+		}
+		p.Total += u.weight
+		rep.Total += u.weight
+		if u.covered {
+			p.Covered += u.weight
+			rep.Covered += u.weight
+		}
+	}
+	rep.Percent = percent(rep.Covered, rep.Total)
+	for _, p := range pkgs {
+		p.Percent = percent(p.Covered, p.Total)
+		rep.Packages = append(rep.Packages, *p)
+	}
+	sort.Slice(rep.Packages, func(i, j int) bool {
+		if rep.Packages[i].Percent != rep.Packages[j].Percent {
+			return rep.Packages[i].Percent < rep.Packages[j].Percent
+		}
+		return rep.Packages[i].Name < rep.Packages[j].Name
+	})
+	return rep
+}
+
+// changedCoverage totals the units that overlap the changed lines.
+func changedCoverage(units []coverUnit, changed ChangedLines) *verdict.ChangedCoverage {
+	c := &verdict.ChangedCoverage{Base: changed.Base}
+	for _, u := range units {
+		ranges := changed.Files[u.file]
+		touched := false
+		for _, r := range ranges {
+			if u.start <= r.End && r.Start <= u.end {
+				touched = true
+				break
+			}
+		}
+		if !touched {
+			continue
+		}
+		c.Total += u.weight
+		if u.covered {
+			c.Covered += u.weight
+		}
+	}
+	c.Percent = percent(c.Covered, c.Total)
+	return c
+}
+
+// percent is covered/total as a percentage rounded to two decimals. Nothing
+// to cover counts as fully covered.
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(covered)*10000/float64(total)) / 100
+}
@@ -0,0 +1,272 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+const goCoverFixture = `mode: set
+example.com/relay/store/store.go:10.20,12.2 2 1
+example.com/relay/store/store.go:14.20,16.2 2 0
+example.com/relay/api/api.go:5.15,9.2 4 1
+example.com/relay/store/store.go:14.20,16.2 2 1
+`
+
+func TestParseGoCoverProfile(t *testing.T) {
+	units, err := parseGoCoverProfile([]byte(goCoverFixture), "example.com/relay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 3 {
+		t.Fatalf("expected repeated block to merge, got %d units", len(units))
+	}
+	if u := units[1]; u.file != "store/store.go" || u.pkg != "example.com/relay/store" || u.start != 14 || u.end != 16 || u.weight != 2 || !u.covered {
+		t.Fatalf("unexpected merged unit: %+v", u)
+	}
+	if _, err := parseGoCoverProfile([]byte("mode: set\ngarbage\n"), ""); err == nil {
+		t.Fatal("expected error for malformed profile")
+	}
+}
+
+func TestSummarizeCoverage(t *testing.T) {
+	rep := summarizeCoverage([]coverUnit{
+		{pkg: "a", weight: 3, covered: true},
+		{pkg: "a", weight: 1},
+		{pkg: "b", weight: 2},
+	})
+	if rep.Covered != 3 || rep.Total != 6 || rep.Percent != 50 {
+		t.Fatalf("unexpected totals: %+v", rep)
+	}
+	want := []verdict.PackageCoverage{{Name: "b", Percent: 0, Total: 2}, {Name: "a", Percent: 75, Covered: 3, Total: 4}}
+	if len(rep.Packages) != 2 || rep.Packages[0] != want[0] || rep.Packages[1] != want[1] {
+		t.Fatalf("expected packages lowest first, got %+v", rep.Packages)
+	}
+	if empty := summarizeCoverage(nil); empty.Percent != 100 {
+		t.Fatalf("expected nothing to cover to count as covered, got %v", empty.Percent)
+	}
+}
+
+func TestParseLcov(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src"), 0755)
+	os.WriteFile(filepath.Join(dir, "src", "app.js"), []byte(""), 0644)
+
+	data := "TN:\nSF:" + filepath.Join(dir, "src", "app.js") + "\nFN:1,main\nDA:1,3\nDA:2,0\nDA:4,1,abc\nend_of_record\n"
+	units, err := parseLcov(dir, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := summarizeCoverage(units)
+	if rep.Covered != 2 || rep.Total != 3 || units[0].file != "src/app.js" || units[0].pkg != "src" {
+		t.Fatalf("unexpected lcov result: %+v %+v", rep, units)
+	}
+	if _, err := parseLcov(dir, []byte("not coverage\n")); err == nil {
+		t.Fatal("expected error for a file that is not lcov")
+	}
+}
+
+func TestParseCobertura_ResolvesSources(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "src", "app"), 0755)
+	os.WriteFile(filepath.Join(dir, "src", "app", "views.py"), []byte(""), 0644)
+
+	data := `<?xml version="1.0" ?>
+<coverage line-rate="0.5"><sources><source>` + filepath.Join(dir, "src") + `</source></sources>
+<packages><package name="app"><classes>
+<class name="views.py" filename="app/views.py"><lines><line number="1" hits="1"/><line number="2" hits="0"/></lines></class>
+<class name="views.py" filename="app/views.py"><lines><line number="2" hits="4"/><line number="3" hits="0"/></lines></class>
+</classes></package></packages></coverage>`
+	units, err := parseCobertura(dir, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := summarizeCoverage(units)
+	if rep.Covered != 2 || rep.Total != 3 || units[0].file != "src/app/views.py" || units[0].pkg != "app" {
+		t.Fatalf("unexpected cobertura result: %+v %+v", rep, units)
+	}
+}
+
+func TestChangedCoverage(t *testing.T) {
+	units := []coverUnit{
+		{file: "a.go", start: 1, end: 3, weight: 2, covered: true},
+		{file: "a.go", start: 10, end: 12, weight: 3},
+		{file: "b.go", start: 1, end: 1, weight: 1},
+	}
+	c := changedCoverage(units, ChangedLines{Base: "abc", Files: map[string][]LineRange{"a.go": {{Start: 3, End: 11}}}})
+	if c.Base != "abc" || c.Covered != 2 || c.Total != 5 || c.Percent != 40 {
+		t.Fatalf("unexpected changed coverage: %+v", c)
+	}
+}
+
+// mockCoverProfile makes go test write profile as its cover profile.
+func mockCoverProfile(t *testing.T, profile string) {
+	t.Helper()
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		for _, a := range args {
+			if p, ok := strings.CutPrefix(a, "-coverprofile="); ok {
+				os.WriteFile(p, []byte(profile), 0644)
+				return true, "ok", nil
+			}
+		}
+		t.Fatalf("expected a cover profile flag, got %s %v", name, args)
+		return false, "", nil
+	})
+}
+
+func TestRunCoverage_GoMeetsMin(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/relay\n"), 0644)
+	mockCoverProfile(t, goCoverFixture)
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{Min: 90})
+	if !r.Pass {
+		t.Fatalf("expected pass, got: %s", r.Output)
+	}
+	if r.Coverage == nil || r.Coverage.Source != CoverageGo || r.Coverage.Unit != "statements" || r.Coverage.Percent != 100 || r.Coverage.Min != 90 {
+		t.Fatalf("unexpected report: %+v", r.Coverage)
+	}
+	if len(r.Coverage.Packages) != 2 {
+		t.Fatalf("expected per-package coverage, got %+v", r.Coverage.Packages)
+	}
+}
+
+func TestRunCoverage_GoBelowMin(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/relay\n"), 0644)
+	mockCoverProfile(t, "mode: set\nexample.com/relay/store/store.go:1.1,2.1 3 1\nexample.com/relay/api/api.go:1.1,2.1 1 0\n")
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{Min: 90})
+	if r.Pass {
+		t.Fatal("expected fail below min")
+	}
+	if r.Output != "coverage 75.0% of statements (3/4, go), min 90%\nlowest: example.com/relay/api 0.0%" {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
+}
+
+func TestRunCoverage_GoTestsFail(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/relay\n"), 0644)
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, "--- FAIL: TestX\nFAIL", nil
+	})
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{})
+	if r.Pass || r.Coverage != nil || !strings.HasPrefix(r.Output, "go test failed; coverage not measured") {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
+
+func TestRunCoverage_GoWaitsForTestsGate(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/relay\n"), 0644)
+	var running, overlapped atomic.Int32
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if running.Add(1) > 1 {
+			overlapped.Store(1)
+		}
+		defer running.Add(-1)
+		time.Sleep(20 * time.Millisecond)
+		for _, a := range args {
+			if p, ok := strings.CutPrefix(a, "-coverprofile="); ok {
+				os.WriteFile(p, []byte(goCoverFixture), 0644)
+			}
+		}
+		return true, "ok", nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); RunTests(context.Background(), dir, 30) }()
+	go func() { defer wg.Done(); RunCoverage(context.Background(), dir, 30, CoverageOptions{}) }()
+	wg.Wait()
+	if overlapped.Load() != 0 {
+		t.Fatal("expected the coverage run to wait for the tests gate's go test")
+	}
+}
+
+func TestRunCoverage_NoReportSkips(t *testing.T) {
+	r := RunCoverage(context.Background(), t.TempDir(), 30, CoverageOptions{Min: 80})
+	if !r.Pass || !r.Skipped {
+		t.Fatalf("expected skip without a report, got %+v", r)
+	}
+}
+
+func TestRunCoverage_StaleReportWarns(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"app.py": "a = 1\n"})
+	report := filepath.Join(dir, "lcov.info")
+	os.WriteFile(report, []byte("SF:app.py\nDA:1,0\nend_of_record\n"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(report, old, old)
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{Min: 80})
+	if r.Pass || r.Skipped || !strings.Contains(r.Output, "report lcov.info may be stale: app.py changed after it was written") {
+		t.Fatalf("expected a stale report to be read with a warning, got %+v", r)
+	}
+
+	os.WriteFile(filepath.Join(dir, "coverage.xml"), []byte(`<coverage><packages><package name="app"><classes><class filename="app.py"><lines><line number="1" hits="1"/></lines></class></classes></package></packages></coverage>`), 0o644)
+	r = RunCoverage(context.Background(), dir, 30, CoverageOptions{Min: 80})
+	if !r.Pass || r.Coverage == nil || r.Coverage.Source != CoverageCobertura || strings.Contains(r.Output, "stale") {
+		t.Fatalf("expected the fresh report to be read, got %+v", r)
+	}
+}
+
+func TestRunCoverage_ReportWrittenBeforeCommitIsFresh(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "app.py"), []byte("a = 1\n"), 0o644)
+	report := filepath.Join(dir, "lcov.info")
+	os.WriteFile(report, []byte("SF:app.py\nDA:1,1\nend_of_record\n"), 0o644)
+	written := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "app.py"), written.Add(-time.Minute), written.Add(-time.Minute))
+	os.Chtimes(report, written, written)
+	gitRepo(t, dir, map[string]string{".gitignore": "lcov.info\n"})
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{Min: 80})
+	if !r.Pass || r.Skipped || strings.Contains(r.Output, "stale") {
+		t.Fatalf("expected a report older than HEAD but newer than its sources to count, got %+v", r)
+	}
+}
+
+func TestRunCoverage_GoMergesReports(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/relay\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "web"), 0o755)
+	os.WriteFile(filepath.Join(dir, "web", "app.js"), []byte("a()\nb()\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "lcov.info"), []byte("SF:web/app.js\nDA:1,1\nDA:2,0\nend_of_record\nSF:store/store.go\nDA:10,0\nend_of_record\n"), 0o644)
+	mockCoverProfile(t, goCoverFixture)
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{})
+	c := r.Coverage
+	if c == nil || c.Source != "go+lcov" || c.Unit != "statements+lines" || c.Covered != 9 || c.Total != 10 {
+		t.Fatalf("expected Go statements and report lines merged, got %+v", c)
+	}
+}
+
+func TestRunCoverage_ChangedLinesBelowMin(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"app.py": "a = 1\n"})
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "app.py"), []byte("a = 1\nb = 2\nc = 3\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "lcov.info"), []byte("SF:app.py\nDA:1,1\nDA:2,1\nDA:3,0\nend_of_record\n"), 0o644)
+
+	r := RunCoverage(context.Background(), dir, 30, CoverageOptions{Min: 50, ChangedMin: 80})
+	if r.Pass {
+		t.Fatalf("expected changed-line coverage to fail, got: %s", r.Output)
+	}
+	c := r.Coverage.Changed
+	if r.Coverage.Source != CoverageLcov || c == nil || c.Covered != 1 || c.Total != 2 || c.Percent != 50 || c.Base == "" {
+		t.Fatalf("unexpected changed coverage: %+v", c)
+	}
+	if !strings.Contains(r.Output, "changed lines 50.0% (1/2), min 80%") {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
+}
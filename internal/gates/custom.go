@@ -0,0 +1,234 @@
+package gates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// maxCustomOutputLines caps the raw output a custom gate reports.
+const maxCustomOutputLines = 40
+
+// CustomCheck is a project-specific gate declared in gate.toml.
+type CustomCheck struct {
+	Name    string
+	Command []string
+	// Dir is the repo-relative working directory; empty is the repo root.
+	Dir string
+	// Parser is how the output is read: exit (the default), json, sarif,
+	// or tap.
+	Parser string
+}
+
+// RunCustom runs a custom gate in the repo at dir. The gate fails when the
+// command exits non-zero, and with the json and sarif parsers also when it
+// reports an error-severity finding. A command that is not on PATH is
+// skipped; a missing working directory fails.
+func RunCustom(ctx context.Context, dir string, timeoutSec int, c CustomCheck) verdict.GateResult {
+	workDir := filepath.Join(dir, c.Dir)
+	if info, err := os.Stat(workDir); err != nil || !info.IsDir() {
+		return verdict.GateResult{Name: c.Name, Pass: false, Output: fmt.Sprintf("dir %q is not a directory in the repo", c.Dir)}
+	}
+
+	start := time.Now()
+	ok, output, err := runCmd(ctx, workDir, timeoutSec, c.Command[0], c.Command[1:]...)
+	dur := time.Since(start).Milliseconds()
+
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return verdict.GateResult{
+				Name:       c.Name,
+				Pass:       true,
+				Skipped:    true,
+				Output:     fmt.Sprintf("%s not available (skipped)", c.Command[0]),
+				DurationMs: dur,
+			}
+		}
+		return verdict.GateResult{Name: c.Name, Pass: false, Output: appendTail(err.Error(), output), DurationMs: dur}
+	}
+
+	var r verdict.GateResult
+	switch c.Parser {
+	case "json", "sarif":
+		parse := parseFindingsJSON
+		if c.Parser == "sarif" {
+			parse = parseSARIF
+		}
+		issues, perr := parse(output)
+		if perr != nil {
+			r = verdict.GateResult{Pass: false, Output: appendTail(fmt.Sprintf("invalid %s output: %v", c.Parser, perr), output)}
+			break
+		}
+		r = findingsResult(issues, ok)
+	case "tap":
+		tests, perr := parseTAP(output)
+		r = tapResult(tests, perr, ok)
+		if !r.Pass {
+			r.Output = appendTail(r.Output, output)
+		}
+	default:
+		r = verdict.GateResult{Pass: ok, Output: tailLines(nonEmptyLines(output), maxCustomOutputLines)}
+	}
+	r.Name = c.Name
+	r.DurationMs = dur
+	return r
+}
+
+// findingsResult judges parsed findings: any error fails the gate, as does
+// a non-zero exit.
+func findingsResult(issues []verdict.Issue, cmdPass bool) verdict.GateResult {
+	findings := tallyIssues(issues, false)
+	pass := cmdPass && findings.Errors == 0
+	summary := fmt.Sprintf("%d errors, %d warnings, %d info", findings.Errors, findings.Warnings, findings.Info)
+	if !pass {
+		summary = fmt.Sprintf("errors=%d warnings=%d info=%d (cmd_pass=%v)", findings.Errors, findings.Warnings, findings.Info, cmdPass)
+	}
+	return verdict.GateResult{Pass: pass, Output: summary, Findings: &findings, Issues: issues}
+}
+
+// tapResult judges parsed TAP tests: a failing test, a bail out, a plan
+// mismatch, or a non-zero exit fails the gate.
+func tapResult(tests []verdict.TestCase, perr error, cmdPass bool) verdict.GateResult {
+	var b strings.Builder
+	b.WriteString("tap: " + countTests(tests))
+	writeFailedTests(&b, tests)
+	pass := cmdPass && perr == nil
+	for _, tc := range tests {
+		pass = pass && tc.Status != verdict.TestFail
+	}
+	if perr != nil {
+		b.WriteString("\n" + perr.Error())
+	}
+	return verdict.GateResult{Pass: pass, Output: b.String(), Tests: tests}
+}
+
+// parseFindingsJSON reads findings printed as a JSON array of issues, or an
+// object with an "issues" array, using the field names of the --json
+// verdict. Lines before the JSON, such as stderr chatter, are ignored. A
+// finding without a severity is an error.
+func parseFindingsJSON(output string) ([]verdict.Issue, error) {
+	data := jsonStart(output)
+	if data == nil {
+		return nil, fmt.Errorf("no JSON found")
+	}
+	var issues []verdict.Issue
+	if data[0] == '[' {
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(&issues); err != nil {
+			return nil, err
+		}
+	} else {
+		var doc struct {
+			Issues []verdict.Issue `json:"issues"`
+		}
+		if err := json.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+			return nil, err
+		}
+		issues = doc.Issues
+	}
+	for i := range issues {
+		issues[i].Severity = lintSeverity(issues[i].Severity)
+		issues[i].File = cleanIssuePath(issues[i].File)
+	}
+	return issues, nil
+}
+
+// sarifLog is the subset of a SARIF 2.1.0 log a custom gate reads.
+type sarifLog struct {
+	Runs []struct {
+		Tool struct {
+			Driver struct {
+				Name string `json:"name"`
+			} `json:"driver"`
+		} `json:"tool"`
+		Results []struct {
+			RuleID  string `json:"ruleId"`
+			Level   string `json:"level"`
+			Message struct {
+				Text string `json:"text"`
+			} `json:"message"`
+			Locations []struct {
+				PhysicalLocation struct {
+					ArtifactLocation struct {
+						URI string `json:"uri"`
+					} `json:"artifactLocation"`
+					Region struct {
+						StartLine   int `json:"startLine"`
+						StartColumn int `json:"startColumn"`
+					} `json:"region"`
+				} `json:"physicalLocation"`
+			} `json:"locations"`
+		} `json:"results"`
+	} `json:"runs"`
+}
+
+// parseSARIF reads the results of every run in a SARIF log. SARIF's
+// default level is warning; note and none map to info.
+func parseSARIF(output string) ([]verdict.Issue, error) {
+	data := jsonStart(output)
+	if data == nil {
+		return nil, fmt.Errorf("no JSON found")
+	}
+	var log sarifLog
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&log); err != nil {
+		return nil, err
+	}
+	var issues []verdict.Issue
+	for _, run := range log.Runs {
+		for _, res := range run.Results {
+			is := verdict.Issue{
+				Rule:     res.RuleID,
+				Severity: sarifSeverity(res.Level),
+				Message:  res.Message.Text,
+				Scanner:  run.Tool.Driver.Name,
+			}
+			if len(res.Locations) > 0 {
+				loc := res.Locations[0].PhysicalLocation
+				is.File = cleanIssuePath(strings.TrimPrefix(loc.ArtifactLocation.URI, "file://"))
+				is.Line = loc.Region.StartLine
+				is.Column = loc.Region.StartColumn
+			}
+			issues = append(issues, is)
+		}
+	}
+	return issues, nil
+}
+
+func sarifSeverity(level string) string {
+	switch strings.ToLower(level) {
+	case "error":
+		return "error"
+	case "note", "none":
+		return "info"
+	}
+	return "warning"
+}
+
+// jsonStart returns output from the first line that opens a JSON array or
+// object, or nil when there is none.
+func jsonStart(output string) []byte {
+	offset := 0
+	for _, line := range strings.SplitAfter(output, "\n") {
+		if t := strings.TrimSpace(line); strings.HasPrefix(t, "[") || strings.HasPrefix(t, "{") {
+			return []byte(output[offset:])
+		}
+		offset += len(line)
+	}
+	return nil
+}
+
+// appendTail adds the last lines of output to a message.
+func appendTail(msg, output string) string {
+	if lines := nonEmptyLines(output); len(lines) > 0 {
+		return msg + "\n" + tailLines(lines, maxCustomOutputLines)
+	}
+	return msg
+}
@@ -0,0 +1,216 @@
+package gates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+func TestRunCustom_ExitParser(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "tools"), 0o755)
+	var gotDir string
+	mockRunCmd(t, func(ctx context.Context, d string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		gotDir = d
+		if name != "./check.sh" || strings.Join(args, " ") != "--strict" || timeoutSec != 30 {
+			t.Fatalf("unexpected command %s %v (timeout %d)", name, args, timeoutSec)
+		}
+		return false, "checking\n\nmissing header: a.go\n", nil
+	})
+
+	r := RunCustom(context.Background(), dir, 30, CustomCheck{Name: "license", Command: []string{"./check.sh", "--strict"}, Dir: "tools"})
+	if r.Name != "license" || r.Pass {
+		t.Fatalf("expected failing license gate, got %+v", r)
+	}
+	if gotDir != filepath.Join(dir, "tools") {
+		t.Fatalf("ran in %s", gotDir)
+	}
+	if r.Output != "checking\nmissing header: a.go" {
+		t.Fatalf("unexpected output %q", r.Output)
+	}
+}
+
+func TestRunCustom_MissingCommandSkips(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		return false, "", fmt.Errorf("exec %s: %w", name, &exec.Error{Name: name, Err: exec.ErrNotFound})
+	})
+	r := RunCustom(context.Background(), t.TempDir(), 30, CustomCheck{Name: "compat", Command: []string{"apicompat"}})
+	if !r.Skipped || !r.Pass || r.Output != "apicompat not available (skipped)" {
+		t.Fatalf("expected skip, got %+v", r)
+	}
+}
+
+func TestRunCustom_MissingDirFails(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		t.Fatalf("ran %s in a missing dir", name)
+		return false, "", nil
+	})
+	r := RunCustom(context.Background(), t.TempDir(), 30, CustomCheck{Name: "lint-web", Command: []string{"npm", "run", "lint"}, Dir: "web"})
+	if r.Pass || r.Skipped || r.Output != `dir "web" is not a directory in the repo` {
+		t.Fatalf("expected failure, got %+v", r)
+	}
+}
+
+func TestRunCustom_MissingScriptFails(t *testing.T) {
+	r := RunCustom(context.Background(), t.TempDir(), 30, CustomCheck{Name: "license", Command: []string{"./check.sh"}})
+	if r.Pass || r.Skipped {
+		t.Fatalf("expected a missing repo script to fail, got %+v", r)
+	}
+}
+
+func TestRunCustom_TimeoutFails(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		return false, "partial\n", fmt.Errorf("timeout after %ds", timeoutSec)
+	})
+	r := RunCustom(context.Background(), t.TempDir(), 5, CustomCheck{Name: "compat", Command: []string{"apicompat"}})
+	if r.Pass || r.Skipped || r.Output != "timeout after 5s\npartial" {
+		t.Fatalf("expected timeout failure, got %+v", r)
+	}
+}
+
+func TestRunCustom_JSONParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		ok       bool
+		output   string
+		wantPass bool
+		wantErr  int
+		wantWarn int
+	}{
+		{"array with error", true, `[{"file":"./db/001.sql","line":3,"rule":"M1","message":"drops a column"},{"severity":"warn","message":"slow"}]`, false, 1, 1},
+		{"object with warnings only", true, "scanning\n" + `{"issues":[{"severity":"warning","file":"db/002.sql","message":"no down migration"}]}`, true, 0, 1},
+		{"no findings but non-zero exit", false, `[]`, false, 0, 0},
+		{"invalid json", true, "boom", false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+				return tt.ok, tt.output, nil
+			})
+			r := RunCustom(context.Background(), t.TempDir(), 30, CustomCheck{Name: "migrations", Command: []string{"migcheck"}, Parser: "json"})
+			if r.Pass != tt.wantPass {
+				t.Fatalf("pass = %v, want %v (%+v)", r.Pass, tt.wantPass, r)
+			}
+			if tt.name == "invalid json" {
+				if !strings.Contains(r.Output, "invalid json output") || !strings.Contains(r.Output, "boom") {
+					t.Fatalf("unexpected output %q", r.Output)
+				}
+				return
+			}
+			if r.Findings == nil || r.Findings.Errors != tt.wantErr || r.Findings.Warnings != tt.wantWarn {
+				t.Fatalf("findings = %+v", r.Findings)
+			}
+		})
+	}
+}
+
+func TestParseFindingsJSON_NormalizesIssues(t *testing.T) {
+	issues, err := parseFindingsJSON(`[{"file":"./db/001.sql","line":3,"column":2,"rule":"M1","message":"drops a column"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := verdict.Issue{Rule: "M1", Severity: "error", File: "db/001.sql", Line: 3, Column: 2, Message: "drops a column"}
+	if len(issues) != 1 || issues[0] != want {
+		t.Fatalf("issues = %+v", issues)
+	}
+}
+
+func TestParseSARIF(t *testing.T) {
+	issues, err := parseSARIF(`{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"apicompat"}},"results":[
+		{"ruleId":"API001","level":"error","message":{"text":"removed method"},
+		 "locations":[{"physicalLocation":{"artifactLocation":{"uri":"api/v1.go"},"region":{"startLine":12,"startColumn":5}}}]},
+		{"ruleId":"API002","message":{"text":"renamed field"}},
+		{"ruleId":"API003","level":"note","message":{"text":"new method"}}]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	want := verdict.Issue{Rule: "API001", Severity: "error", File: "api/v1.go", Line: 12, Column: 5, Message: "removed method", Scanner: "apicompat"}
+	if issues[0] != want {
+		t.Fatalf("issue[0] = %+v", issues[0])
+	}
+	if issues[1].Severity != "warning" || issues[2].Severity != "info" {
+		t.Fatalf("levels not mapped: %+v", issues)
+	}
+
+	if _, err := parseSARIF("not sarif"); err == nil {
+		t.Fatal("expected an error without JSON")
+	}
+}
+
+func TestParseTAP(t *testing.T) {
+	tests, err := parseTAP(`TAP version 13
+1..5
+ok 1 - parses config
+not ok 2 - rejects bad input
+  ---
+  message: expected error
+  ...
+ok 3 # SKIP no network
+not ok 4 - known bug # TODO fix later
+ok 5
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, status string }{
+		{"parses config", verdict.TestPass},
+		{"rejects bad input", verdict.TestFail},
+		{"test 3", verdict.TestSkip},
+		{"known bug", verdict.TestSkip},
+		{"test 5", verdict.TestPass},
+	}
+	if len(tests) != len(want) {
+		t.Fatalf("tests = %+v", tests)
+	}
+	for i, w := range want {
+		if tests[i].Name != w.name || tests[i].Status != w.status {
+			t.Fatalf("test %d = %+v, want %s %s", i, tests[i], w.name, w.status)
+		}
+	}
+	if !strings.Contains(tests[1].Output, "message: expected error") {
+		t.Fatalf("failure details not attached: %q", tests[1].Output)
+	}
+}
+
+func TestParseTAP_Errors(t *testing.T) {
+	tests := []struct {
+		name, output, want string
+	}{
+		{"bail out", "1..3\nok 1\nBail out! database down\n", "bail out: database down"},
+		{"plan mismatch", "1..3\nok 1\nok 2\n", "planned 3 tests, ran 2"},
+		{"no tap", "hello\n", "no TAP output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTAP(tt.output)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := parseTAP("1..0 # SKIP nothing to do\n"); err != nil {
+		t.Fatalf("empty plan: %v", err)
+	}
+}
+
+func TestRunCustom_TAPParser(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		return true, "1..2\nok 1 - a\nnot ok 2 - b\n# got 1, want 2\n", nil
+	})
+	r := RunCustom(context.Background(), t.TempDir(), 30, CustomCheck{Name: "api", Command: []string{"prove"}, Parser: "tap"})
+	if r.Pass || len(r.Tests) != 2 {
+		t.Fatalf("expected failing TAP gate, got %+v", r)
+	}
+	if !strings.HasPrefix(r.Output, "tap: 1 passed, 1 failed, 0 skipped\n--- FAIL: b") {
+		t.Fatalf("unexpected output %q", r.Output)
+	}
+}
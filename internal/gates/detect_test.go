@@ -1,6 +1,7 @@
 package gates
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -17,6 +18,9 @@ func TestDetectTestSuite_Go(t *testing.T) {
 	if cmd[0] != "go" || cmd[1] != "test" {
 		t.Fatalf("expected go test, got %v", cmd)
 	}
+	if !isGoTestJSON(cmd) {
+		t.Fatalf("expected go test -json, got %v", cmd)
+	}
 }
 
 func TestDetectTestSuite_Node(t *testing.T) {
@@ -96,7 +100,7 @@ func TestDetectLinters_Go(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	if len(linters) == 0 {
 		t.Fatal("expected go vet detection")
 	}
@@ -109,7 +113,7 @@ func TestDetectLinters_Shell(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/bash\necho hi"), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	found := false
 	for _, l := range linters {
 		if l.name == "shellcheck" {
@@ -123,7 +127,7 @@ func TestDetectLinters_Shell(t *testing.T) {
 
 func TestDetectLinters_None(t *testing.T) {
 	dir := t.TempDir()
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	if len(linters) != 0 {
 		t.Fatalf("expected no linters, got %v", linters)
 	}
@@ -134,7 +138,7 @@ func TestDetectLinters_ESLint(t *testing.T) {
 	pkg := `{"devDependencies":{"eslint":"^8.0.0"}}`
 	os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	found := false
 	for _, l := range linters {
 		if l.name == "eslint" {
@@ -150,7 +154,7 @@ func TestDetectLinters_NodeNoESLint(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"dependencies":{}}`), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	for _, l := range linters {
 		if l.name == "eslint" {
 			t.Fatal("should not detect eslint when not in deps")
@@ -162,7 +166,7 @@ func TestDetectLinters_PythonRuff_PyFiles(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "app.py"), []byte("print('hi')"), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	found := false
 	for _, l := range linters {
 		if l.name == "ruff" {
@@ -181,7 +185,7 @@ func TestDetectLinters_PythonRuff_SetupPy(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "setup.py"), []byte(""), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	found := false
 	for _, l := range linters {
 		if l.name == "ruff" {
@@ -197,7 +201,7 @@ func TestDetectLinters_PythonRuff_PyprojectToml(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(""), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	found := false
 	for _, l := range linters {
 		if l.name == "ruff" {
@@ -213,7 +217,7 @@ func TestDetectLinters_PythonRuff_SrcDir(t *testing.T) {
 	dir := t.TempDir()
 	os.MkdirAll(filepath.Join(dir, "src"), 0755)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	found := false
 	for _, l := range linters {
 		if l.name == "ruff" {
@@ -229,7 +233,7 @@ func TestDetectLinters_NoPython_NoRuff(t *testing.T) {
 	dir := t.TempDir()
 	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644)
 
-	linters := DetectLinters(dir)
+	linters := DetectLinters(context.Background(), dir)
 	for _, l := range linters {
 		if l.name == "ruff" {
 			t.Fatal("should not detect ruff in non-Python project")
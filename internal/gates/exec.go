@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +13,27 @@ import (
 // to inject mock behavior without executing real binaries.
 var runCmdFunc = runCmdImpl
 
+// lookPath finds installed tools during detection; tests replace it.
+var lookPath = exec.LookPath
+
+// timeoutsKey carries the flag commands set when they hit their timeout.
+type timeoutsKey struct{}
+
+// WatchTimeouts returns a context under which any command that hits its
+// timeout is recorded, and a func reporting whether one did. A timed-out
+// result describes the machine's load, not the repo.
+func WatchTimeouts(ctx context.Context) (context.Context, func() bool) {
+	hit := new(atomic.Bool)
+	return context.WithValue(ctx, timeoutsKey{}, hit), hit.Load
+}
+
+// noteTimeout records a timeout with the watcher on ctx, if any.
+func noteTimeout(ctx context.Context) {
+	if hit, ok := ctx.Value(timeoutsKey{}).(*atomic.Bool); ok {
+		hit.Store(true)
+	}
+}
+
 // runCmd delegates to runCmdFunc so that tests can swap in a mock.
 func runCmd(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
 	return runCmdFunc(ctx, dir, timeoutSec, name, args...)
@@ -33,6 +55,7 @@ func runCmdImpl(ctx context.Context, dir string, timeoutSec int, name string, ar
 	output := buf.String()
 
 	if ctx.Err() == context.DeadlineExceeded {
+		noteTimeout(ctx)
 		return false, output, fmt.Errorf("timeout after %ds", timeoutSec)
 	}
 
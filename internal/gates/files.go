@@ -0,0 +1,124 @@
+package gates
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// listFilesTimeout bounds the git call behind repoFiles.
+const listFilesTimeout = 30 * time.Second
+
+// skipSearchDirs are never searched for projects or scripts: dependencies,
+// build output, and fixtures.
+var skipSearchDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"testdata":     true,
+	"target":       true,
+}
+
+// shellExts are file extensions shellcheck understands.
+var shellExts = map[string]bool{".sh": true, ".bash": true, ".ksh": true, ".dash": true}
+
+// shellShebangRe matches sh, bash, dash, and ksh interpreter lines, direct
+// or through env.
+var shellShebangRe = regexp.MustCompile(`^#!\s*(?:\S*/)?(?:env\s+(?:-\S+\s+)*)?(?:ba|da|k)?sh(?:\s|$)`)
+
+// repoFiles lists the files of the repo at dir as repo-relative slash
+// paths: tracked files plus untracked ones git does not ignore. Outside a
+// git repo it walks the tree, skipping hidden directories. Paths under
+// skipSearchDirs are left out.
+//
+// git runs directly rather than through runCmd: listing files is part of
+// detection, and tests mock runCmd to observe the gate commands themselves.
+func repoFiles(ctx context.Context, dir string) []string {
+	var files []string
+	keep := func(rel string) {
+		parts := strings.Split(rel, "/")
+		for _, p := range parts[:len(parts)-1] {
+			if skipSearchDirs[p] {
+				return
+			}
+		}
+		files = append(files, rel)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, listFilesTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = dir
+	if out, err := cmd.Output(); err == nil {
+		seen := map[string]bool{}
+		for _, f := range strings.Split(string(out), "\x00") {
+			if f != "" && !seen[f] {
+				seen[f] = true
+				keep(f)
+			}
+		}
+		return files
+	}
+
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != dir && (strings.HasPrefix(d.Name(), ".") || skipSearchDirs[d.Name()]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			keep(filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files
+}
+
+// shellScripts returns the shell scripts among files: those with a shell
+// extension, and extensionless files starting with a shell shebang.
+func shellScripts(dir string, files []string) []string {
+	var scripts []string
+	for _, f := range files {
+		abs := filepath.Join(dir, filepath.FromSlash(f))
+		ext := filepath.Ext(f)
+		switch {
+		case shellExts[ext]:
+			if info, err := os.Stat(abs); err == nil && info.Mode().IsRegular() {
+				scripts = append(scripts, f)
+			}
+		case ext == "" && hasShellShebang(abs):
+			scripts = append(scripts, f)
+		}
+	}
+	return scripts
+}
+
+// hasShellShebang reports whether the file at path is a regular file whose
+// first line is a shell shebang.
+func hasShellShebang(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	head := make([]byte, 128)
+	n, err := io.ReadFull(f, head)
+	if err != nil && n == 0 {
+		return false
+	}
+	line, _, _ := bytes.Cut(head[:n], []byte("\n"))
+	return shellShebangRe.Match(bytes.TrimRight(line, "\r"))
+}
@@ -0,0 +1,319 @@
+package gates
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+// formatBatchSize caps the files per gofmt or shfmt run.
+const formatBatchSize = 100
+
+// prettierConfigs are the files that opt a repo into prettier.
+var prettierConfigs = []string{
+	".prettierrc", ".prettierrc.json", ".prettierrc.yml", ".prettierrc.yaml", ".prettierrc.json5",
+	".prettierrc.js", ".prettierrc.cjs", ".prettierrc.mjs", ".prettierrc.toml",
+	"prettier.config.js", "prettier.config.cjs", "prettier.config.mjs",
+}
+
+// cargoDiffRe matches the file header of a `cargo fmt --check` diff, in both
+// the "at line N:" and ":N:" spellings.
+var cargoDiffRe = regexp.MustCompile(`^Diff in (.+?)(?: at line \d+|:\d+):\s*$`)
+
+// formatterSpec describes one auto-detected formatter run in check-only
+// mode. None of the commands write to the tree.
+type formatterSpec struct {
+	name string
+	cmd  []string
+	// files, when set, are appended to cmd in batches of formatBatchSize.
+	files []string
+	// fix is the command that would format the files, for the report.
+	fix string
+	// parse picks the unformatted files out of one run's output; notes are
+	// other lines worth reporting, such as syntax errors.
+	parse func(dir, output string) (files, notes []string)
+}
+
+// DetectFormatters returns the formatters that apply to the repo at dir:
+// gofmt for Go files, prettier when the repo configures it, ruff for Python
+// files, rustfmt for a Cargo project, and shfmt for shell scripts when it
+// is installed.
+func DetectFormatters(ctx context.Context, dir string) []formatterSpec {
+	files := repoFiles(ctx, dir)
+	var specs []formatterSpec
+
+	var goFiles []string
+	hasPy := false
+	for _, f := range files {
+		switch filepath.Ext(f) {
+		case ".go":
+			goFiles = append(goFiles, f)
+		case ".py":
+			hasPy = true
+		}
+	}
+	if len(goFiles) > 0 {
+		specs = append(specs, formatterSpec{name: "gofmt", cmd: []string{"gofmt", "-l"}, files: goFiles, fix: "gofmt -w", parse: parseGofmt})
+	}
+
+	if fileExists(filepath.Join(dir, "package.json")) && hasPrettier(dir) {
+		specs = append(specs, formatterSpec{name: "prettier", cmd: []string{"npx", "prettier", "--check", "."}, fix: "npx prettier --write .", parse: parsePrettier})
+	}
+
+	if hasPy {
+		specs = append(specs, formatterSpec{name: "ruff format", cmd: []string{"ruff", "format", "--check", "--no-cache", "."}, fix: "ruff format", parse: parseRuffFormat})
+	}
+
+	if fileExists(filepath.Join(dir, "Cargo.toml")) {
+		specs = append(specs, formatterSpec{name: "rustfmt", cmd: []string{"cargo", "fmt", "--all", "--check"}, fix: "cargo fmt --all", parse: parseCargoFmt})
+	}
+
+	// Few shell projects adopt shfmt, so it only runs when installed.
+	if scripts := shellScripts(dir, files); len(scripts) > 0 {
+		if _, err := lookPath("shfmt"); err == nil {
+			specs = append(specs, formatterSpec{name: "shfmt", cmd: []string{"shfmt", "-d"}, files: scripts, fix: "shfmt -w", parse: parseShfmt})
+		}
+	}
+	return specs
+}
+
+// RunFormat detects and runs the formatters for the repo at dir.
+func RunFormat(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
+	return RunFormatters(ctx, dir, timeoutSec, DetectFormatters(ctx, dir))
+}
+
+// RunFormatters runs the given formatters in check-only mode and reports
+// every unformatted file as an issue. The gate fails on unformatted files
+// and on formatters that fail without naming any, e.g. on a syntax error.
+func RunFormatters(ctx context.Context, dir string, timeoutSec int, specs []formatterSpec) verdict.GateResult {
+	if len(specs) == 0 {
+		return verdict.GateResult{Name: "format", Pass: true, Skipped: true, Output: "no formatters detected (skipped)"}
+	}
+	if timeoutSec <= 0 {
+		timeoutSec = 60
+	}
+
+	var issues []verdict.Issue
+	r := verdict.TimedRun("format", func() (bool, string, error) {
+		pass := true
+		var clean []string
+		var b strings.Builder
+		for _, spec := range specs {
+			files, notes, ok, err := runFormatter(ctx, dir, timeoutSec, spec)
+			if err != nil {
+				pass = false
+				fmt.Fprintf(&b, "%s: %v\n", spec.name, err)
+				continue
+			}
+			if len(files) == 0 && ok {
+				clean = append(clean, spec.name)
+				continue
+			}
+			pass = false
+			if len(files) > 0 {
+				fmt.Fprintf(&b, "%s: %d unformatted (fix: %s)\n", spec.name, len(files), spec.fix)
+			} else {
+				fmt.Fprintf(&b, "%s: failed\n", spec.name)
+			}
+			for _, f := range files {
+				b.WriteString("  " + f + "\n")
+				issues = append(issues, verdict.Issue{
+					Severity: "error",
+					File:     f,
+					Rule:     "unformatted",
+					Scanner:  spec.name,
+					Message:  "file is not formatted; run " + spec.fix,
+				})
+			}
+			for _, n := range notes {
+				b.WriteString("  " + n + "\n")
+			}
+		}
+		if len(clean) > 0 {
+			fmt.Fprintf(&b, "formatted: %s\n", strings.Join(clean, ", "))
+		}
+		return pass, strings.TrimSpace(b.String()), nil
+	})
+	r.Issues = issues
+	return r
+}
+
+// runFormatter runs spec, once per batch of its files when it has any. ok
+// is false when a run exited non-zero without naming unformatted files.
+func runFormatter(ctx context.Context, dir string, timeoutSec int, spec formatterSpec) (files, notes []string, ok bool, err error) {
+	batches := [][]string{nil}
+	if len(spec.files) > 0 {
+		batches = nil
+		for start := 0; start < len(spec.files); start += formatBatchSize {
+			batches = append(batches, spec.files[start:min(start+formatBatchSize, len(spec.files))])
+		}
+	}
+
+	ok = true
+	seen := map[string]bool{}
+	for _, batch := range batches {
+		args := append(append([]string{}, spec.cmd[1:]...), batch...)
+		exitOK, output, err := runCmd(ctx, dir, timeoutSec, spec.cmd[0], args...)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		found, n := spec.parse(dir, output)
+		for _, f := range found {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+		if !exitOK && len(found) == 0 {
+			ok = false
+			if lines := nonEmptyLines(output); len(n) == 0 && len(lines) > 0 {
+				n = strings.Split(tailLines(lines, maxTestOutputLines), "\n")
+			}
+		}
+		notes = append(notes, n...)
+	}
+	return files, notes, ok, nil
+}
+
+// parseGofmt reads `gofmt -l`: one unformatted file per line, with parse
+// errors in "file:line:col: message" form.
+func parseGofmt(_, output string) (files, notes []string) {
+	for _, line := range nonEmptyLines(output) {
+		if lintLineRe.MatchString(line) {
+			notes = append(notes, line)
+			continue
+		}
+		files = append(files, cleanRepoPath(line))
+	}
+	return files, notes
+}
+
+// parsePrettier reads `prettier --check`, which names each unformatted file
+// on a "[warn]" line and each unparsable one on an "[error]" line.
+func parsePrettier(_, output string) (files, notes []string) {
+	for _, line := range nonEmptyLines(output) {
+		switch {
+		case strings.HasPrefix(line, "[warn] "):
+			if f := strings.TrimPrefix(line, "[warn] "); !strings.HasPrefix(f, "Code style issues") {
+				files = append(files, cleanRepoPath(f))
+			}
+		case strings.HasPrefix(line, "[error] "):
+			notes = append(notes, line)
+		}
+	}
+	return files, notes
+}
+
+// parseRuffFormat reads `ruff format --check`, which prints "Would reformat:
+// <file>" per unformatted file.
+func parseRuffFormat(_, output string) (files, notes []string) {
+	for _, line := range nonEmptyLines(output) {
+		switch {
+		case strings.HasPrefix(line, "Would reformat: "):
+			files = append(files, cleanRepoPath(strings.TrimPrefix(line, "Would reformat: ")))
+		case strings.HasPrefix(line, "error"):
+			notes = append(notes, line)
+		}
+	}
+	return files, notes
+}
+
+// parseCargoFmt reads the diff `cargo fmt --check` prints. Its file headers
+// hold absolute paths, which are made relative to dir.
+func parseCargoFmt(dir, output string) (files, notes []string) {
+	for _, line := range nonEmptyLines(output) {
+		if m := cargoDiffRe.FindStringSubmatch(line); m != nil {
+			f := m[1]
+			if rel, err := filepath.Rel(dir, f); err == nil && filepath.IsAbs(f) && !strings.HasPrefix(rel, "..") {
+				f = rel
+			}
+			files = append(files, cleanRepoPath(f))
+		} else if strings.HasPrefix(line, "error") {
+			notes = append(notes, line)
+		}
+	}
+	return files, notes
+}
+
+// parseShfmt reads the unified diff `shfmt -d` prints; each "+++" header
+// right after a "---" one names an unformatted file. Hunk bodies are
+// skipped by the counts in their "@@" headers, since a script line starting
+// with "++ " shows up there as "+++ ".
+func parseShfmt(_, output string) (files, notes []string) {
+	var prev string
+	var oldLeft, newLeft int
+	for _, line := range strings.Split(output, "\n") {
+		afterMinus := strings.HasPrefix(prev, "--- ")
+		prev = line
+		switch {
+		case oldLeft > 0 || newLeft > 0:
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			default:
+				oldLeft--
+				newLeft--
+			}
+			prev = ""
+		case afterMinus && strings.HasPrefix(line, "+++ "):
+			files = append(files, cleanRepoPath(strings.TrimSpace(strings.TrimPrefix(line, "+++ "))))
+		case strings.HasPrefix(line, "@@ "):
+			oldLeft, newLeft = hunkCounts(line)
+		case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"),
+			strings.HasPrefix(line, " "):
+			// Diff header or stray body line.
+		case lintLineRe.MatchString(line):
+			notes = append(notes, line)
+		}
+	}
+	return files, notes
+}
+
+// hunkCounts returns the old and new line counts of a "@@ -a,b +c,d @@"
+// hunk header; a missing count is 1.
+func hunkCounts(line string) (oldN, newN int) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	count := func(f string) int {
+		if len(f) < 2 {
+			return 0
+		}
+		_, n, ok := strings.Cut(f[1:], ",")
+		if !ok {
+			return 1
+		}
+		v, _ := strconv.Atoi(n)
+		return v
+	}
+	return count(fields[1]), count(fields[2])
+}
+
+// hasPrettier reports whether the repo at dir configures prettier or
+// depends on it.
+func hasPrettier(dir string) bool {
+	for _, name := range prettierConfigs {
+		if fileExists(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return hasNpmDependency(dir, "prettier")
+}
+
+func nonEmptyLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
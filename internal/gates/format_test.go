@@ -0,0 +1,177 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func formatterNames(specs []formatterSpec) []string {
+	var names []string
+	for _, s := range specs {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+func TestDetectFormatters(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{
+		"go.mod":             "module x\n",
+		"cmd/main.go":        "package main\n",
+		"vendor/dep/dep.go":  "package dep\n",
+		"package.json":       `{"devDependencies": {"prettier": "^3.0.0"}}`,
+		"tools/gen.py":       "print(1)\n",
+		"Cargo.toml":         "[package]\nname = \"x\"\n",
+		"scripts/release.sh": "echo hi\n",
+	})
+	mockLookPath(t, "shfmt")
+
+	specs := DetectFormatters(context.Background(), dir)
+	want := []string{"gofmt", "prettier", "ruff format", "rustfmt", "shfmt"}
+	if got := formatterNames(specs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("formatters = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(specs[0].files, []string{"cmd/main.go"}) {
+		t.Fatalf("gofmt files = %v", specs[0].files)
+	}
+
+	// Without a prettier config or dependency, and without shfmt installed,
+	// neither runs.
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"devDependencies": {"jest": "^29"}}`), 0o644)
+	mockLookPath(t)
+	if got := formatterNames(DetectFormatters(context.Background(), dir)); !reflect.DeepEqual(got, []string{"gofmt", "ruff format", "rustfmt"}) {
+		t.Fatalf("formatters = %v", got)
+	}
+}
+
+func TestRunFormatters_ReportsUnformattedFiles(t *testing.T) {
+	dir := t.TempDir()
+	var cmds []string
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		cmds = append(cmds, name+" "+strings.Join(args, " "))
+		switch name {
+		case "gofmt":
+			// gofmt -l exits 0 even when it lists files.
+			return true, "cmd/main.go\n", nil
+		case "ruff":
+			return false, "Would reformat: tools/gen.py\n1 file would be reformatted, 2 files already formatted\n", nil
+		case "cargo":
+			return false, "Diff in " + filepath.Join(dir, "src", "lib.rs") + " at line 3:\n-fn x(){}\n+fn x() {}\n", nil
+		}
+		return true, "Checking formatting...\nAll matched files use Prettier code style!\n", nil
+	})
+
+	specs := []formatterSpec{
+		{name: "gofmt", cmd: []string{"gofmt", "-l"}, files: []string{"cmd/main.go", "util.go"}, fix: "gofmt -w", parse: parseGofmt},
+		{name: "prettier", cmd: []string{"npx", "prettier", "--check", "."}, fix: "npx prettier --write .", parse: parsePrettier},
+		{name: "ruff format", cmd: []string{"ruff", "format", "--check", "--no-cache", "."}, fix: "ruff format", parse: parseRuffFormat},
+		{name: "rustfmt", cmd: []string{"cargo", "fmt", "--all", "--check"}, fix: "cargo fmt --all", parse: parseCargoFmt},
+	}
+	r := RunFormatters(context.Background(), dir, 30, specs)
+	if r.Name != "format" || r.Pass {
+		t.Fatalf("expected failing format gate, got %+v", r)
+	}
+	if cmds[0] != "gofmt -l cmd/main.go util.go" {
+		t.Fatalf("unexpected gofmt command %q", cmds[0])
+	}
+	var files []string
+	for _, is := range r.Issues {
+		files = append(files, is.Scanner+":"+is.File)
+	}
+	want := []string{"gofmt:cmd/main.go", "ruff format:tools/gen.py", "rustfmt:src/lib.rs"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("issues = %v, want %v", files, want)
+	}
+	for _, s := range []string{"gofmt: 1 unformatted (fix: gofmt -w)", "  tools/gen.py", "formatted: prettier"} {
+		if !strings.Contains(r.Output, s) {
+			t.Fatalf("output missing %q:\n%s", s, r.Output)
+		}
+	}
+}
+
+func TestRunFormatters_FailureWithoutFiles(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		return false, "[error] src/a.js: SyntaxError: Unexpected token (1:5)\n", nil
+	})
+	r := RunFormatters(context.Background(), t.TempDir(), 30, []formatterSpec{
+		{name: "prettier", cmd: []string{"npx", "prettier", "--check", "."}, fix: "npx prettier --write .", parse: parsePrettier},
+	})
+	if r.Pass || len(r.Issues) != 0 {
+		t.Fatalf("expected failure without issues, got %+v", r)
+	}
+	if !strings.Contains(r.Output, "prettier: failed") || !strings.Contains(r.Output, "SyntaxError") {
+		t.Fatalf("unexpected output:\n%s", r.Output)
+	}
+}
+
+func TestRunFormatters_NoneDetected(t *testing.T) {
+	r := RunFormatters(context.Background(), t.TempDir(), 30, nil)
+	if !r.Pass || !r.Skipped {
+		t.Fatalf("expected skipped pass, got %+v", r)
+	}
+}
+
+func TestRunFormat_GofmtLeavesTreeUntouched(t *testing.T) {
+	dir := t.TempDir()
+	src := "package x\nfunc  F( ) int {return 1}\n"
+	gitRepo(t, dir, map[string]string{
+		"go.mod": "module x\n",
+		"x.go":   src,
+		"ok.go":  "package x\n\nfunc G() int { return 2 }\n",
+	})
+
+	r := RunFormat(context.Background(), dir, 30)
+	if r.Pass {
+		t.Fatalf("expected unformatted x.go to fail, got %+v", r)
+	}
+	if len(r.Issues) != 1 || r.Issues[0].File != "x.go" {
+		t.Fatalf("unexpected issues %+v", r.Issues)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "x.go"))
+	if string(data) != src {
+		t.Fatalf("format gate modified x.go:\n%s", data)
+	}
+}
+
+func TestParseShfmt(t *testing.T) {
+	output := `--- scripts/a.sh.orig
++++ scripts/a.sh
+@@ -1,2 +1,2 @@
+-if [ -f x ];then
++if [ -f x ]; then
+ echo "a:1: b"
+bin/b:3:5: reached EOF without closing quote "
+`
+	files, notes := parseShfmt("", output)
+	if !reflect.DeepEqual(files, []string{"scripts/a.sh"}) {
+		t.Fatalf("files = %v", files)
+	}
+	if len(notes) != 1 || !strings.HasPrefix(notes[0], "bin/b:3:5:") {
+		t.Fatalf("notes = %v", notes)
+	}
+}
+
+func TestParseShfmt_AddedLineLooksLikeHeader(t *testing.T) {
+	// The script's own lines "-- x" and "++ y" render as "--- x" and
+	// "+++ y" inside the hunk.
+	output := `--- run.sh.orig
++++ run.sh
+@@ -2,2 +2,2 @@
+--- x
++++ y
+ done
+--- lib.sh.orig
++++ lib.sh
+@@ -1 +1 @@
+-f(){ :;}
++f() { :; }
+`
+	files, notes := parseShfmt("", output)
+	if !reflect.DeepEqual(files, []string{"run.sh", "lib.sh"}) || len(notes) != 0 {
+		t.Fatalf("files = %v, notes = %v", files, notes)
+	}
+}
@@ -0,0 +1,198 @@
+package gates
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// Fragility defaults, used when FragilityOptions leaves a field zero.
+const (
+	DefaultFragilityWindowDays = 90
+	DefaultFragilityThreshold  = 0.5
+	DefaultFragilityMinSamples = 4
+)
+
+// areaDepth is how many leading directories identify an area.
+const areaDepth = 2
+
+var (
+	revertSubjectRe = regexp.MustCompile(`(?i)^revert\b|\brevert(s|ed)?\b`)
+	fixSubjectRe    = regexp.MustCompile(`(?i)\b(fix|fixes|fixed|fixup|hotfix|bugfix)\b`)
+)
+
+// FragilityOptions controls the fragility gate.
+type FragilityOptions struct {
+	// Base is the ref to diff against; empty tries main, then master.
+	Base string
+	// WindowDays limits git history to the last N days.
+	WindowDays int
+	// Threshold is the score (0-1) at or above which an area is fragile.
+	Threshold float64
+	// MinSamples is the fewest commits plus gate verdicts needed to judge an area.
+	MinSamples int
+	// GateFailures reports how many past gate verdicts failed in an area.
+	// Nil means no verdict history is available.
+	GateFailures func(area string) int
+}
+
+// RunFragility scores how often the areas touched by the current change
+// have needed fixes, reverts, or failed the gate before. Fragile areas put
+// the gate in review rather than failing it outright.
+func RunFragility(ctx context.Context, dir string, timeoutSec int, opts FragilityOptions) verdict.GateResult {
+	if timeoutSec <= 0 {
+		timeoutSec = 60
+	}
+	if opts.WindowDays <= 0 {
+		opts.WindowDays = DefaultFragilityWindowDays
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultFragilityThreshold
+	}
+	if opts.MinSamples <= 0 {
+		opts.MinSamples = DefaultFragilityMinSamples
+	}
+
+	start := time.Now()
+	skipped := func(reason string) verdict.GateResult {
+		return verdict.GateResult{
+			Name:       "fragility",
+			Pass:       true,
+			Skipped:    true,
+			Output:     "fragility skipped: " + reason,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+
+	cs, err := DiffChangeSet(ctx, dir, opts.Base, timeoutSec)
+	if err != nil {
+		return skipped(err.Error())
+	}
+	areas := ChangedAreas(cs)
+	if len(areas) == 0 {
+		return verdict.GateResult{
+			Name:       "fragility",
+			Pass:       true,
+			Output:     "no changed areas",
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+
+	var report []verdict.AreaFragility
+	for _, area := range areas {
+		af, err := areaHistory(ctx, dir, timeoutSec, area, opts.WindowDays)
+		if err != nil {
+			return skipped(err.Error())
+		}
+		if opts.GateFailures != nil {
+			af.GateFailures = opts.GateFailures(area)
+		}
+		scoreArea(&af, opts)
+		report = append(report, af)
+	}
+
+	var lines, fragile []string
+	for _, af := range report {
+		line := fmt.Sprintf("%s: score=%.2f commits=%d fixes=%d reverts=%d gate_failures=%d",
+			af.Area, af.Score, af.Commits, af.Fixes, af.Reverts, af.GateFailures)
+		if af.Fragile {
+			fragile = append(fragile, af.Area)
+			line += " (fragile)"
+		}
+		lines = append(lines, line)
+	}
+
+	r := verdict.GateResult{
+		Name:       "fragility",
+		Pass:       true,
+		Output:     strings.Join(lines, "\n"),
+		DurationMs: time.Since(start).Milliseconds(),
+		Findings:   &verdict.Findings{Warnings: len(fragile), Info: len(report)},
+		Fragility:  report,
+	}
+	if len(fragile) > 0 {
+		r.MarkReview(fmt.Sprintf("fragile areas: %s", strings.Join(fragile, ", ")))
+	}
+	return r
+}
+
+// ChangedAreas returns the sorted, de-duplicated areas touched by cs. An area
+// is the file's directory truncated to its first two segments; files in the
+// repo root belong to ".".
+func ChangedAreas(cs ChangeSet) []string {
+	seen := map[string]bool{}
+	var areas []string
+	for _, f := range cs.Files {
+		a := areaOf(f.Path)
+		if !seen[a] {
+			seen[a] = true
+			areas = append(areas, a)
+		}
+	}
+	sort.Strings(areas)
+	return areas
+}
+
+func areaOf(p string) string {
+	dir := path.Dir(strings.ReplaceAll(p, "\\", "/"))
+	if dir == "." || dir == "/" {
+		return "."
+	}
+	segs := strings.Split(dir, "/")
+	if len(segs) > areaDepth {
+		segs = segs[:areaDepth]
+	}
+	return strings.Join(segs, "/")
+}
+
+// areaHistory counts commits, fixes, and reverts touching area within the
+// last windowDays days.
+func areaHistory(ctx context.Context, dir string, timeoutSec int, area string, windowDays int) (verdict.AreaFragility, error) {
+	spec := area
+	if area == "." {
+		// Root files only, not the whole tree.
+		spec = ":(glob)*"
+	}
+	ok, output, err := runCmd(ctx, dir, timeoutSec, "git", "log",
+		fmt.Sprintf("--since=%d.days.ago", windowDays), "--format=%s", "--", spec)
+	if err != nil {
+		return verdict.AreaFragility{}, err
+	}
+	if !ok {
+		return verdict.AreaFragility{}, fmt.Errorf("git log failed: %s", strings.TrimSpace(output))
+	}
+
+	af := verdict.AreaFragility{Area: area}
+	for _, subject := range strings.Split(output, "\n") {
+		subject = strings.TrimSpace(subject)
+		if subject == "" {
+			continue
+		}
+		af.Commits++
+		switch {
+		case revertSubjectRe.MatchString(subject):
+			af.Reverts++
+		case fixSubjectRe.MatchString(subject):
+			af.Fixes++
+		}
+	}
+	return af, nil
+}
+
+// scoreArea weights reverts and gate failures double against fixes, relative
+// to the number of samples seen, and caps the score at 1.
+func scoreArea(af *verdict.AreaFragility, opts FragilityOptions) {
+	samples := af.Commits + af.GateFailures
+	if samples == 0 {
+		return
+	}
+	weighted := af.Fixes + 2*af.Reverts + 2*af.GateFailures
+	af.Score = min(1, float64(weighted)/float64(samples))
+	af.Fragile = samples >= opts.MinSamples && af.Score >= opts.Threshold
+}
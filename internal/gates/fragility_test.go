@@ -0,0 +1,128 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+func TestChangedAreas(t *testing.T) {
+	cs := ChangeSet{Files: []FileChange{
+		{Path: "main.go"},
+		{Path: "internal/gates/risk.go"},
+		{Path: "internal/gates/deep/x.go"},
+		{Path: "cmd/gate/main.go"},
+		{Path: "README.md"},
+	}}
+	got := strings.Join(ChangedAreas(cs), ",")
+	if got != ".,cmd/gate,internal/gates" {
+		t.Fatalf("unexpected areas: %s", got)
+	}
+}
+
+func TestRevertAndFixSubjects(t *testing.T) {
+	tests := []struct {
+		subject string
+		revert  bool
+		fix     bool
+	}{
+		{`Revert "add cache"`, true, false},
+		{"fix: nil deref in parser", false, true},
+		{"fix(auth): token refresh", false, true},
+		{"Hotfix login loop", false, true},
+		{"Add prefix handling", false, false},
+		{"Refactor fixtures", false, false},
+	}
+	for _, tt := range tests {
+		if got := revertSubjectRe.MatchString(tt.subject); got != tt.revert {
+			t.Errorf("revert(%q) = %v, want %v", tt.subject, got, tt.revert)
+		}
+		if got := fixSubjectRe.MatchString(tt.subject); got != tt.fix {
+			t.Errorf("fix(%q) = %v, want %v", tt.subject, got, tt.fix)
+		}
+	}
+}
+
+func TestRunFragility_NotGitRepoSkips(t *testing.T) {
+	r := RunFragility(context.Background(), t.TempDir(), 10, FragilityOptions{})
+	if !r.Pass || !r.Skipped {
+		t.Fatalf("expected skipped pass outside git, got %+v", r)
+	}
+}
+
+func TestRunFragility_FragileAreaNeedsReview(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"pkg/parse/parse.go": "package parse\n", "docs/a.md": "a\n"})
+	for i, subject := range []string{"fix: empty input", "fix: unicode", `Revert "speedup"`, "fix: again"} {
+		os.WriteFile(filepath.Join(dir, "pkg", "parse", "parse.go"), []byte("package parse\n// "+strings.Repeat("x", i+1)+"\n"), 0o644)
+		gitRun(t, dir, "commit", "-q", "-am", subject)
+	}
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "pkg", "parse", "parse.go"), []byte("package parse\n\nfunc P() {}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "docs", "a.md"), []byte("b\n"), 0o644)
+
+	var asked []string
+	r := RunFragility(context.Background(), dir, 10, FragilityOptions{
+		Base: "main",
+		GateFailures: func(area string) int {
+			asked = append(asked, area)
+			return 0
+		},
+	})
+
+	if !r.Pass || !r.Review {
+		t.Fatalf("expected pass with review, got %+v", r)
+	}
+	if len(r.Fragility) != 2 {
+		t.Fatalf("expected 2 areas, got %+v", r.Fragility)
+	}
+	docs, parse := r.Fragility[0], r.Fragility[1]
+	if parse.Area != "pkg/parse" || !parse.Fragile || parse.Fixes != 3 || parse.Reverts != 1 {
+		t.Errorf("expected fragile pkg/parse, got %+v", parse)
+	}
+	if docs.Area != "docs" || docs.Fragile {
+		t.Errorf("expected docs not fragile, got %+v", docs)
+	}
+	if strings.Join(asked, ",") != "docs,pkg/parse" {
+		t.Errorf("expected gate history lookups per area, got %v", asked)
+	}
+	if r.Findings.Warnings != 1 {
+		t.Errorf("expected 1 fragile warning, got %+v", r.Findings)
+	}
+	if !strings.Contains(r.Output, "pkg/parse: score=1.00") {
+		t.Errorf("unexpected output: %s", r.Output)
+	}
+}
+
+func TestRunFragility_GateFailuresCount(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"svc/a.go": "package svc\n"})
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "svc", "a.go"), []byte("package svc\n\nvar X = 1\n"), 0o644)
+
+	r := RunFragility(context.Background(), dir, 10, FragilityOptions{
+		Base:         "main",
+		GateFailures: func(string) int { return 3 },
+	})
+	if !r.Review {
+		t.Fatalf("expected review from past gate failures, got %+v", r)
+	}
+	if r.Fragility[0].GateFailures != 3 {
+		t.Fatalf("expected gate failures recorded, got %+v", r.Fragility[0])
+	}
+}
+
+func TestScoreArea_BelowMinSamples(t *testing.T) {
+	af := verdict.AreaFragility{Area: "x", Commits: 2, Fixes: 2}
+	scoreArea(&af, FragilityOptions{Threshold: 0.5, MinSamples: 4})
+	if af.Fragile {
+		t.Fatalf("expected too few samples to judge, got %+v", af)
+	}
+	if af.Score != 1 {
+		t.Fatalf("expected score 1, got %v", af.Score)
+	}
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"polis/gate/internal/verdict"
 )
 
 // mockRunCmd replaces runCmdFunc for the duration of the test.
@@ -271,9 +274,12 @@ func TestRunTruthsayer_CmdFailWithNoErrors(t *testing.T) {
 	}
 }
 
-func TestRunTruthsayerCI_DelegatesToSameImpl(t *testing.T) {
+func TestRunTruthsayerCI_ScansAfterDiff(t *testing.T) {
 	var called bool
 	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name == "git" {
+			return false, "fatal: not a git repository", nil
+		}
 		called = true
 		if name != "truthsayer" {
 			t.Fatalf("expected truthsayer, got %s", name)
@@ -382,59 +388,68 @@ func TestRunUBS_FullScanArgs(t *testing.T) {
 	RunUBS(context.Background(), t.TempDir(), 30)
 }
 
-func TestRunUBSDiff_DiffArgs(t *testing.T) {
+func TestRunUBSDiff_ScopesFindingsToChangedLines(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tpanic(1)\n}\n"), 0o644)
+
 	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
-		joined := strings.Join(args, " ")
-		if !strings.Contains(joined, "--diff") {
-			t.Fatal("diff mode should include --diff")
+		if name == "git" {
+			return runCmdImpl(ctx, d, timeout, name, args...)
 		}
-		return true, `{"scanners":[],"totals":{"critical":0,"warning":0,"info":0,"files":1}}`, nil
+		if slices.Contains(args, "--diff") {
+			t.Fatalf("ubs should scan the full tree, got %v", args)
+		}
+		return false, `{"scanners":[{"scanner":"go","critical":2,"findings":[
+			{"severity":"critical","file":"main.go","line":4,"message":"panic"},
+			{"severity":"critical","file":"lib/old.go","line":9,"message":"nil deref"}
+		]}],"totals":{"critical":2,"files":2}}`, nil
 	})
 
-	RunUBSDiff(context.Background(), t.TempDir(), 30)
+	r := RunUBSDiff(context.Background(), dir, 10, "main")
+	if r.Pass {
+		t.Fatalf("expected the critical finding on a changed line to fail, got %+v", r)
+	}
+	if r.Findings.New != 1 || r.Findings.Errors != 1 || r.Findings.PreExisting != 1 {
+		t.Fatalf("unexpected findings: %+v", r.Findings)
+	}
+	if r.Issues[0].PreExisting || !r.Issues[1].PreExisting {
+		t.Fatalf("expected issues marked by diff scope, got %+v", r.Issues)
+	}
+	if r.Output != "new in diff: critical=1 warning=0 info=0; pre-existing=1" {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
 }
 
-func TestRunUBSDiff_FallbackToFullScan(t *testing.T) {
-	var callCount int
+func TestRunUBSDiff_PreExistingOnlyPasses(t *testing.T) {
 	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
-		callCount++
-		joined := strings.Join(args, " ")
-		if callCount == 1 {
-			// First call: diff mode fails (non-git context)
-			if !strings.Contains(joined, "--diff") {
-				t.Fatal("first call should be diff mode")
-			}
-			return false, "not a git repository", nil
+		if name == "git" && args[0] == "merge-base" {
+			return true, "abc123\n", nil
 		}
-		// Second call: full scan succeeds
-		if strings.Contains(joined, "--diff") {
-			t.Fatal("fallback should not include --diff")
+		if name == "git" {
+			return true, "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,0 +2,3 @@\n", nil
 		}
-		return true, `{"scanners":[],"totals":{"critical":0,"warning":0,"info":0,"files":1}}`, nil
+		return false, `{"findings":[{"severity":"critical","file":"b.go","line":3}],"totals":{"critical":1,"files":1}}`, nil
 	})
 
-	r := RunUBSDiff(context.Background(), t.TempDir(), 30)
-	if callCount != 2 {
-		t.Fatalf("expected 2 calls (diff + fallback), got %d", callCount)
-	}
-	if !r.Pass {
-		t.Fatal("expected pass after fallback")
+	r := RunUBSDiff(context.Background(), t.TempDir(), 10, "")
+	if !r.Pass || r.Findings.PreExisting != 1 {
+		t.Fatalf("pre-existing findings should not fail the diff, got %+v (%s)", r.Findings, r.Output)
 	}
 }
 
-func TestRunUBSDiff_DiffSucceeds(t *testing.T) {
-	var callCount int
+func TestRunUBSDiff_NoGitFallsBackToFullScan(t *testing.T) {
 	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
-		callCount++
+		if name == "git" {
+			return false, "fatal: not a git repository", nil
+		}
 		return true, `{"scanners":[],"totals":{"critical":0,"warning":0,"info":0,"files":1}}`, nil
 	})
 
-	r := RunUBSDiff(context.Background(), t.TempDir(), 30)
-	if callCount != 1 {
-		t.Fatalf("expected 1 call (diff succeeded), got %d", callCount)
-	}
-	if !r.Pass {
-		t.Fatal("expected pass")
+	r := RunUBSDiff(context.Background(), t.TempDir(), 30, "")
+	if !r.Pass || !strings.Contains(r.Output, "full scan: no merge base") {
+		t.Fatalf("expected full-scan fallback, got %+v", r)
 	}
 }
 
@@ -478,3 +493,78 @@ func TestRunUBS_OutputSummaryFormat(t *testing.T) {
 		t.Fatalf("expected output to contain warning=3, got: %s", r.Output)
 	}
 }
+
+func TestRunTestCommand_UsesGivenCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	var got string
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		got = name + " " + strings.Join(args, " ")
+		return true, "ok", nil
+	})
+
+	r := RunTestCommand(context.Background(), dir, 30, []string{"make", "check"})
+	if !r.Pass {
+		t.Fatal("expected pass")
+	}
+	if got != "make check" {
+		t.Fatalf("expected configured command to run, got %q", got)
+	}
+}
+
+func TestAdjustLinters_RemoveAndAdd(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+	os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/bash\n"), 0644)
+
+	specs := AdjustLinters(DetectLinters(context.Background(), dir), []string{"shellcheck"}, []CustomLinter{
+		{Name: "staticcheck", Command: []string{"staticcheck", "./..."}},
+	})
+
+	var names []string
+	for _, s := range specs {
+		names = append(names, s.name)
+	}
+	if got := strings.Join(names, ","); got != "go vet,staticcheck" {
+		t.Fatalf("expected go vet,staticcheck, got %s", got)
+	}
+}
+
+func TestAdjustLinters_CustomReplacesDetected(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	specs := AdjustLinters(DetectLinters(context.Background(), dir), nil, []CustomLinter{
+		{Name: "go vet", Command: []string{"go", "vet", "-tags", "integration", "./..."}},
+	})
+	if len(specs) != 1 {
+		t.Fatalf("expected 1 linter, got %d", len(specs))
+	}
+	if strings.Join(specs[0].cmd, " ") != "go vet -tags integration ./..." {
+		t.Fatalf("expected custom go vet command, got %v", specs[0].cmd)
+	}
+}
+
+func TestParseLintIssues(t *testing.T) {
+	output := `# polis/gate/internal/x
+./internal/x/x.go:10:2: unreachable code
+app.py:3:1: F401 'os' imported but unused
+main.c:7: warning: unused variable
+exit status 1`
+
+	issues := parseLintIssues("go vet", output)
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	want := verdict.Issue{Severity: "error", File: "internal/x/x.go", Line: 10, Column: 2, Scanner: "go vet", Message: "unreachable code"}
+	if issues[0] != want {
+		t.Errorf("issue[0] = %+v, want %+v", issues[0], want)
+	}
+	if issues[1].Rule != "F401" || issues[1].Message != "'os' imported but unused" {
+		t.Errorf("expected ruff code as rule, got %+v", issues[1])
+	}
+	if issues[2].Severity != "warning" || issues[2].Column != 0 || issues[2].Message != "unused variable" {
+		t.Errorf("expected warning without column, got %+v", issues[2])
+	}
+}
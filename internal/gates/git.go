@@ -0,0 +1,290 @@
+package gates
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultBaseRefs are tried in order when no base ref is configured.
+var defaultBaseRefs = []string{"main", "master"}
+
+// FileChange is one file touched between the merge base and the working tree.
+type FileChange struct {
+	Path    string
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+// ChangeSet is the diff between the merge base of a base ref and the
+// working tree: committed and uncommitted tracked changes, plus untracked
+// files that are not ignored, which count as added in full.
+type ChangeSet struct {
+	// Base is the resolved merge-base commit.
+	Base  string
+	Files []FileChange
+}
+
+// Lines returns the total added and deleted line counts.
+func (c ChangeSet) Lines() (added, deleted int) {
+	for _, f := range c.Files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	return added, deleted
+}
+
+// DiffChangeSet computes the change set of the repo at dir against base.
+// An empty base tries main, then master.
+func DiffChangeSet(ctx context.Context, dir, base string, timeoutSec int) (ChangeSet, error) {
+	mb, err := MergeBase(ctx, dir, base, timeoutSec)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+
+	ok, output, err := runCmd(ctx, dir, timeoutSec, "git", "diff", "--numstat", "-z", "--no-renames", mb)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	if !ok {
+		return ChangeSet{}, fmt.Errorf("git diff failed: %s", strings.TrimSpace(output))
+	}
+	files := parseNumstat(output)
+	untracked, err := untrackedFiles(ctx, dir, timeoutSec)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	for _, u := range untracked {
+		files = append(files, FileChange{Path: u.path, Added: u.lines, Binary: u.binary})
+	}
+	return ChangeSet{Base: mb, Files: files}, nil
+}
+
+// LineRange is an inclusive span of line numbers in the working-tree
+// version of a file.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// ChangedLines maps repo-relative paths to the lines added or modified
+// between the merge base of a base ref and the working tree. Untracked
+// files that are not ignored are changed in full.
+type ChangedLines struct {
+	// Base is the resolved merge-base commit.
+	Base  string
+	Files map[string][]LineRange
+}
+
+// Touches reports whether line of file is part of the change. Line 0 means
+// the whole file, which is touched if the diff touches it at all.
+func (c ChangedLines) Touches(file string, line int) bool {
+	ranges, ok := c.Files[cleanRepoPath(file)]
+	if !ok {
+		return false
+	}
+	if line <= 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffChangedLines computes the changed line ranges of the repo at dir
+// against base. An empty base tries main, then master.
+func DiffChangedLines(ctx context.Context, dir, base string, timeoutSec int) (ChangedLines, error) {
+	mb, err := MergeBase(ctx, dir, base, timeoutSec)
+	if err != nil {
+		return ChangedLines{}, err
+	}
+
+	ok, output, err := runCmd(ctx, dir, timeoutSec, "git", "-c", "core.quotePath=false", "diff",
+		"--unified=0", "--no-renames", "--no-color", "--src-prefix=a/", "--dst-prefix=b/", mb)
+	if err != nil {
+		return ChangedLines{}, err
+	}
+	if !ok {
+		return ChangedLines{}, fmt.Errorf("git diff failed: %s", strings.TrimSpace(output))
+	}
+	files := parseUnifiedZero(output)
+	untracked, err := untrackedFiles(ctx, dir, timeoutSec)
+	if err != nil {
+		return ChangedLines{}, err
+	}
+	for _, u := range untracked {
+		files[u.path] = nil
+		if u.lines > 0 {
+			files[u.path] = []LineRange{{Start: 1, End: u.lines}}
+		}
+	}
+	return ChangedLines{Base: mb, Files: files}, nil
+}
+
+// untrackedFile is a new file git does not know about yet.
+type untrackedFile struct {
+	path   string
+	lines  int
+	binary bool
+}
+
+// untrackedFiles lists the untracked, unignored regular files of the repo
+// at dir with their line counts. git diff leaves them out, yet they are as
+// much part of the change as a staged new file.
+func untrackedFiles(ctx context.Context, dir string, timeoutSec int) ([]untrackedFile, error) {
+	ok, output, err := runCmd(ctx, dir, timeoutSec, "git", "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("git ls-files failed: %s", strings.TrimSpace(output))
+	}
+	var files []untrackedFile
+	for _, name := range strings.Split(output, "\x00") {
+		if name == "" {
+			continue
+		}
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if info, err := os.Lstat(full); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			continue
+		}
+		f := untrackedFile{path: cleanRepoPath(name)}
+		// Like git, a NUL in the first 8000 bytes marks a binary file.
+		if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+			f.binary = true
+		} else {
+			f.lines = bytes.Count(data, []byte("\n"))
+			if len(data) > 0 && data[len(data)-1] != '\n' {
+				f.lines++
+			}
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// parseUnifiedZero parses `git diff --unified=0` output into the new-side
+// line ranges of each file. Files with only deletions map to no ranges;
+// deleted files are left out. A "+++ " line only names the file in a
+// file's header, right after its "--- " line; inside a hunk it is an added
+// line that starts with "++ ".
+func parseUnifiedZero(output string) map[string][]LineRange {
+	files := map[string][]LineRange{}
+	var current, prev string
+	inHeader := false
+	for _, line := range strings.Split(output, "\n") {
+		afterMinus := inHeader && strings.HasPrefix(prev, "--- ")
+		prev = line
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = ""
+			inHeader = true
+		case afterMinus && strings.HasPrefix(line, "+++ "):
+			name := strings.TrimPrefix(line, "+++ ")
+			if unq, err := strconv.Unquote(name); err == nil {
+				name = unq
+			}
+			if name == "/dev/null" {
+				current = ""
+				continue
+			}
+			current = cleanRepoPath(strings.TrimPrefix(name, "b/"))
+			if _, ok := files[current]; !ok {
+				files[current] = nil
+			}
+		case strings.HasPrefix(line, "@@ "):
+			inHeader = false
+			if current == "" {
+				continue
+			}
+			if r, ok := parseHunkHeader(line); ok {
+				files[current] = append(files[current], r)
+			}
+		}
+	}
+	return files
+}
+
+// parseHunkHeader reads the new-side range from "@@ -a,b +c,d @@". A count
+// of zero (pure deletion) yields no range.
+func parseHunkHeader(line string) (LineRange, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return LineRange{}, false
+	}
+	startStr, countStr, hasCount := strings.Cut(fields[2][1:], ",")
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return LineRange{}, false
+	}
+	count := 1
+	if hasCount {
+		if count, err = strconv.Atoi(countStr); err != nil {
+			return LineRange{}, false
+		}
+	}
+	if count == 0 {
+		return LineRange{}, false
+	}
+	return LineRange{Start: start, End: start + count - 1}, true
+}
+
+// cleanRepoPath normalises a path reported by a tool to the slash-separated,
+// repo-relative form git uses.
+func cleanRepoPath(p string) string {
+	return path.Clean(strings.ReplaceAll(p, "\\", "/"))
+}
+
+// MergeBase resolves the merge base between base and HEAD, the commit
+// diff-aware gates compare against. An empty base tries main, then master.
+func MergeBase(ctx context.Context, dir, base string, timeoutSec int) (string, error) {
+	candidates := defaultBaseRefs
+	if base != "" {
+		candidates = []string{base}
+	}
+	for _, ref := range candidates {
+		ok, output, err := runCmd(ctx, dir, timeoutSec, "git", "merge-base", ref, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return strings.TrimSpace(output), nil
+		}
+	}
+	return "", fmt.Errorf("no merge base with %s", strings.Join(candidates, " or "))
+}
+
+// parseNumstat parses `git diff --numstat -z` output. Binary files report
+// "-" for both counts.
+func parseNumstat(output string) []FileChange {
+	var files []FileChange
+	for _, rec := range strings.Split(output, "\x00") {
+		rec = strings.TrimLeft(rec, "\n")
+		parts := strings.SplitN(rec, "\t", 3)
+		if len(parts) != 3 || parts[2] == "" {
+			continue
+		}
+		fc := FileChange{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			fc.Binary = true
+		} else {
+			fc.Added, _ = strconv.Atoi(parts[0])
+			fc.Deleted, _ = strconv.Atoi(parts[1])
+		}
+		files = append(files, fc)
+	}
+	return files
+}
@@ -0,0 +1,164 @@
+package gates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+// golangciConfigs are the config files golangci-lint reads, in its order.
+var golangciConfigs = []string{".golangci.yml", ".golangci.yaml", ".golangci.toml", ".golangci.json"}
+
+// golangciV2Re matches the version key golangci-lint v2 configs declare.
+var golangciV2Re = regexp.MustCompile(`(?m)^\s*"?version"?\s*[:=]\s*["']?2["']?\s*,?\s*$`)
+
+// goLinter picks the richest Go linter the repo configures and the machine
+// has installed: golangci-lint, then staticcheck, falling back to go vet.
+func goLinter(dir string) linterSpec {
+	if cfg := golangciConfig(dir); cfg != "" {
+		if _, err := lookPath("golangci-lint"); err == nil {
+			cmd := []string{"golangci-lint", "run", "--out-format=json", "./..."}
+			if data, err := os.ReadFile(cfg); err == nil && golangciV2Re.Match(data) {
+				cmd = []string{"golangci-lint", "run", "--output.json.path=stdout", "--show-stats=false", "./..."}
+			}
+			return linterSpec{name: "golangci-lint", cmd: cmd, parse: parseGolangciJSON}
+		}
+	}
+	if fileExists(filepath.Join(dir, "staticcheck.conf")) {
+		if _, err := lookPath("staticcheck"); err == nil {
+			return linterSpec{name: "staticcheck", cmd: []string{"staticcheck", "-f", "json", "./..."}, parse: parseStaticcheckJSON}
+		}
+	}
+	return linterSpec{name: "go vet", cmd: []string{"go", "vet", "./..."}}
+}
+
+// golangciConfig returns the path of the repo's golangci-lint config, or ""
+// when it has none.
+func golangciConfig(dir string) string {
+	for _, name := range golangciConfigs {
+		if p := filepath.Join(dir, name); fileExists(p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// golangciReport is the JSON golangci-lint prints; v1 and v2 share it.
+type golangciReport struct {
+	Issues []struct {
+		FromLinter string `json:"FromLinter"`
+		Text       string `json:"Text"`
+		Severity   string `json:"Severity"`
+		Pos        struct {
+			Filename string `json:"Filename"`
+			Line     int    `json:"Line"`
+			Column   int    `json:"Column"`
+		} `json:"Pos"`
+	} `json:"Issues"`
+}
+
+// parseGolangciJSON reads golangci-lint JSON output into issues, one per
+// finding with the reporting linter as the rule. Log lines around the JSON
+// are kept in the report.
+func parseGolangciJSON(_, output string) ([]verdict.Issue, string, error) {
+	docs, notes := splitJSONLines(output)
+	if len(docs) == 0 {
+		return nil, "", fmt.Errorf("no golangci-lint JSON in output")
+	}
+	var report golangciReport
+	if err := json.Unmarshal([]byte(docs[0]), &report); err != nil {
+		return nil, "", fmt.Errorf("invalid golangci-lint JSON: %v", err)
+	}
+
+	var issues []verdict.Issue
+	var lines []string
+	for _, fd := range report.Issues {
+		is := verdict.Issue{
+			Severity: lintSeverity(fd.Severity),
+			File:     cleanIssuePath(fd.Pos.Filename),
+			Line:     fd.Pos.Line,
+			Column:   fd.Pos.Column,
+			Rule:     fd.FromLinter,
+			Scanner:  "golangci-lint",
+			Message:  fd.Text,
+		}
+		issues = append(issues, is)
+		lines = append(lines, formatLintIssue(is))
+	}
+	return issues, strings.Join(append(lines, notes...), "\n"), nil
+}
+
+// staticcheckProblem is one line of `staticcheck -f json` output.
+type staticcheckProblem struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Location struct {
+		File   string `json:"file"`
+		Line   int    `json:"line"`
+		Column int    `json:"column"`
+	} `json:"location"`
+	Message string `json:"message"`
+}
+
+// parseStaticcheckJSON reads staticcheck's JSON lines into issues. Its
+// absolute file paths are made relative to dir.
+func parseStaticcheckJSON(dir, output string) ([]verdict.Issue, string, error) {
+	docs, notes := splitJSONLines(output)
+	if len(docs) == 0 && len(notes) > 0 {
+		return nil, "", fmt.Errorf("no staticcheck JSON in output")
+	}
+
+	var issues []verdict.Issue
+	var lines []string
+	for _, doc := range docs {
+		var p staticcheckProblem
+		if err := json.Unmarshal([]byte(doc), &p); err != nil {
+			return nil, "", fmt.Errorf("invalid staticcheck JSON: %v", err)
+		}
+		file := p.Location.File
+		if rel, err := filepath.Rel(dir, file); err == nil && filepath.IsAbs(file) && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		is := verdict.Issue{
+			Severity: lintSeverity(p.Severity),
+			File:     cleanIssuePath(file),
+			Line:     p.Location.Line,
+			Column:   p.Location.Column,
+			Rule:     p.Code,
+			Scanner:  "staticcheck",
+			Message:  p.Message,
+		}
+		issues = append(issues, is)
+		lines = append(lines, formatLintIssue(is))
+	}
+	return issues, strings.Join(append(lines, notes...), "\n"), nil
+}
+
+// splitJSONLines separates the lines of output holding a JSON object from
+// the rest, such as log lines on stderr.
+func splitJSONLines(output string) (docs, notes []string) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "{"):
+			docs = append(docs, line)
+		case line != "":
+			notes = append(notes, line)
+		}
+	}
+	return docs, notes
+}
+
+// lintSeverity normalises a linter's severity; linters that leave it empty
+// fail the build on every finding, so empty means error.
+func lintSeverity(severity string) string {
+	if s := normalizeSeverity(severity); s != "" {
+		return s
+	}
+	return "error"
+}
@@ -0,0 +1,122 @@
+package gates
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mockLookPath makes only the named tools look installed.
+func mockLookPath(t *testing.T, installed ...string) {
+	t.Helper()
+	orig := lookPath
+	t.Cleanup(func() { lookPath = orig })
+	lookPath = func(name string) (string, error) {
+		for _, tool := range installed {
+			if tool == name {
+				return "/usr/local/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestDetectLinters_GoLinterChoice(t *testing.T) {
+	cases := []struct {
+		name      string
+		files     map[string]string
+		installed []string
+		want      string
+	}{
+		{"golangci v1", map[string]string{".golangci.yml": "linters:\n  enable: [errcheck]\n"}, []string{"golangci-lint"}, "golangci-lint run --out-format=json ./..."},
+		{"golangci v2", map[string]string{".golangci.yaml": "version: \"2\"\n"}, []string{"golangci-lint", "staticcheck"}, "golangci-lint run --output.json.path=stdout --show-stats=false ./..."},
+		{"golangci missing", map[string]string{".golangci.toml": "", "staticcheck.conf": "checks = [\"all\"]\n"}, []string{"staticcheck"}, "staticcheck -f json ./..."},
+		{"staticcheck missing", map[string]string{"staticcheck.conf": ""}, nil, "go vet ./..."},
+		{"no config", nil, []string{"golangci-lint", "staticcheck"}, "go vet ./..."},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0o644)
+			for name, content := range tc.files {
+				os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+			}
+			mockLookPath(t, tc.installed...)
+
+			linters := DetectLinters(context.Background(), dir)
+			if len(linters) != 1 {
+				t.Fatalf("expected 1 linter, got %d", len(linters))
+			}
+			if got := strings.Join(linters[0].cmd, " "); got != tc.want {
+				t.Fatalf("command = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunLinters_GolangciJSON(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".golangci.yml"), []byte("linters:\n  enable: [errcheck]\n"), 0o644)
+	mockLookPath(t, "golangci-lint")
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		return false, `level=warning msg="[config_reader] deprecated option"
+{"Issues":[{"FromLinter":"errcheck","Text":"Error return value of ` + "`f.Close`" + ` is not checked","Severity":"","SourceLines":["\tf.Close()"],"Pos":{"Filename":"internal/x/x.go","Offset":120,"Line":12,"Column":9}},` +
+			`{"FromLinter":"gocritic","Text":"ifElseChain: rewrite if-else to switch statement","Severity":"warning","Pos":{"Filename":"./main.go","Line":30,"Column":2}}],"Report":{"Linters":[]}}`, nil
+	})
+
+	results := RunLinters(context.Background(), dir, 30, DetectLinters(context.Background(), dir))
+	if len(results) != 1 || results[0].Name != "lint:golangci-lint" || results[0].Pass {
+		t.Fatalf("expected failing lint:golangci-lint, got %+v", results)
+	}
+	r := results[0]
+	if len(r.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", r.Issues)
+	}
+	is := r.Issues[0]
+	if is.File != "internal/x/x.go" || is.Line != 12 || is.Column != 9 || is.Rule != "errcheck" || is.Severity != "error" || is.Scanner != "golangci-lint" {
+		t.Fatalf("unexpected issue %+v", is)
+	}
+	if r.Issues[1].File != "main.go" || r.Issues[1].Severity != "warning" {
+		t.Fatalf("unexpected issue %+v", r.Issues[1])
+	}
+	if !strings.Contains(r.Output, "internal/x/x.go:12:9: error: Error return value of `f.Close` is not checked [errcheck]") ||
+		!strings.Contains(r.Output, "deprecated option") {
+		t.Fatalf("unexpected output:\n%s", r.Output)
+	}
+}
+
+func TestParseStaticcheckJSON(t *testing.T) {
+	dir := t.TempDir()
+	output := `{"code":"SA4006","severity":"error","location":{"file":"` + filepath.Join(dir, "cmd", "main.go") + `","line":7,"column":2},"end":{"file":"","line":7,"column":5},"message":"this value of err is never used"}
+{"code":"ST1005","severity":"warning","location":{"file":"` + filepath.Join(dir, "util.go") + `","line":3,"column":9},"message":"error strings should not be capitalized"}
+`
+	issues, report, err := parseStaticcheckJSON(dir, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", issues)
+	}
+	if issues[0].File != "cmd/main.go" || issues[0].Rule != "SA4006" || issues[0].Severity != "error" || issues[0].Line != 7 {
+		t.Fatalf("unexpected issue %+v", issues[0])
+	}
+	if issues[1].File != "util.go" || issues[1].Severity != "warning" {
+		t.Fatalf("unexpected issue %+v", issues[1])
+	}
+	if !strings.HasPrefix(report, "cmd/main.go:7:2: error: this value of err is never used [SA4006]") {
+		t.Fatalf("unexpected report:\n%s", report)
+	}
+
+	// A clean run prints nothing.
+	if issues, _, err := parseStaticcheckJSON(dir, ""); err != nil || len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v, %v", issues, err)
+	}
+	// Output without JSON, such as a crash, falls back to the raw text.
+	if _, _, err := parseStaticcheckJSON(dir, "panic: boom\n"); err == nil {
+		t.Fatal("expected an error for output without JSON")
+	}
+}
@@ -0,0 +1,283 @@
+package gates
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// maxTestOutputLines caps the output snippet kept for one failing test.
+const maxTestOutputLines = 20
+
+// goTestEvent is one line of `go test -json` (test2json) output.
+type goTestEvent struct {
+	Action     string  `json:"Action"`
+	Package    string  `json:"Package"`
+	Test       string  `json:"Test"`
+	Elapsed    float64 `json:"Elapsed"`
+	Output     string  `json:"Output"`
+	ImportPath string  `json:"ImportPath"` // build-output events
+}
+
+// goTestRun is the parsed result of a `go test -json` run.
+type goTestRun struct {
+	Tests    []verdict.TestCase
+	Packages []goTestPackage
+	// Other holds lines that were not test events, such as build errors
+	// written to stderr.
+	Other []string
+}
+
+// goTestPackage is the result of one package.
+type goTestPackage struct {
+	Name       string
+	Status     string
+	DurationMs int64
+	Output     []string
+}
+
+// goTestLocks holds one mutex per module directory; see lockGoTest.
+var goTestLocks sync.Map
+
+// lockGoTest serializes go test runs in dir, so the tests and coverage
+// gates do not build and run the same suite twice at once. It returns the
+// unlock func.
+func lockGoTest(dir string) func() {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	mu, _ := goTestLocks.LoadOrStore(dir, new(sync.Mutex))
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// isGoTest reports whether cmd runs go test.
+func isGoTest(cmd []string) bool {
+	return len(cmd) >= 2 && cmd[0] == "go" && cmd[1] == "test"
+}
+
+// isGoTestJSON reports whether cmd runs go test with JSON output.
+func isGoTestJSON(cmd []string) bool {
+	if !isGoTest(cmd) {
+		return false
+	}
+	for _, a := range cmd[2:] {
+		if a == "-json" || a == "--json" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoTestJSON decodes a `go test -json` event stream. It returns false
+// when output holds no test events at all, e.g. when go itself failed to
+// start.
+func parseGoTestJSON(output string) (goTestRun, bool) {
+	type key struct{ pkg, test string }
+	var (
+		run     goTestRun
+		events  int
+		tests   = map[key]*verdict.TestCase{}
+		outputs = map[key][]string{}
+		order   []key
+		pkgs    = map[string]*goTestPackage{}
+		pkgList []string
+	)
+	pkg := func(name string) *goTestPackage {
+		p, ok := pkgs[name]
+		if !ok {
+			p = &goTestPackage{Name: name}
+			pkgs[name] = p
+			pkgList = append(pkgList, name)
+		}
+		return p
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(output))
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		var ev goTestEvent
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &ev) != nil || ev.Action == "" {
+			if strings.TrimSpace(line) != "" {
+				run.Other = append(run.Other, line)
+			}
+			continue
+		}
+		events++
+
+		switch ev.Action {
+		case "build-output":
+			run.Other = append(run.Other, strings.TrimRight(ev.Output, "\n"))
+			continue
+		case "build-fail":
+			continue
+		}
+
+		if ev.Test == "" {
+			p := pkg(ev.Package)
+			switch ev.Action {
+			case "output":
+				p.Output = append(p.Output, strings.TrimRight(ev.Output, "\n"))
+			case "pass":
+				p.Status, p.DurationMs = verdict.TestPass, elapsedMs(ev.Elapsed)
+			case "fail":
+				p.Status, p.DurationMs = verdict.TestFail, elapsedMs(ev.Elapsed)
+			case "skip":
+				p.Status, p.DurationMs = verdict.TestSkip, elapsedMs(ev.Elapsed)
+			}
+			continue
+		}
+
+		k := key{ev.Package, ev.Test}
+		tc, ok := tests[k]
+		if !ok {
+			tc = &verdict.TestCase{Name: ev.Test, Suite: ev.Package}
+			tests[k] = tc
+			order = append(order, k)
+			pkg(ev.Package)
+		}
+		switch ev.Action {
+		case "output":
+			if text := strings.TrimRight(ev.Output, "\n"); !isGoTestFraming(text) {
+				outputs[k] = append(outputs[k], text)
+			}
+		case "pass":
+			tc.Status, tc.DurationMs = verdict.TestPass, elapsedMs(ev.Elapsed)
+		case "fail":
+			tc.Status, tc.DurationMs = verdict.TestFail, elapsedMs(ev.Elapsed)
+		case "skip":
+			tc.Status, tc.DurationMs = verdict.TestSkip, elapsedMs(ev.Elapsed)
+		}
+	}
+	if events == 0 {
+		return goTestRun{}, false
+	}
+
+	for _, k := range order {
+		tc := tests[k]
+		if tc.Status == "" {
+			// Started but never finished: a panic or timeout took the
+			// package down mid-test.
+			tc.Status = verdict.TestFail
+		}
+		if tc.Status != verdict.TestPass {
+			tc.Output = tailLines(outputs[k], maxTestOutputLines)
+		}
+		run.Tests = append(run.Tests, *tc)
+	}
+	for _, name := range pkgList {
+		run.Packages = append(run.Packages, *pkgs[name])
+	}
+	return run, true
+}
+
+// goTestResult turns a parsed run into the tests gate result. The output
+// summarizes counts and lists failing tests with their output instead of
+// the full log. Tests keeps only the failing tests, so the verdict does not
+// grow with the suite; every package's status and time are kept in
+// Packages, which is also where a package that failed without a failing
+// test (a build error, say) shows up.
+func goTestResult(run goTestRun, pass bool) verdict.GateResult {
+	var b strings.Builder
+	fmt.Fprintf(&b, "go test: %s in %d packages", countTests(run.Tests), len(run.Packages))
+	writeFailedTests(&b, run.Tests)
+
+	var tests []verdict.TestCase
+	failedTests := map[string]bool{}
+	for _, tc := range run.Tests {
+		if tc.Status == verdict.TestFail {
+			tests = append(tests, tc)
+			failedTests[tc.Suite] = true
+		}
+	}
+
+	var broken []goTestPackage
+	var packages []verdict.PackageResult
+	for _, p := range run.Packages {
+		packages = append(packages, verdict.PackageResult{Name: p.Name, Status: p.Status, DurationMs: p.DurationMs})
+		if p.Status == verdict.TestFail && !failedTests[p.Name] {
+			// Build errors, a failing TestMain, or a panic outside a test.
+			broken = append(broken, p)
+		}
+	}
+	sort.Slice(broken, func(i, j int) bool { return broken[i].Name < broken[j].Name })
+	for _, p := range broken {
+		fmt.Fprintf(&b, "\nFAIL %s (no failing test reported)", p.Name)
+		for _, line := range strings.Split(tailLines(p.Output, maxTestOutputLines), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "FAIL") {
+				b.WriteString("\n    " + line)
+			}
+		}
+	}
+	if !pass && len(run.Other) > 0 {
+		b.WriteString("\n" + tailLines(run.Other, maxTestOutputLines))
+	}
+
+	return verdict.GateResult{Name: "tests", Pass: pass, Output: b.String(), Tests: tests, Packages: packages}
+}
+
+// isGoTestFraming reports whether a test output line is test2json framing
+// rather than something the test printed.
+func isGoTestFraming(line string) bool {
+	for _, p := range []string{"=== RUN", "=== PAUSE", "=== CONT", "=== NAME", "--- PASS", "--- FAIL", "--- SKIP"} {
+		if strings.HasPrefix(strings.TrimSpace(line), p) {
+			return true
+		}
+	}
+	return false
+}
+
+func elapsedMs(sec float64) int64 {
+	return int64(sec * float64(time.Second/time.Millisecond))
+}
+
+// countTests formats pass/fail/skip counts, e.g. "3 passed, 1 failed, 0 skipped".
+func countTests(tests []verdict.TestCase) string {
+	var passed, failed, skipped int
+	for _, tc := range tests {
+		switch tc.Status {
+		case verdict.TestPass:
+			passed++
+		case verdict.TestFail:
+			failed++
+		case verdict.TestSkip:
+			skipped++
+		}
+	}
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", passed, failed, skipped)
+}
+
+// writeFailedTests lists each failing test with its indented output.
+func writeFailedTests(b *strings.Builder, tests []verdict.TestCase) {
+	for _, tc := range tests {
+		if tc.Status != verdict.TestFail {
+			continue
+		}
+		name := tc.Name
+		if tc.Suite != "" {
+			name = tc.Suite + " " + tc.Name
+		}
+		fmt.Fprintf(b, "\n--- FAIL: %s (%.2fs)", name, float64(tc.DurationMs)/1000)
+		for _, line := range strings.Split(tc.Output, "\n") {
+			if strings.TrimSpace(line) != "" {
+				b.WriteString("\n    " + strings.TrimSpace(line))
+			}
+		}
+	}
+}
+
+// tailLines joins the last n lines.
+func tailLines(lines []string, n int) string {
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
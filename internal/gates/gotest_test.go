@@ -0,0 +1,156 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+const goTestJSONFixture = `{"Action":"start","Package":"relay/store"}
+{"Action":"run","Package":"relay/store","Test":"TestOpen"}
+{"Action":"output","Package":"relay/store","Test":"TestOpen","Output":"=== RUN   TestOpen\n"}
+{"Action":"output","Package":"relay/store","Test":"TestOpen","Output":"--- PASS: TestOpen (0.01s)\n"}
+{"Action":"pass","Package":"relay/store","Test":"TestOpen","Elapsed":0.01}
+{"Action":"run","Package":"relay/store","Test":"TestClose"}
+{"Action":"output","Package":"relay/store","Test":"TestClose","Output":"=== RUN   TestClose\n"}
+{"Action":"output","Package":"relay/store","Test":"TestClose","Output":"    store_test.go:9: closed twice\n"}
+{"Action":"output","Package":"relay/store","Test":"TestClose","Output":"--- FAIL: TestClose (0.02s)\n"}
+{"Action":"fail","Package":"relay/store","Test":"TestClose","Elapsed":0.02}
+{"Action":"run","Package":"relay/store","Test":"TestSlow"}
+{"Action":"output","Package":"relay/store","Test":"TestSlow","Output":"--- SKIP: TestSlow (0.00s)\n"}
+{"Action":"output","Package":"relay/store","Test":"TestSlow","Output":"    store_test.go:20: short mode\n"}
+{"Action":"skip","Package":"relay/store","Test":"TestSlow","Elapsed":0}
+{"Action":"output","Package":"relay/store","Output":"FAIL\n"}
+{"Action":"fail","Package":"relay/store","Elapsed":0.5}
+{"Action":"start","Package":"relay/api"}
+{"Action":"output","Package":"relay/api","Output":"ok  \trelay/api\t0.2s\n"}
+{"Action":"pass","Package":"relay/api","Elapsed":0.2}
+`
+
+func TestParseGoTestJSON(t *testing.T) {
+	run, ok := parseGoTestJSON(goTestJSONFixture)
+	if !ok {
+		t.Fatal("expected events to parse")
+	}
+
+	want := []verdict.TestCase{
+		{Name: "TestOpen", Suite: "relay/store", Status: verdict.TestPass, DurationMs: 10},
+		{Name: "TestClose", Suite: "relay/store", Status: verdict.TestFail, DurationMs: 20, Output: "    store_test.go:9: closed twice"},
+		{Name: "TestSlow", Suite: "relay/store", Status: verdict.TestSkip, Output: "    store_test.go:20: short mode"},
+	}
+	if !slices.Equal(run.Tests, want) {
+		t.Fatalf("tests mismatch:\n got %+v\nwant %+v", run.Tests, want)
+	}
+	if len(run.Packages) != 2 || run.Packages[0].Status != verdict.TestFail || run.Packages[1].Status != verdict.TestPass {
+		t.Fatalf("unexpected packages: %+v", run.Packages)
+	}
+}
+
+func TestParseGoTestJSON_NoEvents(t *testing.T) {
+	if _, ok := parseGoTestJSON("go: cannot find main module\n"); ok {
+		t.Fatal("expected plain output not to parse as events")
+	}
+}
+
+func TestParseGoTestJSON_UnfinishedTestFails(t *testing.T) {
+	out := `{"Action":"run","Package":"p","Test":"TestHang"}
+{"Action":"output","Package":"p","Test":"TestHang","Output":"panic: test timed out after 1s\n"}
+{"Action":"fail","Package":"p","Elapsed":1}
+`
+	run, _ := parseGoTestJSON(out)
+	if len(run.Tests) != 1 || run.Tests[0].Status != verdict.TestFail || !strings.Contains(run.Tests[0].Output, "timed out") {
+		t.Fatalf("expected unfinished test to fail with its output, got %+v", run.Tests)
+	}
+}
+
+func TestRunTests_GoJSONFailingTests(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if !slices.Contains(args, "-json") {
+			t.Fatalf("expected go test -json, got %v", args)
+		}
+		return false, goTestJSONFixture, nil
+	})
+
+	r := RunTests(context.Background(), dir, 30)
+	if r.Pass {
+		t.Fatal("expected fail")
+	}
+	if len(r.Tests) != 1 || r.Tests[0].Name != "TestClose" {
+		t.Fatalf("expected only the failing test kept, got %+v", r.Tests)
+	}
+	if !strings.HasPrefix(r.Output, "go test: 1 passed, 1 failed, 1 skipped in 2 packages") {
+		t.Fatalf("unexpected summary: %s", r.Output)
+	}
+	if !strings.Contains(r.Output, "--- FAIL: relay/store TestClose (0.02s)\n    store_test.go:9: closed twice") {
+		t.Fatalf("expected failing test with its output, got: %s", r.Output)
+	}
+	if strings.Contains(r.Output, `"Action"`) || strings.Contains(r.Output, "TestOpen") {
+		t.Fatalf("expected summary, not the raw log: %s", r.Output)
+	}
+	wantPkgs := []verdict.PackageResult{
+		{Name: "relay/store", Status: verdict.TestFail, DurationMs: 500},
+		{Name: "relay/api", Status: verdict.TestPass, DurationMs: 200},
+	}
+	if !slices.Equal(r.Packages, wantPkgs) {
+		t.Fatalf("packages mismatch:\n got %+v\nwant %+v", r.Packages, wantPkgs)
+	}
+}
+
+func TestRunTests_GoJSONBuildFailure(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, `{"ImportPath":"relay/api [relay/api.test]","Action":"build-output","Output":"api/api.go:3:2: undefined: foo\n"}
+{"ImportPath":"relay/api [relay/api.test]","Action":"build-fail"}
+{"Action":"start","Package":"relay/api"}
+{"Action":"output","Package":"relay/api","Output":"FAIL\trelay/api [build failed]\n"}
+{"Action":"fail","Package":"relay/api","Elapsed":0}
+{"Action":"start","Package":"relay/cmd"}
+{"Action":"output","Package":"relay/cmd","Output":"?   \trelay/cmd\t[no test files]\n"}
+{"Action":"skip","Package":"relay/cmd","Elapsed":0}
+`, nil
+	})
+
+	r := RunTests(context.Background(), dir, 30)
+	if r.Pass {
+		t.Fatal("expected fail")
+	}
+	if !strings.Contains(r.Output, "FAIL relay/api (no failing test reported)") || !strings.Contains(r.Output, "undefined: foo") {
+		t.Fatalf("expected broken package and build error, got: %s", r.Output)
+	}
+	if len(r.Tests) != 0 {
+		t.Fatalf("expected the build failure only under packages, got tests %+v", r.Tests)
+	}
+	wantPkgs := []verdict.PackageResult{
+		{Name: "relay/api", Status: verdict.TestFail},
+		{Name: "relay/cmd", Status: verdict.TestSkip},
+	}
+	if !slices.Equal(r.Packages, wantPkgs) {
+		t.Fatalf("packages mismatch:\n got %+v\nwant %+v", r.Packages, wantPkgs)
+	}
+}
+
+func TestIsGoTestJSON(t *testing.T) {
+	for _, tc := range []struct {
+		cmd  []string
+		want bool
+	}{
+		{[]string{"go", "test", "-json", "./..."}, true},
+		{[]string{"go", "test", "./..."}, false},
+		{[]string{"make", "test", "-json"}, false},
+		{[]string{"go"}, false},
+	} {
+		if got := isGoTestJSON(tc.cmd); got != tc.want {
+			t.Errorf("isGoTestJSON(%v) = %v, want %v", tc.cmd, got, tc.want)
+		}
+	}
+}
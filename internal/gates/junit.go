@@ -0,0 +1,198 @@
+package gates
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// defaultJUnitReports are where common runners write JUnit XML when the
+// repo does not configure tests.junit: jest-junit, generic CI report
+// directories, and cargo-nextest.
+var defaultJUnitReports = []string{
+	"junit.xml",
+	"test-results/*.xml",
+	"reports/junit*.xml",
+	"target/nextest/*/junit.xml",
+}
+
+// junitSuite is a <testsuites> or <testsuite> element; runners nest them
+// to different depths.
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Time      string         `xml:"time,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Errors    []junitFailure `xml:"error"`
+	Skipped   *junitFailure  `xml:"skipped"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// requestJUnit adds the flags that make a detected test runner write a
+// JUnit report into outDir. It returns false for runners it cannot ask,
+// or when cmd already chooses a report.
+func requestJUnit(cmd []string, outDir string) ([]string, bool) {
+	switch {
+	case len(cmd) > 0 && cmd[0] == "pytest":
+		for _, a := range cmd[1:] {
+			if strings.HasPrefix(a, "--junitxml") || strings.HasPrefix(a, "--junit-xml") {
+				return cmd, false
+			}
+		}
+		return append(append([]string{}, cmd...), "--junitxml="+filepath.Join(outDir, "pytest.xml")), true
+	case len(cmd) > 0 && cmd[0] == "bats":
+		for _, a := range cmd[1:] {
+			if a == "--report-formatter" || a == "-F" || a == "--formatter" {
+				return cmd, false
+			}
+		}
+		out := []string{"bats", "--report-formatter", "junit", "--output", outDir}
+		return append(out, cmd[1:]...), true
+	}
+	return cmd, false
+}
+
+// findJUnitReports returns the report files written since start: every XML
+// file in outDir, plus the files in dir matching patterns (or the default
+// locations when patterns is empty). Older files are stale reports from a
+// previous run.
+func findJUnitReports(dir, outDir string, patterns []string, start time.Time) []string {
+	if len(patterns) == 0 {
+		patterns = defaultJUnitReports
+	}
+	var globs []string
+	if outDir != "" {
+		globs = append(globs, filepath.Join(outDir, "*.xml"))
+	}
+	for _, p := range patterns {
+		globs = append(globs, filepath.Join(dir, filepath.FromSlash(p)))
+	}
+
+	// Filesystem timestamps can be coarser than the clock.
+	since := start.Truncate(time.Second)
+	seen := map[string]bool{}
+	var files []string
+	for _, g := range globs {
+		matches, err := filepath.Glob(g)
+		if err != nil {
+			continue
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil || info.IsDir() || info.ModTime().Before(since) || seen[m] {
+				continue
+			}
+			seen[m] = true
+			files = append(files, m)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// parseJUnitReport reads the test cases from one JUnit XML document.
+func parseJUnitReport(data []byte) ([]verdict.TestCase, error) {
+	var root junitSuite
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid JUnit XML: %v", err)
+	}
+	var tests []verdict.TestCase
+	var walk func(s junitSuite)
+	walk = func(s junitSuite) {
+		for _, c := range s.Cases {
+			tests = append(tests, junitTestCase(s.Name, c))
+		}
+		for _, child := range s.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+	return tests, nil
+}
+
+func junitTestCase(suite string, c junitCase) verdict.TestCase {
+	tc := verdict.TestCase{Name: c.Name, Suite: c.ClassName, Status: verdict.TestPass}
+	if tc.Suite == "" {
+		tc.Suite = suite
+	}
+	if sec, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(c.Time), ",", ""), 64); err == nil {
+		tc.DurationMs = elapsedMs(sec)
+	}
+
+	if failures := slices.Concat(c.Failures, c.Errors); len(failures) > 0 {
+		tc.Status = verdict.TestFail
+		var lines []string
+		for _, f := range failures {
+			for _, text := range []string{f.Message, f.Body} {
+				for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+					if strings.TrimSpace(line) != "" {
+						lines = append(lines, line)
+					}
+				}
+			}
+		}
+		tc.Output = tailLines(lines, maxTestOutputLines)
+	} else if c.Skipped != nil {
+		tc.Status = verdict.TestSkip
+		tc.Output = strings.TrimSpace(c.Skipped.Message)
+	}
+	return tc
+}
+
+// junitResult reads the reports in files into the tests gate result. It
+// returns false when none of them holds a test case, so the caller keeps
+// the raw output.
+func junitResult(runner string, files []string, pass bool, output string) (verdict.GateResult, bool) {
+	var tests []verdict.TestCase
+	var problems []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err == nil {
+			var parsed []verdict.TestCase
+			parsed, err = parseJUnitReport(data)
+			tests = append(tests, parsed...)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", filepath.Base(f), err))
+		}
+	}
+	if len(tests) == 0 {
+		return verdict.GateResult{}, false
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", runner, countTests(tests))
+	writeFailedTests(&b, tests)
+	for _, p := range problems {
+		b.WriteString("\nskipped report " + p)
+	}
+	failed := false
+	for _, tc := range tests {
+		failed = failed || tc.Status == verdict.TestFail
+	}
+	if !pass && !failed {
+		// The runner failed without a failing test in the report, e.g. a
+		// collection error; its own output explains it.
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		b.WriteString("\n" + tailLines(lines, maxTestOutputLines))
+	}
+	return verdict.GateResult{Name: "tests", Pass: pass, Output: b.String(), Tests: tests}, true
+}
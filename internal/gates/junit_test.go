@@ -0,0 +1,151 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+const pytestJUnitFixture = `<?xml version="1.0" encoding="utf-8"?>
+<testsuites><testsuite name="pytest" tests="3" failures="1" skipped="1" time="0.05">
+<testcase classname="tests.test_store" name="test_open" time="0.010"/>
+<testcase classname="tests.test_store" name="test_close" time="0.020"><failure message="AssertionError: closed twice">def test_close():
+&gt;       assert store.close()
+E       AssertionError: closed twice</failure></testcase>
+<testcase classname="tests.test_store" name="test_slow" time="0.000"><skipped message="slow" type="pytest.skip"/></testcase>
+</testsuite></testsuites>`
+
+func TestParseJUnitReport_Pytest(t *testing.T) {
+	tests, err := parseJUnitReport([]byte(pytestJUnitFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 3 {
+		t.Fatalf("expected 3 tests, got %+v", tests)
+	}
+	if tests[0] != (verdict.TestCase{Name: "test_open", Suite: "tests.test_store", Status: verdict.TestPass, DurationMs: 10}) {
+		t.Errorf("unexpected passing test: %+v", tests[0])
+	}
+	if tc := tests[1]; tc.Status != verdict.TestFail || !strings.HasPrefix(tc.Output, "AssertionError: closed twice\ndef test_close():") {
+		t.Errorf("unexpected failing test: %+v", tc)
+	}
+	if tc := tests[2]; tc.Status != verdict.TestSkip || tc.Output != "slow" {
+		t.Errorf("unexpected skipped test: %+v", tc)
+	}
+}
+
+func TestParseJUnitReport_NestedSuitesAndErrors(t *testing.T) {
+	// jest-junit style: suite names without classnames on some cases, and
+	// an <error> for a test that crashed.
+	data := `<testsuites name="jest tests"><testsuite name="store.test.js">
+<testcase classname="store opens" name="store opens" time="0.004"/>
+<testcase name="closes" time="1,200.5"><error message="TypeError: x is undefined"/></testcase>
+</testsuite></testsuites>`
+	tests, err := parseJUnitReport([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 2 || tests[1].Suite != "store.test.js" || tests[1].Status != verdict.TestFail || tests[1].DurationMs != 1200500 {
+		t.Fatalf("unexpected tests: %+v", tests)
+	}
+	if tests[1].Output != "TypeError: x is undefined" {
+		t.Fatalf("expected error message as output, got %q", tests[1].Output)
+	}
+}
+
+func TestParseJUnitReport_Invalid(t *testing.T) {
+	if _, err := parseJUnitReport([]byte("<testsuites><testcase")); err == nil {
+		t.Fatal("expected error for truncated XML")
+	}
+}
+
+func TestRequestJUnit(t *testing.T) {
+	got, ok := requestJUnit([]string{"pytest"}, "/tmp/r")
+	if !ok || !slices.Equal(got, []string{"pytest", "--junitxml=/tmp/r/pytest.xml"}) {
+		t.Errorf("pytest: got %v %v", got, ok)
+	}
+	got, ok = requestJUnit([]string{"bats", "."}, "/tmp/r")
+	if !ok || !slices.Equal(got, []string{"bats", "--report-formatter", "junit", "--output", "/tmp/r", "."}) {
+		t.Errorf("bats: got %v %v", got, ok)
+	}
+	if _, ok := requestJUnit([]string{"pytest", "--junitxml=out.xml"}, "/tmp/r"); ok {
+		t.Error("expected an explicit report to be left alone")
+	}
+	if _, ok := requestJUnit([]string{"npm", "test"}, "/tmp/r"); ok {
+		t.Error("npm cannot be asked for a report")
+	}
+}
+
+func TestRunTests_PytestRequestsJUnit(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(""), 0644)
+
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name != "pytest" || len(args) != 1 || !strings.HasPrefix(args[0], "--junitxml=") {
+			t.Fatalf("expected pytest to be asked for a report, got %s %v", name, args)
+		}
+		os.WriteFile(strings.TrimPrefix(args[0], "--junitxml="), []byte(pytestJUnitFixture), 0644)
+		return false, "=== 1 failed, 1 passed, 1 skipped ===", nil
+	})
+
+	r := RunTests(context.Background(), dir, 30)
+	if r.Pass || len(r.Tests) != 3 {
+		t.Fatalf("expected failing gate with 3 tests, got %+v", r)
+	}
+	if !strings.HasPrefix(r.Output, "pytest: 1 passed, 1 failed, 1 skipped\n--- FAIL: tests.test_store test_close (0.02s)\n    AssertionError: closed twice") {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
+}
+
+func TestRunTestSuite_FindsConfiguredReport(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+	os.MkdirAll(filepath.Join(dir, "out"), 0755)
+
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		os.WriteFile(filepath.Join(d, "out", "jest.xml"), []byte(pytestJUnitFixture), 0644)
+		return false, "npm ERR! Test failed.", nil
+	})
+
+	r := RunTestSuite(context.Background(), dir, 30, TestOptions{JUnit: []string{"out/*.xml"}})
+	if len(r.Tests) != 3 || !strings.HasPrefix(r.Output, "npm: 1 passed, 1 failed") {
+		t.Fatalf("expected tests from the configured report, got %+v", r)
+	}
+}
+
+func TestRunTestSuite_IgnoresStaleReport(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0644)
+	stale := filepath.Join(dir, "junit.xml")
+	os.WriteFile(stale, []byte(pytestJUnitFixture), 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(stale, old, old)
+
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return true, "all good", nil
+	})
+
+	r := RunTests(context.Background(), dir, 30)
+	if !r.Pass || len(r.Tests) != 0 || r.Output != "all good" {
+		t.Fatalf("expected stale report to be ignored, got %+v", r)
+	}
+}
+
+func TestJUnitResult_FailureWithoutFailingTest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.xml")
+	os.WriteFile(path, []byte(`<testsuite name="s"><testcase name="ok"/></testsuite>`), 0644)
+
+	r, ok := junitResult("pytest", []string{path}, false, "ERROR collecting tests/test_io.py")
+	if !ok {
+		t.Fatal("expected report to be used")
+	}
+	if !strings.Contains(r.Output, "ERROR collecting tests/test_io.py") {
+		t.Fatalf("expected runner output when no test failed, got: %s", r.Output)
+	}
+}
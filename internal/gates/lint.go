@@ -3,8 +3,11 @@ package gates
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"polis/gate/internal/verdict"
@@ -14,15 +17,26 @@ import (
 type linterSpec struct {
 	name string
 	cmd  []string
+	// files, when set, are appended to cmd in batches of at most batch.
+	files []string
+	batch int
+	// parse reads the linter's machine-readable output into issues and a
+	// readable report. Nil keeps the raw output and uses parseLintIssues.
+	parse func(dir, output string) ([]verdict.Issue, string, error)
+}
+
+// Name is the linter name, as reported after "lint:" in results.
+func (s linterSpec) Name() string {
+	return s.name
 }
 
 // DetectLinters returns all applicable linters for the repo at dir.
-func DetectLinters(dir string) []linterSpec {
+func DetectLinters(ctx context.Context, dir string) []linterSpec {
 	var linters []linterSpec
 
 	// Go
 	if fileExists(filepath.Join(dir, "go.mod")) {
-		linters = append(linters, linterSpec{name: "go vet", cmd: []string{"go", "vet", "./..."}})
+		linters = append(linters, goLinter(dir))
 	}
 
 	// Node/eslint
@@ -41,21 +55,50 @@ func DetectLinters(dir string) []linterSpec {
 	}
 
 	// Shell
-	shFiles, err := filepath.Glob(filepath.Join(dir, "*.sh"))
-	if err == nil && len(shFiles) > 0 {
-		args := []string{}
-		for _, f := range shFiles {
-			args = append(args, f)
-		}
-		linters = append(linters, linterSpec{name: "shellcheck", cmd: append([]string{"shellcheck"}, args...)})
+	if scripts := shellScripts(dir, repoFiles(ctx, dir)); len(scripts) > 0 {
+		linters = append(linters, shellcheckSpec(scripts))
 	}
 
 	return linters
 }
 
+// CustomLinter is an extra linter declared in gate.toml.
+type CustomLinter struct {
+	Name    string
+	Command []string
+}
+
+// AdjustLinters drops detected linters whose name is in remove and appends
+// the custom linters in add. A custom linter replaces a detected one of the
+// same name.
+func AdjustLinters(specs []linterSpec, remove []string, add []CustomLinter) []linterSpec {
+	drop := make(map[string]bool, len(remove)+len(add))
+	for _, name := range remove {
+		drop[name] = true
+	}
+	for _, c := range add {
+		drop[c.Name] = true
+	}
+
+	var out []linterSpec
+	for _, s := range specs {
+		if !drop[s.name] {
+			out = append(out, s)
+		}
+	}
+	for _, c := range add {
+		out = append(out, linterSpec{name: c.Name, cmd: c.Command})
+	}
+	return out
+}
+
 // RunLint detects and runs all applicable linters for the repo at dir.
 func RunLint(ctx context.Context, dir string, timeoutSec int) []verdict.GateResult {
-	specs := DetectLinters(dir)
+	return RunLinters(ctx, dir, timeoutSec, DetectLinters(ctx, dir))
+}
+
+// RunLinters runs the given linters for the repo at dir, one result each.
+func RunLinters(ctx context.Context, dir string, timeoutSec int, specs []linterSpec) []verdict.GateResult {
 	if len(specs) == 0 {
 		return []verdict.GateResult{{Name: "lint", Pass: true, Output: "no linters detected"}}
 	}
@@ -64,19 +107,115 @@ func RunLint(ctx context.Context, dir string, timeoutSec int) []verdict.GateResu
 	}
 
 	var results []verdict.GateResult
-	for _, s := range specs {
-		spec := s
-		r := verdict.TimedRun("lint:"+spec.name, func() (bool, string, error) {
-			pass, output, err := runCmd(ctx, dir, timeoutSec, spec.cmd[0], spec.cmd[1:]...)
-			return pass, output, err
-		})
-		results = append(results, r)
+	for _, spec := range specs {
+		results = append(results, runLinter(ctx, dir, timeoutSec, spec))
 	}
 	return results
 }
 
+// parseOutput reads one run's output with s.parse, falling back to the raw
+// output when there is no parser or it cannot read it.
+func (s linterSpec) parseOutput(dir, output string) ([]verdict.Issue, string) {
+	if s.parse != nil {
+		if issues, report, err := s.parse(dir, output); err == nil {
+			return issues, report
+		}
+	}
+	return parseLintIssues(s.name, output), output
+}
+
+// runLinter runs spec, once per batch of its files when it has any, and
+// merges the results. The linter fails if any run fails.
+func runLinter(ctx context.Context, dir string, timeoutSec int, spec linterSpec) verdict.GateResult {
+	batches := [][]string{nil}
+	if len(spec.files) > 0 {
+		size := spec.batch
+		if size <= 0 {
+			size = len(spec.files)
+		}
+		batches = nil
+		for start := 0; start < len(spec.files); start += size {
+			batches = append(batches, spec.files[start:min(start+size, len(spec.files))])
+		}
+	}
+
+	var issues []verdict.Issue
+	r := verdict.TimedRun("lint:"+spec.name, func() (bool, string, error) {
+		pass := true
+		var reports []string
+		for _, batch := range batches {
+			args := append(append([]string{}, spec.cmd[1:]...), batch...)
+			ok, output, err := runCmd(ctx, dir, timeoutSec, spec.cmd[0], args...)
+			if err != nil {
+				return false, output, err
+			}
+			pass = pass && ok
+			found, report := spec.parseOutput(dir, output)
+			issues = append(issues, found...)
+			if report != "" {
+				reports = append(reports, report)
+			}
+		}
+		return pass, strings.Join(reports, "\n"), nil
+	})
+	r.Issues = issues
+	return r
+}
+
+var (
+	lintLineRe = regexp.MustCompile(`^(\S[^:]*):(\d+)(?::(\d+))?:\s*(.+)$`)
+	lintCodeRe = regexp.MustCompile(`^[A-Z]{1,4}\d{2,5}$`)
+)
+
+// parseLintIssues picks "file:line[:col]: message" lines out of linter
+// output, the format go vet, ruff, and most compilers share. A leading code
+// such as F401 or SC2086 becomes the rule.
+func parseLintIssues(linter, output string) []verdict.Issue {
+	var issues []verdict.Issue
+	for _, line := range strings.Split(output, "\n") {
+		m := lintLineRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		is := verdict.Issue{
+			Severity: "error",
+			File:     cleanRepoPath(m[1]),
+			Scanner:  linter,
+			Message:  m[4],
+		}
+		is.Line, _ = strconv.Atoi(m[2])
+		is.Column, _ = strconv.Atoi(m[3])
+		if code, rest, ok := strings.Cut(is.Message, " "); ok && lintCodeRe.MatchString(code) {
+			is.Rule = code
+			is.Message = strings.TrimSpace(rest)
+		}
+		if msg, ok := strings.CutPrefix(is.Message, "warning: "); ok {
+			is.Severity = "warning"
+			is.Message = msg
+		}
+		issues = append(issues, is)
+	}
+	return issues
+}
+
+// formatLintIssue renders a structured finding as a report line,
+// "file:line:col: severity: message [rule]".
+func formatLintIssue(is verdict.Issue) string {
+	line := fmt.Sprintf("%s:%d:%d: %s: %s", is.File, is.Line, is.Column, is.Severity, is.Message)
+	if is.Rule != "" {
+		line += " [" + is.Rule + "]"
+	}
+	return line
+}
+
 // hasESLint checks if eslint is a devDependency or dependency in package.json.
 func hasESLint(dir string) bool {
+	return hasNpmDependency(dir, "eslint")
+}
+
+// hasNpmDependency reports whether package.json in dir mentions name in its
+// dependencies or devDependencies.
+func hasNpmDependency(dir, name string) bool {
 	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
 	if err != nil {
 		return false
@@ -87,7 +226,7 @@ func hasESLint(dir string) bool {
 	}
 	for _, key := range []string{"devDependencies", "dependencies"} {
 		if raw, ok := pkg[key]; ok {
-			if strings.Contains(string(raw), "eslint") {
+			if strings.Contains(string(raw), name) {
 				return true
 			}
 		}
@@ -0,0 +1,198 @@
+package gates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// PluginProtocol is the version of the plugin request and response format.
+// A plugin must answer with the version it was asked to speak.
+const PluginProtocol = 1
+
+// PluginPrefix names plugin binaries: plugin "x" is gate-plugin-x.
+const PluginPrefix = "gate-plugin-"
+
+// pluginWaitDelay bounds how long a timed-out plugin's children may hold
+// its output open.
+const pluginWaitDelay = 2 * time.Second
+
+// Plugin is an external gate binary.
+type Plugin struct {
+	Name string
+	// Path is the binary, absolute or relative to the repo. Empty finds
+	// gate-plugin-<name> on PATH.
+	Path string
+}
+
+// DiscoverPlugins returns the names of the gate-plugin-<name> executables
+// on PATH, sorted. A name found in several PATH directories is listed once.
+func DiscoverPlugins() []string {
+	var names []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := strings.CutPrefix(e.Name(), PluginPrefix)
+			if !ok || name == "" || slices.Contains(names, name) {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(dir, e.Name()))
+			if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// PluginRequest is written to a plugin's stdin as JSON.
+type PluginRequest struct {
+	Protocol int    `json:"protocol"`
+	Name     string `json:"name"`
+	// Repo is the absolute path of the tree to check; the plugin also runs
+	// there.
+	Repo  string `json:"repo"`
+	Level string `json:"level"`
+	// Base is the merge-base commit diff-aware checks compare against, and
+	// Head the commit under check. Either is empty when unknown.
+	Base       string `json:"base,omitempty"`
+	Head       string `json:"head,omitempty"`
+	TimeoutSec int    `json:"timeout_sec"`
+}
+
+// pluginResponse is what a plugin prints on stdout: a gate result plus the
+// protocol version. Name, duration, and caching are filled in by gate.
+type pluginResponse struct {
+	Protocol int `json:"protocol"`
+	verdict.GateResult
+}
+
+// RunPlugin runs plugin p with req on stdin and turns its response into a
+// gate result. A plugin reports a failing check with "pass": false and
+// exits 0; one that is not installed is skipped, and one that times out,
+// exits non-zero, or answers with anything but a valid response fails, with
+// its stderr attached.
+func RunPlugin(ctx context.Context, p Plugin, req PluginRequest) verdict.GateResult {
+	req.Protocol = PluginProtocol
+	req.Name = p.Name
+
+	start := time.Now()
+	fail := func(msg, stderr string) verdict.GateResult {
+		return verdict.GateResult{Name: p.Name, Pass: false, Output: appendTail(msg, stderr), DurationMs: time.Since(start).Milliseconds()}
+	}
+
+	bin, display := p.Path, p.Path
+	switch {
+	case bin == "":
+		display = PluginPrefix + p.Name
+		found, err := lookPath(display)
+		if err != nil {
+			return verdict.GateResult{Name: p.Name, Pass: true, Skipped: true, Output: display + " not found on PATH (skipped)"}
+		}
+		bin = found
+	case !filepath.IsAbs(bin):
+		bin = filepath.Join(req.Repo, bin)
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return fail(fmt.Sprintf("plugin %s: %v", display, err), "")
+	}
+	stdout, stderr, err := runPlugin(ctx, req.Repo, req.TimeoutSec, input, bin)
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		return fail(fmt.Sprintf("plugin %s crashed: %v", display, exitErr), stderr)
+	case errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist):
+		return verdict.GateResult{Name: p.Name, Pass: true, Skipped: true, Output: display + " not found (skipped)", DurationMs: time.Since(start).Milliseconds()}
+	case err != nil:
+		return fail(fmt.Sprintf("plugin %s %v", display, err), stderr)
+	}
+
+	var resp pluginResponse
+	if err := json.NewDecoder(bytes.NewReader(stdout)).Decode(&resp); err != nil {
+		return fail(fmt.Sprintf("plugin %s returned an invalid response: %v", display, err), stderr)
+	}
+	if resp.Protocol != PluginProtocol {
+		return fail(fmt.Sprintf("plugin %s answered with protocol %d, gate speaks %d", display, resp.Protocol, PluginProtocol), stderr)
+	}
+
+	r := pluginResult(p.Name, resp.GateResult)
+	r.DurationMs = time.Since(start).Milliseconds()
+	return r
+}
+
+// pluginResult cleans up a plugin's reported result: gate owns the name,
+// timing, and cache fields, a skipped result passes, review only applies
+// to a pass, and findings are tallied from issues when the plugin did not
+// count them.
+func pluginResult(name string, got verdict.GateResult) verdict.GateResult {
+	r := verdict.GateResult{
+		Name:     name,
+		Pass:     got.Pass || got.Skipped,
+		Skipped:  got.Skipped,
+		Output:   got.Output,
+		Findings: got.Findings,
+		Issues:   got.Issues,
+		Tests:    got.Tests,
+		Packages: got.Packages,
+	}
+	for i := range r.Issues {
+		r.Issues[i].Severity = lintSeverity(r.Issues[i].Severity)
+		r.Issues[i].File = cleanIssuePath(r.Issues[i].File)
+	}
+	if r.Findings == nil && len(r.Issues) > 0 {
+		f := tallyIssues(r.Issues, false)
+		r.Findings = &f
+	}
+	if got.Review {
+		reason := got.ReviewReason
+		if reason == "" {
+			reason = "plugin requested review"
+		}
+		r.MarkReview(reason)
+	}
+	return r
+}
+
+// runPlugin runs bin in dir with input on stdin and returns stdout and
+// stderr separately: stdout carries the response, stderr is for humans.
+func runPlugin(ctx context.Context, dir string, timeoutSec int, input []byte, bin string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSec)*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, bin)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = pluginWaitDelay
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		noteTimeout(ctx)
+		return stdout.Bytes(), stderr.String(), fmt.Errorf("timed out after %ds", timeoutSec)
+	}
+	return stdout.Bytes(), stderr.String(), err
+}
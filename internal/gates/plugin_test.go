@@ -0,0 +1,150 @@
+package gates
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writePlugin puts an executable gate-plugin-<name> shell script in dir.
+func writePlugin(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, PluginPrefix+name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	t.Setenv("PATH", first+string(os.PathListSeparator)+second)
+	writePlugin(t, first, "apicompat", "exit 0\n")
+	writePlugin(t, second, "apicompat", "exit 0\n")
+	writePlugin(t, second, "license", "exit 0\n")
+	os.WriteFile(filepath.Join(second, PluginPrefix+"notes"), []byte("not executable"), 0o644)
+	os.Mkdir(filepath.Join(second, PluginPrefix+"dir"), 0o755)
+
+	got := DiscoverPlugins()
+	if want := []string{"apicompat", "license"}; !slices.Equal(got, want) {
+		t.Fatalf("DiscoverPlugins() = %v, want %v", got, want)
+	}
+}
+
+func TestRunPlugin_Protocol(t *testing.T) {
+	bin, repo := t.TempDir(), t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	reqFile := filepath.Join(t.TempDir(), "request.json")
+	writePlugin(t, bin, "apicompat", `cat > `+reqFile+`
+echo "comparing" >&2
+cat <<'EOF'
+{"protocol": 1, "name": "ignored", "pass": false, "output": "1 breaking change",
+ "issues": [{"rule": "API001", "severity": "critical", "file": "./api/v1.go", "line": 12, "message": "removed method"},
+            {"rule": "API002", "severity": "warn", "message": "renamed field"}]}
+EOF
+`)
+
+	r := RunPlugin(context.Background(), Plugin{Name: "apicompat"}, PluginRequest{
+		Repo: repo, Level: "standard", Base: "base-sha", Head: "head-sha", TimeoutSec: 30,
+	})
+	if r.Name != "apicompat" || r.Pass || r.Skipped || r.Output != "1 breaking change" {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	if len(r.Issues) != 2 || r.Issues[0].Severity != "error" || r.Issues[0].File != "api/v1.go" || r.Issues[1].Severity != "warning" {
+		t.Fatalf("issues not normalized: %+v", r.Issues)
+	}
+	if r.Findings == nil || r.Findings.Errors != 1 || r.Findings.Warnings != 1 {
+		t.Fatalf("findings = %+v", r.Findings)
+	}
+
+	data, err := os.ReadFile(reqFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req PluginRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("request is not JSON: %s", data)
+	}
+	want := PluginRequest{Protocol: 1, Name: "apicompat", Repo: repo, Level: "standard", Base: "base-sha", Head: "head-sha", TimeoutSec: 30}
+	if req != want {
+		t.Fatalf("request = %+v, want %+v", req, want)
+	}
+}
+
+func TestRunPlugin_ReviewAndSkip(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	writePlugin(t, bin, "review", `echo '{"protocol":1,"pass":true,"review":true,"review_reason":"new public API"}'`)
+	writePlugin(t, bin, "skip", `echo '{"protocol":1,"skipped":true,"output":"no API files"}'`)
+
+	r := RunPlugin(context.Background(), Plugin{Name: "review"}, PluginRequest{Repo: t.TempDir(), TimeoutSec: 30})
+	if !r.Pass || !r.Review || r.ReviewReason != "new public API" {
+		t.Fatalf("expected review, got %+v", r)
+	}
+	r = RunPlugin(context.Background(), Plugin{Name: "skip"}, PluginRequest{Repo: t.TempDir(), TimeoutSec: 30})
+	if !r.Pass || !r.Skipped || r.Output != "no API files" {
+		t.Fatalf("expected a passing skip, got %+v", r)
+	}
+}
+
+func TestRunPlugin_RepoRelativePath(t *testing.T) {
+	repo := t.TempDir()
+	os.Mkdir(filepath.Join(repo, "tools"), 0o755)
+	writePlugin(t, filepath.Join(repo, "tools"), "local", `echo '{"protocol":1,"pass":true,"output":"ok"}'`)
+
+	r := RunPlugin(context.Background(), Plugin{Name: "local", Path: "tools/gate-plugin-local"}, PluginRequest{Repo: repo, TimeoutSec: 30})
+	if !r.Pass || r.Output != "ok" {
+		t.Fatalf("unexpected result %+v", r)
+	}
+
+	r = RunPlugin(context.Background(), Plugin{Name: "gone", Path: "tools/gate-plugin-gone"}, PluginRequest{Repo: repo, TimeoutSec: 30})
+	if !r.Skipped || r.Output != "tools/gate-plugin-gone not found (skipped)" {
+		t.Fatalf("expected a skip for a missing path, got %+v", r)
+	}
+}
+
+func TestRunPlugin_NotOnPath(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	r := RunPlugin(context.Background(), Plugin{Name: "absent"}, PluginRequest{Repo: t.TempDir(), TimeoutSec: 30})
+	if !r.Pass || !r.Skipped || r.Output != "gate-plugin-absent not found on PATH (skipped)" {
+		t.Fatalf("expected skip, got %+v", r)
+	}
+}
+
+func TestRunPlugin_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout int
+		want    []string
+	}{
+		{"bad json", "echo 'warming up'\necho 'broken' >&2\n", 30, []string{"plugin gate-plugin-bad-json returned an invalid response", "broken"}},
+		{"empty output", "exit 0\n", 30, []string{"invalid response: EOF"}},
+		{"crash", "echo 'panic: nil map' >&2\nexit 3\n", 30, []string{"plugin gate-plugin-crash crashed: exit status 3", "panic: nil map"}},
+		{"timeout", "echo 'still going' >&2\nexec sleep 10\n", 1, []string{"plugin gate-plugin-timeout timed out after 1s", "still going"}},
+		{"wrong protocol", `echo '{"protocol":2,"pass":true}'`, 30, []string{"answered with protocol 2, gate speaks 1"}},
+		{"missing protocol", `echo '{"pass":true}'`, 30, []string{"answered with protocol 0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bin := t.TempDir()
+			t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+			name := strings.ReplaceAll(tt.name, " ", "-")
+			writePlugin(t, bin, name, tt.script)
+
+			r := RunPlugin(context.Background(), Plugin{Name: name}, PluginRequest{Repo: t.TempDir(), TimeoutSec: tt.timeout})
+			if r.Pass || r.Skipped || r.Name != name {
+				t.Fatalf("expected a failing result, got %+v", r)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(r.Output, w) {
+					t.Fatalf("output %q missing %q", r.Output, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package gates
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Test ecosystems, in the order projects sharing a directory run.
+const (
+	EcosystemGo     = "go"
+	EcosystemNpm    = "npm"
+	EcosystemPytest = "pytest"
+	EcosystemCargo  = "cargo"
+	EcosystemBats   = "bats"
+)
+
+var ecosystemOrder = []string{EcosystemGo, EcosystemNpm, EcosystemPytest, EcosystemCargo, EcosystemBats}
+
+// TestProject is one test suite in a repo: an ecosystem rooted at a
+// directory.
+type TestProject struct {
+	Ecosystem string
+	// Dir is repo-relative with forward slashes; "." is the repo root.
+	Dir     string
+	Command []string
+}
+
+// Label names the project's tests gate, e.g. "tests:go:./svc".
+func (p TestProject) Label() string {
+	dir := p.Dir
+	if dir != "." {
+		dir = "./" + dir
+	}
+	return "tests:" + p.Ecosystem + ":" + dir
+}
+
+// DetectTestProjects finds every test project in the repo at dir. Files
+// come from git, so ignored paths are skipped; outside a git repo the tree
+// is walked instead. A directory can hold projects of several ecosystems.
+// When nothing qualifies, the root falls back to DetectTestSuite.
+func DetectTestProjects(ctx context.Context, dir string) []TestProject {
+	byDir := map[string]map[string]bool{}
+	for _, f := range projectFiles(ctx, dir) {
+		d, name := path.Split(f)
+		d = strings.TrimSuffix(d, "/")
+		if d == "" {
+			d = "."
+		}
+		if byDir[d] == nil {
+			byDir[d] = map[string]bool{}
+		}
+		byDir[d][name] = true
+	}
+
+	var projects []TestProject
+	for d, files := range byDir {
+		abs := filepath.Join(dir, filepath.FromSlash(d))
+		add := func(eco string, cmd ...string) {
+			projects = append(projects, TestProject{Ecosystem: eco, Dir: d, Command: cmd})
+		}
+		if files["go.mod"] {
+			add(EcosystemGo, "go", "test", "-json", "./...")
+		}
+		if files["package.json"] && hasNpmTestScript(abs) {
+			add(EcosystemNpm, "npm", "test")
+		}
+		if files["pyproject.toml"] || files["setup.py"] {
+			add(EcosystemPytest, "pytest")
+		}
+		if files["Cargo.toml"] && !inCargoWorkspace(dir, d) {
+			add(EcosystemCargo, "cargo", "test")
+		}
+		for name := range files {
+			if strings.HasSuffix(name, ".bats") {
+				add(EcosystemBats, "bats", ".")
+				break
+			}
+		}
+	}
+
+	if len(projects) == 0 {
+		if cmd := DetectTestSuite(dir); cmd != nil {
+			// Each detected command starts with its ecosystem's name.
+			return []TestProject{{Ecosystem: cmd[0], Dir: ".", Command: cmd}}
+		}
+		return nil
+	}
+
+	rank := map[string]int{}
+	for i, e := range ecosystemOrder {
+		rank[e] = i
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].Dir != projects[j].Dir {
+			// The root sorts first.
+			return projects[i].Dir == "." || (projects[j].Dir != "." && projects[i].Dir < projects[j].Dir)
+		}
+		return rank[projects[i].Ecosystem] < rank[projects[j].Ecosystem]
+	})
+	return projects
+}
+
+// projectFiles lists repo-relative paths that can mark a test project.
+func projectFiles(ctx context.Context, dir string) []string {
+	var files []string
+	for _, f := range repoFiles(ctx, dir) {
+		switch name := path.Base(f); {
+		case name == "go.mod", name == "package.json", name == "pyproject.toml",
+			name == "setup.py", name == "Cargo.toml", strings.HasSuffix(name, ".bats"):
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// hasNpmTestScript reports whether package.json in dir defines a real test
+// script, not the `npm init` placeholder.
+func hasNpmTestScript(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return false
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return false
+	}
+	script := pkg.Scripts["test"]
+	return strings.TrimSpace(script) != "" && !strings.Contains(script, "no test specified")
+}
+
+// inCargoWorkspace reports whether a parent of rel declares a Cargo
+// workspace, whose root already tests its members.
+func inCargoWorkspace(root, rel string) bool {
+	if rel == "." {
+		return false
+	}
+	for d := path.Dir(rel); ; d = path.Dir(d) {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(d), "Cargo.toml"))
+		if err == nil && strings.Contains(string(data), "[workspace]") {
+			return true
+		}
+		if d == "." {
+			return false
+		}
+	}
+}
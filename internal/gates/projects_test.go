@@ -0,0 +1,98 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func projectLabels(projects []TestProject) []string {
+	var labels []string
+	for _, p := range projects {
+		labels = append(labels, p.Label())
+	}
+	return labels
+}
+
+func TestDetectTestProjects_Monorepo(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{
+		".gitignore":                 "build/\n",
+		"go.mod":                     "module mono\n",
+		"svc/go.mod":                 "module mono/svc\n",
+		"web/package.json":           `{"scripts": {"test": "jest"}}`,
+		"web/node_modules/x/go.mod":  "module x\n",
+		"tools/lint/pyproject.toml":  "[project]\nname = \"lint\"\n",
+		"scripts/test/cli.bats":      "@test \"x\" { true; }\n",
+		"docs/package.json":          `{"scripts": {"test": "echo \"Error: no test specified\" && exit 1"}}`,
+		"internal/x/testdata/go.mod": "module fixture\n",
+		"rust/Cargo.toml":            "[workspace]\nmembers = [\"core\"]\n",
+		"rust/core/Cargo.toml":       "[package]\nname = \"core\"\n",
+	})
+	// Ignored, untracked project files are not picked up.
+	os.MkdirAll(filepath.Join(dir, "build"), 0o755)
+	os.WriteFile(filepath.Join(dir, "build", "go.mod"), []byte("module gen\n"), 0o644)
+
+	got := projectLabels(DetectTestProjects(context.Background(), dir))
+	want := []string{
+		"tests:go:.",
+		"tests:cargo:./rust",
+		"tests:bats:./scripts/test",
+		"tests:go:./svc",
+		"tests:pytest:./tools/lint",
+		"tests:npm:./web",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("projects = %v\nwant %v", got, want)
+	}
+}
+
+func TestDetectTestProjects_SameDirSeveralEcosystems(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x"), 0o644)
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts":{"test":"vitest"}}`), 0o644)
+	os.MkdirAll(filepath.Join(dir, ".cache", "y"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".cache", "y", "go.mod"), []byte("module y"), 0o644)
+
+	// Not a git repo: the tree walk skips hidden directories.
+	projects := DetectTestProjects(context.Background(), dir)
+	if got := projectLabels(projects); !reflect.DeepEqual(got, []string{"tests:go:.", "tests:npm:."}) {
+		t.Fatalf("projects = %v", got)
+	}
+	if !isGoTestJSON(projects[0].Command) {
+		t.Fatalf("expected go test -json, got %v", projects[0].Command)
+	}
+}
+
+func TestDetectTestProjects_FallsBackToRootDetection(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0o644)
+
+	projects := DetectTestProjects(context.Background(), dir)
+	if len(projects) != 1 || projects[0].Label() != "tests:npm:." || projects[0].Command[0] != "npm" {
+		t.Fatalf("expected root npm fallback, got %+v", projects)
+	}
+	if got := DetectTestProjects(context.Background(), t.TempDir()); got != nil {
+		t.Fatalf("expected no projects in an empty repo, got %+v", got)
+	}
+}
+
+func TestRunTestProject_RunsInProjectDir(t *testing.T) {
+	dir := t.TempDir()
+	var ranIn string
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		ranIn = d
+		return true, "ok", nil
+	})
+
+	p := TestProject{Ecosystem: EcosystemNpm, Dir: "web", Command: []string{"npm", "test"}}
+	r := RunTestProject(context.Background(), dir, 30, p, TestOptions{})
+	if ranIn != filepath.Join(dir, "web") {
+		t.Fatalf("expected run in project dir, got %s", ranIn)
+	}
+	if r.Name != "tests:npm:./web" || !r.Pass {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
@@ -0,0 +1,243 @@
+package gates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+// testKey identifies a test across runs.
+type testKey struct{ suite, name string }
+
+// testRerunner runs the given failing tests again and returns the status
+// of each test it saw.
+type testRerunner func(ctx context.Context, failing []verdict.TestCase) (map[testKey]string, error)
+
+// retryFailedTests reruns the failing tests in r up to retries times. Tests
+// that pass on a rerun are marked flaky; if every failure turns out flaky,
+// the gate passes but needs review. Runners other than `go test -json` and
+// pytest, and failures that are not individual tests, are not retried.
+func retryFailedTests(ctx context.Context, dir string, timeoutSec int, cmd []string, r *verdict.GateResult, retries int) {
+	if r.Pass || len(r.Tests) == 0 {
+		return
+	}
+	var rerun testRerunner
+	var retriable func(tc verdict.TestCase) bool
+	switch {
+	case isGoTestJSON(cmd):
+		rerun = goTestRerunner(dir, timeoutSec, cmd)
+		retriable = func(verdict.TestCase) bool { return true }
+	case isPytest(cmd):
+		rerun = pytestRerunner(dir, timeoutSec, cmd)
+		retriable = func(tc verdict.TestCase) bool { return pytestNodeID(dir, tc) != "" }
+	default:
+		return
+	}
+
+	var pending []int
+	for i, tc := range r.Tests {
+		if tc.Status == verdict.TestFail && retriable(tc) {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	var notes []string
+	attempt := 1
+	for attempt <= retries && len(pending) > 0 {
+		attempt++
+		failing := make([]verdict.TestCase, len(pending))
+		for j, i := range pending {
+			failing[j] = r.Tests[i]
+		}
+		statuses, err := rerun(ctx, failing)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("retry %d: %v", attempt-1, err))
+			break
+		}
+		var still []int
+		for _, i := range pending {
+			tc := &r.Tests[i]
+			tc.Attempts = attempt
+			if rerunStatus(statuses, *tc) == verdict.TestPass {
+				tc.Status = verdict.TestPass
+				tc.Flaky = true
+				notes = append(notes, fmt.Sprintf("flaky: %s %s (passed on attempt %d)", tc.Suite, tc.Name, attempt))
+				continue
+			}
+			still = append(still, i)
+		}
+		pending = still
+	}
+	for _, i := range pending {
+		if r.Tests[i].Attempts == 0 {
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("still failing after %d attempts: %s %s", r.Tests[i].Attempts, r.Tests[i].Suite, r.Tests[i].Name))
+	}
+	if len(notes) > 0 {
+		r.Output += "\n" + strings.Join(notes, "\n")
+	}
+
+	for _, p := range r.Packages {
+		// A package that failed without a failing test (build errors,
+		// TestMain) was not retried.
+		if p.Status == verdict.TestFail && !slices.ContainsFunc(r.Tests, func(tc verdict.TestCase) bool { return tc.Suite == p.Name }) {
+			return
+		}
+	}
+	var flaky []string
+	for _, tc := range r.Tests {
+		if tc.Status == verdict.TestFail {
+			return
+		}
+		if tc.Flaky {
+			flaky = append(flaky, tc.Name)
+		}
+	}
+	if len(flaky) == 0 {
+		return
+	}
+	r.Pass = true
+	r.MarkReview("flaky tests: " + strings.Join(flaky, ", "))
+}
+
+// rerunStatus looks up tc in a rerun. A Go subtest missing from the rerun
+// takes the status of its top-level test.
+func rerunStatus(statuses map[testKey]string, tc verdict.TestCase) string {
+	if s, ok := statuses[testKey{tc.Suite, tc.Name}]; ok {
+		return s
+	}
+	top, _, _ := strings.Cut(tc.Name, "/")
+	return statuses[testKey{tc.Suite, top}]
+}
+
+// goTestRerunner reruns failing Go tests with the original command, -run
+// narrowed to their top-level names.
+func goTestRerunner(dir string, timeoutSec int, cmd []string) testRerunner {
+	return func(ctx context.Context, failing []verdict.TestCase) (map[testKey]string, error) {
+		var names []string
+		for _, tc := range failing {
+			top, _, _ := strings.Cut(tc.Name, "/")
+			if q := regexp.QuoteMeta(top); !slices.Contains(names, q) {
+				names = append(names, q)
+			}
+		}
+		args := append(withoutFlag(cmd[1:], "-run"), "-run", "^("+strings.Join(names, "|")+")$")
+		_, output, err := runCmd(ctx, dir, timeoutSec, cmd[0], args...)
+		if err != nil {
+			return nil, err
+		}
+		run, ok := parseGoTestJSON(output)
+		if !ok {
+			return nil, fmt.Errorf("no test events in go test output")
+		}
+		statuses := map[testKey]string{}
+		for _, tc := range run.Tests {
+			statuses[testKey{tc.Suite, tc.Name}] = tc.Status
+		}
+		return statuses, nil
+	}
+}
+
+// isPytest reports whether cmd runs pytest directly or as a module.
+func isPytest(cmd []string) bool {
+	if len(cmd) > 0 && filepath.Base(cmd[0]) == "pytest" {
+		return true
+	}
+	return len(cmd) > 2 && strings.HasPrefix(filepath.Base(cmd[0]), "python") && cmd[1] == "-m" && cmd[2] == "pytest"
+}
+
+// pytestRerunner reruns failing pytest tests by node ID, dropping the paths
+// the original command selected and reading the result from a JUnit report.
+func pytestRerunner(dir string, timeoutSec int, cmd []string) testRerunner {
+	return func(ctx context.Context, failing []verdict.TestCase) (map[testKey]string, error) {
+		outDir, err := os.MkdirTemp("", "gate-retry-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(outDir)
+		report := filepath.Join(outDir, "retry.xml")
+
+		var args []string
+		for _, a := range cmd[1:] {
+			if strings.HasPrefix(a, "--junitxml") || strings.HasPrefix(a, "--junit-xml") || isPytestTarget(dir, a) {
+				continue
+			}
+			args = append(args, a)
+		}
+		args = append(args, "--junitxml="+report)
+		for _, tc := range failing {
+			args = append(args, pytestNodeID(dir, tc))
+		}
+		if _, _, err := runCmd(ctx, dir, timeoutSec, cmd[0], args...); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(report)
+		if err != nil {
+			return nil, fmt.Errorf("no JUnit report from pytest rerun")
+		}
+		tests, err := parseJUnitReport(data)
+		if err != nil {
+			return nil, err
+		}
+		statuses := map[testKey]string{}
+		for _, tc := range tests {
+			statuses[testKey{tc.Suite, tc.Name}] = tc.Status
+		}
+		return statuses, nil
+	}
+}
+
+// isPytestTarget reports whether a pytest argument selects tests: a node ID
+// or a path in the repo.
+func isPytestTarget(dir, arg string) bool {
+	if strings.HasPrefix(arg, "-") {
+		return false
+	}
+	if strings.Contains(arg, "::") {
+		return true
+	}
+	_, err := os.Stat(filepath.Join(dir, arg))
+	return err == nil
+}
+
+// pytestNodeID rebuilds a pytest node ID from a JUnit test case. The dotted
+// classname holds the module path followed by any classes; the longest
+// prefix that names a file in dir is the module. It returns "" when no
+// module file matches.
+func pytestNodeID(dir string, tc verdict.TestCase) string {
+	parts := strings.Split(tc.Suite, ".")
+	for i := len(parts); i > 0; i-- {
+		file := strings.Join(parts[:i], "/") + ".py"
+		if !fileExists(filepath.Join(dir, filepath.FromSlash(file))) {
+			continue
+		}
+		return strings.Join(append(append([]string{file}, parts[i:]...), tc.Name), "::")
+	}
+	return ""
+}
+
+// withoutFlag drops a flag and its value from args, in both the "-f v" and
+// "-f=v" forms.
+func withoutFlag(args []string, flag string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == flag || args[i] == "-"+flag:
+			i++
+		case strings.HasPrefix(args[i], flag+"=") || strings.HasPrefix(args[i], "-"+flag+"="):
+		default:
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
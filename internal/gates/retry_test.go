@@ -0,0 +1,178 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+const goFlakyFirstRun = `{"Action":"run","Package":"relay/store","Test":"TestOpen"}
+{"Action":"pass","Package":"relay/store","Test":"TestOpen","Elapsed":0.01}
+{"Action":"run","Package":"relay/store","Test":"TestRace"}
+{"Action":"output","Package":"relay/store","Test":"TestRace","Output":"    store_test.go:30: timing\n"}
+{"Action":"fail","Package":"relay/store","Test":"TestRace","Elapsed":0.02}
+{"Action":"fail","Package":"relay/store","Elapsed":0.1}
+`
+
+func goRerun(pkg, test, action string) string {
+	return `{"Action":"run","Package":"` + pkg + `","Test":"` + test + `"}
+{"Action":"` + action + `","Package":"` + pkg + `","Test":"` + test + `","Elapsed":0.02}
+{"Action":"` + action + `","Package":"` + pkg + `","Elapsed":0.1}
+`
+}
+
+func TestRunTestSuite_GoFlakyPassesWithReview(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	var calls [][]string
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		calls = append(calls, args)
+		if len(calls) == 1 {
+			return false, goFlakyFirstRun, nil
+		}
+		return true, goRerun("relay/store", "TestRace", "pass"), nil
+	})
+
+	r := RunTestSuite(context.Background(), dir, 30, TestOptions{Retries: 2})
+	if len(calls) != 2 {
+		t.Fatalf("expected one retry, got %d runs", len(calls))
+	}
+	if want := []string{"test", "-json", "./...", "-run", "^(TestRace)$"}; !slices.Equal(calls[1], want) {
+		t.Fatalf("retry args = %v, want %v", calls[1], want)
+	}
+	if !r.Pass || !r.Review || r.ReviewReason != "flaky tests: TestRace" {
+		t.Fatalf("expected pass in review, got pass=%v review=%v reason=%q", r.Pass, r.Review, r.ReviewReason)
+	}
+	if len(r.Tests) != 1 {
+		t.Fatalf("expected only the flaky test kept, got %+v", r.Tests)
+	}
+	tc := r.Tests[0]
+	if !tc.Flaky || tc.Status != verdict.TestPass || tc.Attempts != 2 {
+		t.Fatalf("expected flaky test passing on attempt 2, got %+v", tc)
+	}
+	if !strings.Contains(r.Output, "flaky: relay/store TestRace (passed on attempt 2)") {
+		t.Fatalf("expected flaky note in output, got: %s", r.Output)
+	}
+}
+
+func TestRunTestSuite_GoStillFailingAfterRetries(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	runs := 0
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		runs++
+		if runs == 1 {
+			return false, goFlakyFirstRun, nil
+		}
+		return false, goRerun("relay/store", "TestRace", "fail"), nil
+	})
+
+	r := RunTestSuite(context.Background(), dir, 30, TestOptions{Retries: 2})
+	if runs != 3 {
+		t.Fatalf("expected two retries, got %d runs", runs)
+	}
+	if r.Pass || r.Review || r.Tests[0].Flaky || r.Tests[0].Attempts != 3 {
+		t.Fatalf("expected a plain failure, got %+v", r)
+	}
+	if !strings.Contains(r.Output, "still failing after 3 attempts: relay/store TestRace") {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
+}
+
+func TestRunTestSuite_GoBrokenPackageBlocksFlakyPass(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	runs := 0
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		runs++
+		if runs == 1 {
+			return false, goFlakyFirstRun + `{"Action":"output","Package":"relay/api","Output":"FAIL\trelay/api [build failed]\n"}
+{"Action":"fail","Package":"relay/api","Elapsed":0}
+`, nil
+		}
+		return true, goRerun("relay/store", "TestRace", "pass"), nil
+	})
+
+	r := RunTestSuite(context.Background(), dir, 30, TestOptions{Retries: 2})
+	if r.Pass || r.Review {
+		t.Fatalf("expected the build failure to keep the gate failing, got pass=%v review=%v", r.Pass, r.Review)
+	}
+	if len(r.Tests) != 1 || !r.Tests[0].Flaky {
+		t.Fatalf("expected TestRace still marked flaky, got %+v", r.Tests)
+	}
+}
+
+func TestRunTestSuite_NoRetriesByDefault(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module test"), 0644)
+
+	runs := 0
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		runs++
+		return false, goFlakyFirstRun, nil
+	})
+
+	if r := RunTests(context.Background(), dir, 30); r.Pass || runs != 1 {
+		t.Fatalf("expected a single failing run, got pass=%v runs=%d", r.Pass, runs)
+	}
+}
+
+func TestRunTestSuite_PytestFlakyByNodeID(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "pyproject.toml"), []byte(""), 0644)
+	os.MkdirAll(filepath.Join(dir, "tests"), 0755)
+	os.WriteFile(filepath.Join(dir, "tests", "test_store.py"), []byte(""), 0644)
+
+	var rerunArgs []string
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		report := strings.TrimPrefix(args[0], "--junitxml=")
+		if len(args) == 1 {
+			os.WriteFile(report, []byte(pytestJUnitFixture), 0644)
+			return false, "", nil
+		}
+		rerunArgs = args
+		os.WriteFile(report, []byte(`<testsuite name="pytest"><testcase classname="tests.test_store" name="test_close"/></testsuite>`), 0644)
+		return true, "", nil
+	})
+
+	r := RunTestSuite(context.Background(), dir, 30, TestOptions{Retries: 1})
+	if len(rerunArgs) != 2 || rerunArgs[1] != "tests/test_store.py::test_close" {
+		t.Fatalf("expected rerun by node ID, got %v", rerunArgs)
+	}
+	if !r.Pass || !r.Review || !r.Tests[1].Flaky {
+		t.Fatalf("expected flaky pass in review, got %+v", r)
+	}
+}
+
+func TestPytestNodeID(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "tests"), 0755)
+	os.WriteFile(filepath.Join(dir, "tests", "test_api.py"), []byte(""), 0644)
+
+	for _, tc := range []struct {
+		suite, name, want string
+	}{
+		{"tests.test_api", "test_get", "tests/test_api.py::test_get"},
+		{"tests.test_api.TestClient", "test_post[json]", "tests/test_api.py::TestClient::test_post[json]"},
+		{"tests.test_missing", "test_x", ""},
+	} {
+		if got := pytestNodeID(dir, verdict.TestCase{Suite: tc.suite, Name: tc.name}); got != tc.want {
+			t.Errorf("pytestNodeID(%s, %s) = %q, want %q", tc.suite, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWithoutFlag(t *testing.T) {
+	got := withoutFlag([]string{"-json", "-run", "TestA", "./...", "--run=TestB", "-race"}, "-run")
+	if want := []string{"-json", "./...", "-race"}; !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
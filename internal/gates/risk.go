@@ -0,0 +1,250 @@
+package gates
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"polis/gate/internal/verdict"
+)
+
+// File categories used by the risk gate.
+const (
+	CategoryCode   = "code"
+	CategoryTest   = "test"
+	CategoryConfig = "config"
+	CategoryInfra  = "infra"
+	CategoryDocs   = "docs"
+)
+
+// DefaultRiskFailAt is the score at or above which the risk gate fails.
+const DefaultRiskFailAt = 75
+
+// DefaultRiskReviewAt is the score at or above which a passing risk gate
+// needs human review.
+const DefaultRiskReviewAt = 40
+
+// RiskOptions controls the risk gate.
+type RiskOptions struct {
+	// Base is the ref to diff against; empty tries main, then master.
+	Base string
+	// FailAt is the score (0-100) at or above which the gate fails.
+	FailAt int
+	// ReviewAt is the score at or above which a passing gate needs review.
+	// Zero uses the default; a value at or above FailAt disables review.
+	ReviewAt int
+	// Sensitive lists extra path prefixes treated as sensitive.
+	Sensitive []string
+}
+
+var sensitiveTokens = map[string]string{
+	"auth": "auth", "authn": "auth", "authz": "auth", "authentication": "auth",
+	"authorization": "auth", "oauth": "auth", "login": "auth", "session": "auth",
+	"sessions": "auth", "password": "auth", "passwords": "auth", "jwt": "auth",
+	"rbac": "auth", "acl": "auth", "permissions": "auth",
+	"crypto": "crypto", "cryptography": "crypto", "cipher": "crypto",
+	"encrypt": "crypto", "encryption": "crypto", "decrypt": "crypto", "tls": "crypto",
+	"ssl": "crypto", "hmac": "crypto", "secret": "crypto", "secrets": "crypto",
+	"signing": "crypto", "kms": "crypto",
+	"migration": "migrations", "migrations": "migrations", "migrate": "migrations",
+}
+
+var ciPaths = []string{".github/", ".gitlab-ci.yml", ".circleci/", ".buildkite/", ".travis.yml", "Jenkinsfile", "azure-pipelines.yml"}
+
+// RunRisk scores the change in the repo at dir against opts.Base.
+// Outside a git repo, or without a resolvable base, the gate is skipped.
+func RunRisk(ctx context.Context, dir string, timeoutSec int, opts RiskOptions) verdict.GateResult {
+	if timeoutSec <= 0 {
+		timeoutSec = 60
+	}
+	start := time.Now()
+	cs, err := DiffChangeSet(ctx, dir, opts.Base, timeoutSec)
+	if err != nil {
+		return verdict.GateResult{
+			Name:       "risk",
+			Pass:       true,
+			Skipped:    true,
+			Output:     fmt.Sprintf("risk skipped: %v", err),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+	}
+	r := ScoreRisk(cs, opts)
+	r.DurationMs = time.Since(start).Milliseconds()
+	return r
+}
+
+// ScoreRisk classifies the files in cs and computes a 0-100 risk score from
+// change size, spread, sensitive paths, infra/config churn, and code changes
+// that arrive without test changes.
+func ScoreRisk(cs ChangeSet, opts RiskOptions) verdict.GateResult {
+	failAt := opts.FailAt
+	if failAt <= 0 {
+		failAt = DefaultRiskFailAt
+	}
+	reviewAt := opts.ReviewAt
+	if reviewAt <= 0 {
+		reviewAt = DefaultRiskReviewAt
+	}
+
+	added, deleted := cs.Lines()
+	report := verdict.RiskReport{
+		Base:         cs.Base,
+		Files:        len(cs.Files),
+		LinesAdded:   added,
+		LinesDeleted: deleted,
+		Categories:   map[string]int{},
+	}
+	for _, f := range cs.Files {
+		report.Categories[ClassifyFile(f.Path)]++
+		if reason := sensitiveReason(f.Path, opts.Sensitive); reason != "" {
+			report.Sensitive = append(report.Sensitive, verdict.SensitivePath{Path: f.Path, Reason: reason})
+		}
+	}
+
+	addFactor := func(name string, points int, detail string) {
+		if points > 0 {
+			report.Factors = append(report.Factors, verdict.RiskFactor{Name: name, Points: points, Detail: detail})
+			report.Score += points
+		}
+	}
+	lines := added + deleted
+	addFactor("size", min(30, lines/20), fmt.Sprintf("%d lines changed", lines))
+	addFactor("spread", min(15, len(cs.Files)), fmt.Sprintf("%d files changed", len(cs.Files)))
+	addFactor("sensitive", min(40, 15*len(report.Sensitive)), fmt.Sprintf("%d sensitive paths", len(report.Sensitive)))
+	if n := report.Categories[CategoryInfra]; n > 0 {
+		addFactor("infra", 10, fmt.Sprintf("%d infra files", n))
+	}
+	if n := report.Categories[CategoryConfig]; n > 0 {
+		addFactor("config", 5, fmt.Sprintf("%d config files", n))
+	}
+	if report.Categories[CategoryCode] > 0 && report.Categories[CategoryTest] == 0 {
+		addFactor("untested", 10, "code changed without test changes")
+	}
+	report.Score = min(100, report.Score)
+
+	findings := verdict.Findings{Warnings: len(report.Sensitive), Info: len(cs.Files)}
+	pass := report.Score < failAt
+	if !pass {
+		findings.Errors = 1
+	}
+
+	output := fmt.Sprintf("score=%d files=%d +%d/-%d sensitive=%d", report.Score, report.Files, added, deleted, len(report.Sensitive))
+	if len(cs.Files) == 0 {
+		output = "no changes against merge base"
+	}
+	if !pass {
+		output = fmt.Sprintf("%s (fail_at=%d)\n%s", output, failAt, describeRisk(report))
+	}
+
+	r := verdict.GateResult{
+		Name:     "risk",
+		Pass:     pass,
+		Output:   output,
+		Findings: &findings,
+		Risk:     &report,
+	}
+	if pass && report.Score >= reviewAt {
+		r.Output = fmt.Sprintf("%s (review_at=%d)\n%s", output, reviewAt, describeRisk(report))
+		r.MarkReview(fmt.Sprintf("risk score %d in review band [%d, %d)", report.Score, reviewAt, failAt))
+	}
+	return r
+}
+
+// ClassifyFile places a repo-relative path in one of the risk categories.
+func ClassifyFile(p string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	base := path.Base(p)
+	lower := strings.ToLower(p)
+	lowerBase := strings.ToLower(base)
+	segs := strings.Split(lower, "/")
+
+	if isCIPath(p) || strings.HasPrefix(lowerBase, "dockerfile") || strings.HasPrefix(lowerBase, "docker-compose") ||
+		lowerBase == "makefile" || strings.HasSuffix(lowerBase, ".tf") || strings.HasSuffix(lowerBase, ".tfvars") ||
+		hasSegment(segs[:len(segs)-1], "deploy", "k8s", "helm", "terraform", "infra", "ansible") {
+		return CategoryInfra
+	}
+
+	if strings.HasSuffix(lowerBase, "_test.go") || strings.HasSuffix(lowerBase, ".bats") ||
+		(strings.HasSuffix(lowerBase, ".py") && (strings.HasPrefix(lowerBase, "test_") || strings.HasSuffix(lowerBase, "_test.py"))) ||
+		strings.Contains(lowerBase, ".test.") || strings.Contains(lowerBase, ".spec.") ||
+		hasSegment(segs[:len(segs)-1], "test", "tests", "__tests__", "testdata", "spec") {
+		return CategoryTest
+	}
+
+	switch path.Ext(lowerBase) {
+	case ".md", ".rst", ".adoc", ".txt":
+		return CategoryDocs
+	}
+	if lowerBase == "license" || strings.HasPrefix(lowerBase, "changelog") || hasSegment(segs[:len(segs)-1], "docs", "doc") {
+		return CategoryDocs
+	}
+
+	switch path.Ext(lowerBase) {
+	case ".toml", ".yaml", ".yml", ".json", ".ini", ".cfg", ".conf", ".lock", ".sum", ".mod", ".properties":
+		return CategoryConfig
+	}
+	if strings.HasPrefix(lowerBase, ".env") || (strings.HasPrefix(lowerBase, ".") && !strings.Contains(lowerBase[1:], ".")) {
+		return CategoryConfig
+	}
+
+	return CategoryCode
+}
+
+// sensitiveReason returns why p is sensitive, or "" if it is not.
+func sensitiveReason(p string, extra []string) string {
+	p = strings.ReplaceAll(p, "\\", "/")
+	if isCIPath(p) {
+		return "ci"
+	}
+	for _, prefix := range extra {
+		if prefix != "" && strings.HasPrefix(p, prefix) {
+			return "configured"
+		}
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(p), func(r rune) bool {
+		return r == '/' || r == '.' || r == '_' || r == '-'
+	})
+	for _, tok := range tokens {
+		if reason, ok := sensitiveTokens[tok]; ok {
+			return reason
+		}
+	}
+	return ""
+}
+
+func isCIPath(p string) bool {
+	for _, ci := range ciPaths {
+		if strings.HasSuffix(ci, "/") {
+			if strings.HasPrefix(p, ci) {
+				return true
+			}
+		} else if p == ci {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSegment(segs []string, names ...string) bool {
+	for _, s := range segs {
+		for _, n := range names {
+			if s == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describeRisk(r verdict.RiskReport) string {
+	var lines []string
+	for _, f := range r.Factors {
+		lines = append(lines, fmt.Sprintf("+%d %s: %s", f.Points, f.Name, f.Detail))
+	}
+	for _, s := range r.Sensitive {
+		lines = append(lines, fmt.Sprintf("sensitive (%s): %s", s.Reason, s.Path))
+	}
+	return strings.Join(lines, "\n")
+}
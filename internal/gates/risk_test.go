@@ -0,0 +1,249 @@
+package gates
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", CategoryCode},
+		{"internal/gates/risk.go", CategoryCode},
+		{"internal/gates/risk_test.go", CategoryTest},
+		{"tests/test_app.py", CategoryTest},
+		{"test_app.py", CategoryTest},
+		{"web/src/app.spec.ts", CategoryTest},
+		{"web/src/app.test.js", CategoryTest},
+		{"smoke.bats", CategoryTest},
+		{"internal/gates/testdata/out.json", CategoryTest},
+		{"README.md", CategoryDocs},
+		{"docs/guide.html", CategoryDocs},
+		{"LICENSE", CategoryDocs},
+		{"go.mod", CategoryConfig},
+		{"go.sum", CategoryConfig},
+		{"gate.toml", CategoryConfig},
+		{"config/app.yaml", CategoryConfig},
+		{".gitignore", CategoryConfig},
+		{".env.production", CategoryConfig},
+		{"Dockerfile", CategoryInfra},
+		{"docker-compose.yml", CategoryInfra},
+		{"Makefile", CategoryInfra},
+		{".github/workflows/ci.yml", CategoryInfra},
+		{"deploy/k8s/service.yaml", CategoryInfra},
+		{"main.tf", CategoryInfra},
+	}
+	for _, tt := range tests {
+		if got := ClassifyFile(tt.path); got != tt.want {
+			t.Errorf("ClassifyFile(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSensitiveReason(t *testing.T) {
+	tests := []struct {
+		path  string
+		extra []string
+		want  string
+	}{
+		{"internal/auth/token.go", nil, "auth"},
+		{"pkg/oauth_client.go", nil, "auth"},
+		{"internal/crypto/seal.go", nil, "crypto"},
+		{"db/migrations/0001_init.sql", nil, "migrations"},
+		{".github/workflows/ci.yml", nil, "ci"},
+		{".gitlab-ci.yml", nil, "ci"},
+		{"billing/charge.go", []string{"billing/"}, "configured"},
+		{"internal/author/name.go", nil, ""},
+		{"main.go", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := sensitiveReason(tt.path, tt.extra); got != tt.want {
+			t.Errorf("sensitiveReason(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseNumstat(t *testing.T) {
+	out := "3\t1\tmain.go\x0010\t0\tdocs/a b.md\x00-\t-\tlogo.png\x00"
+	files := parseNumstat(out)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %+v", files)
+	}
+	if files[0].Path != "main.go" || files[0].Added != 3 || files[0].Deleted != 1 {
+		t.Errorf("unexpected first file: %+v", files[0])
+	}
+	if files[1].Path != "docs/a b.md" {
+		t.Errorf("expected path with space, got %q", files[1].Path)
+	}
+	if !files[2].Binary {
+		t.Errorf("expected binary file: %+v", files[2])
+	}
+}
+
+func TestScoreRisk_SmallTestedChangePasses(t *testing.T) {
+	r := ScoreRisk(ChangeSet{Base: "abc", Files: []FileChange{
+		{Path: "main.go", Added: 10, Deleted: 2},
+		{Path: "main_test.go", Added: 20},
+	}}, RiskOptions{})
+
+	if !r.Pass {
+		t.Fatalf("expected pass, got %s", r.Output)
+	}
+	if r.Risk == nil {
+		t.Fatal("expected risk breakdown")
+	}
+	if r.Risk.Categories[CategoryCode] != 1 || r.Risk.Categories[CategoryTest] != 1 {
+		t.Errorf("unexpected categories: %v", r.Risk.Categories)
+	}
+	if r.Risk.Score >= DefaultRiskFailAt {
+		t.Errorf("expected low score, got %d", r.Risk.Score)
+	}
+	if r.Findings == nil || r.Findings.Info != 2 {
+		t.Errorf("expected 2 info findings, got %+v", r.Findings)
+	}
+}
+
+func TestScoreRisk_LargeSensitiveChangeFails(t *testing.T) {
+	r := ScoreRisk(ChangeSet{Files: []FileChange{
+		{Path: "internal/auth/login.go", Added: 400, Deleted: 200},
+		{Path: "internal/crypto/keys.go", Added: 300},
+		{Path: "db/migrations/0002.sql", Added: 50},
+		{Path: ".github/workflows/ci.yml", Added: 5},
+		{Path: "go.mod", Added: 1},
+	}}, RiskOptions{})
+
+	if r.Pass {
+		t.Fatalf("expected fail, got score %d", r.Risk.Score)
+	}
+	if len(r.Risk.Sensitive) != 4 {
+		t.Errorf("expected 4 sensitive paths, got %+v", r.Risk.Sensitive)
+	}
+	if r.Findings.Errors != 1 || r.Findings.Warnings != 4 {
+		t.Errorf("unexpected findings: %+v", r.Findings)
+	}
+	if !strings.Contains(r.Output, "sensitive (auth): internal/auth/login.go") {
+		t.Errorf("expected sensitive detail in output, got: %s", r.Output)
+	}
+	if !strings.Contains(r.Output, "untested") {
+		t.Errorf("expected untested factor in output, got: %s", r.Output)
+	}
+}
+
+func TestScoreRisk_CustomFailAt(t *testing.T) {
+	cs := ChangeSet{Files: []FileChange{{Path: "main.go", Added: 5}}}
+	if r := ScoreRisk(cs, RiskOptions{FailAt: 5}); r.Pass {
+		t.Fatalf("expected fail with fail_at=5, score %d", r.Risk.Score)
+	}
+}
+
+func TestScoreRisk_NoChanges(t *testing.T) {
+	r := ScoreRisk(ChangeSet{Base: "abc"}, RiskOptions{})
+	if !r.Pass || r.Risk.Score != 0 {
+		t.Fatalf("expected clean pass, got %+v", r)
+	}
+	if r.Output != "no changes against merge base" {
+		t.Errorf("unexpected output: %q", r.Output)
+	}
+}
+
+func TestRunRisk_NotGitRepoSkips(t *testing.T) {
+	r := RunRisk(context.Background(), t.TempDir(), 10, RiskOptions{})
+	if !r.Pass || !r.Skipped {
+		t.Fatalf("expected skipped pass outside git, got %+v", r)
+	}
+}
+
+func TestRunRisk_DiffsAgainstBase(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"main.go": "package main\n"})
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	os.MkdirAll(filepath.Join(dir, "internal", "auth"), 0o755)
+	os.WriteFile(filepath.Join(dir, "internal", "auth", "auth.go"), []byte("package auth\n\nfunc A() {}\n"), 0o644)
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "add auth")
+	// Uncommitted edits are part of the change too.
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644)
+
+	r := RunRisk(context.Background(), dir, 10, RiskOptions{Base: "main"})
+	if r.Skipped {
+		t.Fatalf("expected risk to run, got %s", r.Output)
+	}
+	if r.Risk.Files != 2 {
+		t.Fatalf("expected 2 changed files, got %+v", r.Risk)
+	}
+	if len(r.Risk.Sensitive) != 1 || r.Risk.Sensitive[0].Path != "internal/auth/auth.go" {
+		t.Fatalf("expected auth path flagged, got %+v", r.Risk.Sensitive)
+	}
+}
+
+func TestRunRisk_CountsUntrackedFiles(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"main.go": "package main\n", ".gitignore": "build/\n"})
+	os.MkdirAll(filepath.Join(dir, "internal", "auth"), 0o755)
+	os.WriteFile(filepath.Join(dir, "internal", "auth", "auth.go"), []byte("package auth\n\nfunc A() {}"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "build"), 0o755)
+	os.WriteFile(filepath.Join(dir, "build", "out.go"), []byte("package build\n"), 0o644)
+
+	r := RunRisk(context.Background(), dir, 10, RiskOptions{Base: "main"})
+	if r.Skipped {
+		t.Fatalf("expected risk to run, got %s", r.Output)
+	}
+	if r.Risk.Files != 1 || r.Risk.LinesAdded != 3 {
+		t.Fatalf("expected the untracked file as 3 added lines, got %+v", r.Risk)
+	}
+	if len(r.Risk.Sensitive) != 1 || r.Risk.Sensitive[0].Path != "internal/auth/auth.go" {
+		t.Fatalf("expected untracked auth path flagged, got %+v", r.Risk.Sensitive)
+	}
+}
+
+// gitRepo initialises a repo on branch main with files committed.
+func gitRepo(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	gitRun(t, dir, "config", "user.email", "gate-tests@example.com")
+	gitRun(t, dir, "config", "user.name", "gate-tests")
+	for name, content := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(target), 0o755)
+		if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "--allow-empty", "-m", "init")
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v (%s)", args, err, string(out))
+	}
+	return string(out)
+}
+
+func TestScoreRisk_ReviewBand(t *testing.T) {
+	cs := ChangeSet{Files: []FileChange{
+		{Path: "internal/auth/token.go", Added: 300},
+		{Path: "go.mod", Added: 1},
+	}}
+	r := ScoreRisk(cs, RiskOptions{})
+	if !r.Pass || !r.Review {
+		t.Fatalf("expected pass with review, got score %d pass=%v review=%v", r.Risk.Score, r.Pass, r.Review)
+	}
+	if !strings.Contains(r.ReviewReason, "review band [40, 75)") {
+		t.Fatalf("unexpected review reason: %q", r.ReviewReason)
+	}
+
+	if r := ScoreRisk(cs, RiskOptions{ReviewAt: 100}); r.Review {
+		t.Fatalf("review_at above fail_at should disable review, got %q", r.ReviewReason)
+	}
+}
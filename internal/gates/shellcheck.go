@@ -0,0 +1,86 @@
+package gates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+// shellcheckBatchSize caps the scripts per shellcheck run, keeping the
+// command line well under the OS argument limit.
+const shellcheckBatchSize = 100
+
+// shellcheckComment is one finding from `shellcheck -f json`.
+type shellcheckComment struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Level   string `json:"level"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// shellcheckSpec lints the given repo-relative scripts with shellcheck.
+func shellcheckSpec(scripts []string) linterSpec {
+	return linterSpec{
+		name:  "shellcheck",
+		cmd:   []string{"shellcheck", "-f", "json"},
+		files: scripts,
+		batch: shellcheckBatchSize,
+		parse: parseShellcheckJSON,
+	}
+}
+
+// parseShellcheckJSON reads `shellcheck -f json` output into issues and a
+// report in formatLintIssue form. Lines around the JSON
+// array, such as errors for unreadable files, are kept in the report.
+func parseShellcheckJSON(_, output string) ([]verdict.Issue, string, error) {
+	var notes []string
+	var comments []shellcheckComment
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !found && strings.HasPrefix(trimmed, "[") {
+			if err := json.Unmarshal([]byte(trimmed), &comments); err != nil {
+				return nil, "", fmt.Errorf("invalid shellcheck JSON: %v", err)
+			}
+			found = true
+			continue
+		}
+		if trimmed != "" {
+			notes = append(notes, trimmed)
+		}
+	}
+	if !found && len(notes) > 0 {
+		return nil, "", fmt.Errorf("no shellcheck JSON in output")
+	}
+
+	var issues []verdict.Issue
+	var lines []string
+	for _, c := range comments {
+		is := verdict.Issue{
+			Severity: shellcheckSeverity(c.Level),
+			File:     cleanRepoPath(c.File),
+			Line:     c.Line,
+			Column:   c.Column,
+			Rule:     fmt.Sprintf("SC%d", c.Code),
+			Scanner:  "shellcheck",
+			Message:  c.Message,
+		}
+		issues = append(issues, is)
+		lines = append(lines, formatLintIssue(is))
+	}
+	return issues, strings.Join(append(lines, notes...), "\n"), nil
+}
+
+// shellcheckSeverity maps shellcheck levels onto issue severities; style
+// suggestions count as info.
+func shellcheckSeverity(level string) string {
+	switch level {
+	case "error", "warning":
+		return level
+	}
+	return "info"
+}
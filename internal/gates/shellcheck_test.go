@@ -0,0 +1,105 @@
+package gates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectLinters_ShellScriptsAnywhere(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{
+		".gitignore":              "build/\n",
+		"scripts/deploy.sh":       "echo deploy\n",
+		"scripts/ci/lib.bash":     "f() { :; }\n",
+		"bin/release":             "#!/usr/bin/env bash\necho release\n",
+		"bin/hook":                "#!/bin/sh -e\nexit 0\n",
+		"bin/tool":                "#!/usr/bin/env python3\nprint(1)\n",
+		"README":                  "plain text\n",
+		"web/node_modules/x/a.sh": "echo dep\n",
+	})
+	os.MkdirAll(filepath.Join(dir, "build"), 0o755)
+	os.WriteFile(filepath.Join(dir, "build", "gen.sh"), []byte("echo gen\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "new.sh"), []byte("echo untracked\n"), 0o644)
+
+	var spec *linterSpec
+	for _, l := range DetectLinters(context.Background(), dir) {
+		if l.name == "shellcheck" {
+			spec = &l
+		}
+	}
+	if spec == nil {
+		t.Fatal("expected shellcheck detection")
+	}
+	got := slices.Sorted(slices.Values(spec.files))
+	want := []string{"bin/hook", "bin/release", "new.sh", "scripts/ci/lib.bash", "scripts/deploy.sh"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("scripts = %v, want %v", got, want)
+	}
+}
+
+func TestRunLinters_ShellcheckBatches(t *testing.T) {
+	var scripts []string
+	for i := range shellcheckBatchSize + 5 {
+		scripts = append(scripts, fmt.Sprintf("s%03d.sh", i))
+	}
+
+	var batches [][]string
+	mockRunCmd(t, func(ctx context.Context, dir string, timeoutSec int, name string, args ...string) (bool, string, error) {
+		if name != "shellcheck" || strings.Join(args[:2], " ") != "-f json" {
+			t.Fatalf("unexpected command %s %v", name, args)
+		}
+		batches = append(batches, args[2:])
+		if args[2] == "s000.sh" {
+			return true, "[]", nil
+		}
+		return false, `[{"file":"./s101.sh","line":3,"endLine":3,"column":6,"endColumn":8,"level":"warning","code":2086,"message":"Double quote to prevent globbing and word splitting.","fix":null},` +
+			`{"file":"s104.sh","line":1,"column":1,"level":"style","code":2148,"message":"Tips depend on target shell."}]`, nil
+	})
+
+	results := RunLinters(context.Background(), t.TempDir(), 30, []linterSpec{shellcheckSpec(scripts)})
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	r := results[0]
+	if len(batches) != 2 || len(batches[0]) != shellcheckBatchSize || len(batches[1]) != 5 {
+		t.Fatalf("expected batches of %d and 5, got %d batches", shellcheckBatchSize, len(batches))
+	}
+	if r.Name != "lint:shellcheck" || r.Pass {
+		t.Fatalf("expected failing lint:shellcheck, got %+v", r)
+	}
+	if len(r.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", r.Issues)
+	}
+	is := r.Issues[0]
+	if is.File != "s101.sh" || is.Line != 3 || is.Column != 6 || is.Rule != "SC2086" || is.Severity != "warning" || is.Scanner != "shellcheck" {
+		t.Fatalf("unexpected issue %+v", is)
+	}
+	if r.Issues[1].Severity != "info" {
+		t.Fatalf("style finding should be info, got %q", r.Issues[1].Severity)
+	}
+	if !strings.Contains(r.Output, "s101.sh:3:6: warning: Double quote to prevent globbing and word splitting. [SC2086]") {
+		t.Fatalf("unexpected output:\n%s", r.Output)
+	}
+}
+
+func TestParseShellcheckJSON_KeepsErrors(t *testing.T) {
+	output := "gone.sh: does not exist (No such file or directory)\n[]\n"
+	issues, report, err := parseShellcheckJSON("", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 || report != "gone.sh: does not exist (No such file or directory)" {
+		t.Fatalf("issues = %v, report = %q", issues, report)
+	}
+
+	// Output without JSON falls back to the raw text.
+	if _, _, err := parseShellcheckJSON("", "shellcheck: unrecognized option\n"); err == nil {
+		t.Fatal("expected an error for output without JSON")
+	}
+}
@@ -0,0 +1,90 @@
+package gates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+var (
+	tapPlanRe = regexp.MustCompile(`^1\.\.(\d+)`)
+	tapTestRe = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:-\s*)?(.*)$`)
+)
+
+// parseTAP reads Test Anything Protocol output into test cases. Only
+// top-level test lines count; indented subtests, YAML blocks, and "#"
+// diagnostics after a test become its output. A "# SKIP" directive skips
+// a test, and "# TODO" keeps a known failure from failing. A bail out, a
+// plan that does not match the tests run, or output without any TAP is
+// returned as an error alongside the tests parsed so far.
+func parseTAP(output string) ([]verdict.TestCase, error) {
+	var (
+		tests   []verdict.TestCase
+		details [][]string
+		planned = -1
+	)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "Bail out!") {
+			return tapFinish(tests, details), fmt.Errorf("bail out: %s", strings.TrimSpace(strings.TrimPrefix(line, "Bail out!")))
+		}
+		if m := tapPlanRe.FindStringSubmatch(line); m != nil {
+			planned, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if m := tapTestRe.FindStringSubmatch(line); m != nil {
+			tests = append(tests, tapTestCase(len(tests)+1, m[1] != "", m[2], m[3]))
+			details = append(details, nil)
+			continue
+		}
+		if len(tests) > 0 && strings.TrimSpace(line) != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "#")) {
+			details[len(details)-1] = append(details[len(details)-1], line)
+		}
+	}
+	tests = tapFinish(tests, details)
+
+	switch {
+	case planned < 0 && len(tests) == 0:
+		return nil, fmt.Errorf("no TAP output")
+	case planned >= 0 && planned != len(tests):
+		return tests, fmt.Errorf("planned %d tests, ran %d", planned, len(tests))
+	}
+	return tests, nil
+}
+
+// tapTestCase builds a test from an "ok"/"not ok" line.
+func tapTestCase(seq int, failed bool, num, desc string) verdict.TestCase {
+	desc, directive, _ := strings.Cut(desc, "#")
+	name := strings.TrimSpace(desc)
+	if name == "" {
+		if num == "" {
+			num = strconv.Itoa(seq)
+		}
+		name = "test " + num
+	}
+	tc := verdict.TestCase{Name: name, Status: verdict.TestPass}
+	if failed {
+		tc.Status = verdict.TestFail
+	}
+	directive = strings.ToUpper(strings.TrimSpace(directive))
+	switch {
+	case strings.HasPrefix(directive, "SKIP"):
+		tc.Status = verdict.TestSkip
+	case strings.HasPrefix(directive, "TODO") && failed:
+		tc.Status = verdict.TestSkip
+	}
+	return tc
+}
+
+// tapFinish attaches the collected detail lines to failing tests.
+func tapFinish(tests []verdict.TestCase, details [][]string) []verdict.TestCase {
+	for i := range tests {
+		if tests[i].Status == verdict.TestFail && len(details[i]) > 0 {
+			tests[i].Output = tailLines(details[i], maxTestOutputLines)
+		}
+	}
+	return tests
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 	"polis/gate/internal/verdict"
 )
@@ -13,7 +14,7 @@ import (
 func DetectTestSuite(dir string) []string {
 	// Go
 	if fileExists(filepath.Join(dir, "go.mod")) {
-		return []string{"go", "test", "./..."}
+		return []string{"go", "test", "-json", "./..."}
 	}
 	// Node
 	if fileExists(filepath.Join(dir, "package.json")) {
@@ -35,19 +36,101 @@ func DetectTestSuite(dir string) []string {
 	return nil
 }
 
+// TestOptions configures the tests gate.
+type TestOptions struct {
+	// Command runs the suite; empty auto-detects it.
+	Command []string
+	// JUnit lists repo-relative globs of JUnit XML reports the runner
+	// writes. Empty checks the common default locations.
+	JUnit []string
+	// Retries reruns failing Go and pytest tests up to this many times;
+	// tests that then pass are reported as flaky. Zero disables retries.
+	Retries int
+}
+
 // RunTests detects and runs the test suite for the repo at dir.
 func RunTests(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
+	return RunTestSuite(ctx, dir, timeoutSec, TestOptions{})
+}
+
+// RunTestCommand runs cmd as the test suite for the repo at dir.
+// A nil cmd means no test suite was detected and the gate passes.
+func RunTestCommand(ctx context.Context, dir string, timeoutSec int, cmd []string) verdict.GateResult {
+	if len(cmd) == 0 {
+		return noTestSuite()
+	}
+	return RunTestSuite(ctx, dir, timeoutSec, TestOptions{Command: cmd})
+}
+
+// RunTestSuite runs the test suite for the repo at dir and reports each
+// test when the runner says how they went: `go test -json` events, or JUnit
+// XML reports. A detected pytest or bats suite is asked to write a report;
+// other runners' reports are found on disk. The output then summarizes the
+// failing tests instead of carrying the whole log.
+func RunTestSuite(ctx context.Context, dir string, timeoutSec int, opts TestOptions) verdict.GateResult {
+	if len(opts.Command) > 0 {
+		return runTestSuite(ctx, dir, timeoutSec, opts.Command, false, opts)
+	}
 	cmd := DetectTestSuite(dir)
-	if cmd == nil {
-		return verdict.GateResult{Name: "tests", Pass: true, Output: "no test suite detected"}
+	if len(cmd) == 0 {
+		return noTestSuite()
 	}
+	return runTestSuite(ctx, dir, timeoutSec, cmd, true, opts)
+}
+
+// RunTestProject runs one detected project of the repo at dir in its own
+// directory. The result is named after the project, e.g. "tests:go:./svc".
+// opts.Command is ignored; JUnit globs are relative to the project.
+func RunTestProject(ctx context.Context, dir string, timeoutSec int, p TestProject, opts TestOptions) verdict.GateResult {
+	r := runTestSuite(ctx, filepath.Join(dir, filepath.FromSlash(p.Dir)), timeoutSec, p.Command, true, opts)
+	r.Name = p.Label()
+	return r
+}
+
+// runTestSuite runs cmd in dir. A detected cmd may be given flags that
+// make the runner write a JUnit report.
+func runTestSuite(ctx context.Context, dir string, timeoutSec int, cmd []string, detected bool, opts TestOptions) verdict.GateResult {
 	if timeoutSec <= 0 {
 		timeoutSec = 120
 	}
-	return verdict.TimedRun("tests", func() (bool, string, error) {
-		pass, output, err := runCmd(ctx, dir, timeoutSec, cmd[0], cmd[1:]...)
-		return pass, output, err
-	})
+
+	var reportDir string
+	if detected && !isGoTestJSON(cmd) {
+		if d, err := os.MkdirTemp("", "gate-junit-"); err == nil {
+			defer os.RemoveAll(d)
+			if req, ok := requestJUnit(cmd, d); ok {
+				cmd, reportDir = req, d
+			}
+		}
+	}
+
+	start := time.Now()
+	if isGoTest(cmd) {
+		defer lockGoTest(dir)()
+	}
+	pass, output, err := runCmd(ctx, dir, timeoutSec, cmd[0], cmd[1:]...)
+	if err != nil {
+		return verdict.GateResult{Name: "tests", Pass: false, Output: err.Error(), DurationMs: time.Since(start).Milliseconds()}
+	}
+	r := verdict.GateResult{Name: "tests", Pass: pass, Output: output}
+	if isGoTestJSON(cmd) {
+		if run, ok := parseGoTestJSON(output); ok {
+			r = goTestResult(run, pass)
+		}
+	} else if files := findJUnitReports(dir, reportDir, opts.JUnit, start); len(files) > 0 {
+		if jr, ok := junitResult(filepath.Base(cmd[0]), files, pass, output); ok {
+			r = jr
+		}
+	}
+	if opts.Retries > 0 {
+		retryFailedTests(ctx, dir, timeoutSec, cmd, &r, opts.Retries)
+	}
+	r.DurationMs = time.Since(start).Milliseconds()
+	return r
+}
+
+func noTestSuite() verdict.GateResult {
+	return verdict.GateResult{Name: "tests", Pass: true, Output: "no test suite detected"}
 }
 
 func fileExists(path string) bool {
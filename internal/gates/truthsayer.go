@@ -12,31 +12,58 @@ import (
 
 // truthsayerReport models the JSON output of `truthsayer scan --format json`.
 type truthsayerReport struct {
-	Findings []struct {
-		Severity string `json:"severity"`
-	} `json:"findings"`
-	Summary struct {
+	Findings []truthsayerFinding `json:"findings"`
+	Summary  struct {
 		Errors   int `json:"errors"`
 		Warnings int `json:"warnings"`
 		Info     int `json:"info"`
 	} `json:"summary"`
 }
 
+// truthsayerFinding is one entry in the findings array of a truthsayer report.
+type truthsayerFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
 // RunTruthsayer runs truthsayer scan on the repo at dir.
 // Truthsayer is optional — if not installed, the gate passes with skipped=true.
 // Pass criteria: zero critical (error) findings.
 func RunTruthsayer(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
-	return runTruthsayer(ctx, dir, timeoutSec)
+	return runTruthsayer(ctx, dir, timeoutSec, nil)
 }
 
-// RunTruthsayerCI runs truthsayer in CI mode (changed lines/files focus).
-// Note: truthsayer "ci" subcommand does not support --format json, so this
-// currently behaves identically to RunTruthsayer (full scan with JSON output).
+// RunTruthsayerCI runs truthsayer in changed-lines mode against the default
+// base (main, then master).
 func RunTruthsayerCI(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
-	return runTruthsayer(ctx, dir, timeoutSec)
+	return RunTruthsayerDiff(ctx, dir, timeoutSec, "")
 }
 
-func runTruthsayer(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
+// RunTruthsayerDiff scans the full tree, then scopes findings to the lines
+// changed since the merge base of base and HEAD. Only errors on changed lines
+// fail the gate; the rest are counted as pre-existing. Without a usable diff
+// (not a git repo, no merge base) it falls back to the full-scan verdict.
+func RunTruthsayerDiff(ctx context.Context, dir string, timeoutSec int, base string) verdict.GateResult {
+	if timeoutSec <= 0 {
+		timeoutSec = 60
+	}
+	changed, err := DiffChangedLines(ctx, dir, base, timeoutSec)
+	if err != nil {
+		r := runTruthsayer(ctx, dir, timeoutSec, nil)
+		if !r.Skipped {
+			r.Output += fmt.Sprintf(" (full scan: %v)", err)
+		}
+		return r
+	}
+	return runTruthsayer(ctx, dir, timeoutSec, &changed)
+}
+
+// runTruthsayer scans dir. A non-nil changed scopes findings to the diff.
+func runTruthsayer(ctx context.Context, dir string, timeoutSec int, changed *ChangedLines) verdict.GateResult {
 	if timeoutSec <= 0 {
 		timeoutSec = 60
 	}
@@ -59,13 +86,41 @@ func runTruthsayer(ctx context.Context, dir string, timeoutSec int) verdict.Gate
 		}
 	}
 
+	baseline, err := LoadBaseline(dir)
+	if err != nil {
+		return verdict.GateResult{Name: "truthsayer", Pass: false, Output: err.Error(), DurationMs: dur}
+	}
+
+	report, decoded := decodeTruthsayerReport(output)
+	var issues []verdict.Issue
+	if decoded {
+		for _, fd := range report.Findings {
+			issues = append(issues, fd.issue())
+		}
+		fingerprintIssues(dir, issues)
+	}
+
+	if changed != nil && decoded && scopable(report) {
+		return scopedTruthsayerResult(issues, *changed, baseline, dur)
+	}
+
 	findings := parseTruthsayerOutput(output)
 	pass := cmdPass && findings.Errors == 0
+	if suppressed := baseline.apply("truthsayer", issues); suppressed > 0 {
+		// A non-zero exit may only reflect baselined errors; it still
+		// counts when none of them were errors.
+		var baselinedErrors int
+		findings, baselinedErrors = subtractBaselined(findings, issues)
+		pass = (cmdPass || baselinedErrors > 0) && findings.Errors == 0
+	}
 
 	summary := fmt.Sprintf("%d errors, %d warnings, %d info", findings.Errors, findings.Warnings, findings.Info)
 	if !pass {
 		summary = fmt.Sprintf("errors=%d warnings=%d info=%d (cmd_pass=%v)", findings.Errors, findings.Warnings, findings.Info, cmdPass)
 	}
+	if findings.Suppressed > 0 {
+		summary += fmt.Sprintf("; %d baselined", findings.Suppressed)
+	}
 
 	return verdict.GateResult{
 		Name:       "truthsayer",
@@ -73,6 +128,103 @@ func runTruthsayer(ctx context.Context, dir string, timeoutSec int) verdict.Gate
 		Output:     summary,
 		DurationMs: dur,
 		Findings:   &findings,
+		Issues:     issues,
+	}
+}
+
+// scopedTruthsayerResult splits findings into new-in-diff and pre-existing.
+// Findings without a file cannot be placed and count as new. The exit code
+// is ignored: truthsayer fails on pre-existing errors too.
+func scopedTruthsayerResult(issues []verdict.Issue, changed ChangedLines, baseline Baseline, dur int64) verdict.GateResult {
+	for i := range issues {
+		if issues[i].File != "" && !changed.Touches(issues[i].File, issues[i].Line) {
+			issues[i].PreExisting = true
+		}
+	}
+	baseline.apply("truthsayer", issues)
+	f := tallyIssues(issues, true)
+	pass := f.Errors == 0
+
+	summary := fmt.Sprintf("%d errors, %d warnings, %d info new in diff; %d pre-existing", f.Errors, f.Warnings, f.Info, f.PreExisting)
+	if !pass {
+		summary = fmt.Sprintf("new in diff: errors=%d warnings=%d info=%d; pre-existing=%d", f.Errors, f.Warnings, f.Info, f.PreExisting)
+	}
+	if f.Suppressed > 0 {
+		summary += fmt.Sprintf("; %d baselined", f.Suppressed)
+	}
+
+	return verdict.GateResult{
+		Name:       "truthsayer",
+		Pass:       pass,
+		Output:     summary,
+		DurationMs: dur,
+		Findings:   &f,
+		Issues:     issues,
+	}
+}
+
+func (fd truthsayerFinding) issue() verdict.Issue {
+	return verdict.Issue{
+		Rule:     fd.Rule,
+		Severity: normalizeSeverity(fd.Severity),
+		File:     cleanIssuePath(fd.File),
+		Line:     fd.Line,
+		Column:   fd.Column,
+		Message:  fd.Message,
+	}
+}
+
+// normalizeSeverity maps scanner severity spellings onto error, warning,
+// and info. Unknown severities pass through lowercased.
+func normalizeSeverity(severity string) string {
+	s := strings.ToLower(strings.TrimSpace(severity))
+	switch s {
+	case "warn":
+		return "warning"
+	case "critical":
+		return "error"
+	}
+	return s
+}
+
+// cleanIssuePath normalises a reported path, leaving an empty path empty.
+func cleanIssuePath(p string) string {
+	if p == "" {
+		return ""
+	}
+	return cleanRepoPath(p)
+}
+
+// scopable reports whether a report lists its findings individually. A
+// summary-only report with non-zero counts cannot be scoped to the diff.
+func scopable(report truthsayerReport) bool {
+	s := report.Summary
+	return len(report.Findings) > 0 || (s.Errors == 0 && s.Warnings == 0 && s.Info == 0)
+}
+
+// decodeTruthsayerReport locates and decodes the JSON report in output,
+// skipping any log lines printed before it.
+func decodeTruthsayerReport(output string) (truthsayerReport, bool) {
+	var report truthsayerReport
+	raw := strings.TrimSpace(output)
+	idx := strings.Index(raw, "{")
+	if idx < 0 {
+		return report, false
+	}
+	if err := json.NewDecoder(strings.NewReader(raw[idx:])).Decode(&report); err != nil {
+		return report, false
+	}
+	return report, true
+}
+
+func countSeverity(f *verdict.Findings, severity string) {
+	switch strings.ToLower(severity) {
+	case "error":
+		f.Errors++
+	case "warning", "warn":
+		f.Warnings++
+	case "info":
+		f.Info++
 	}
 }
 
@@ -87,41 +239,29 @@ func parseTruthsayerOutput(output string) verdict.Findings {
 		return f
 	}
 
-	// Locate the start of the JSON object. Output may contain log lines
-	// before the JSON blob (e.g. "INFO scanning...").
-	if idx := strings.Index(raw, "{"); idx >= 0 {
-		var report truthsayerReport
-		dec := json.NewDecoder(strings.NewReader(raw[idx:]))
-		if err := dec.Decode(&report); err == nil {
-			// Prefer the summary counts when present.
-			if report.Summary.Errors > 0 || report.Summary.Warnings > 0 || report.Summary.Info > 0 {
-				return verdict.Findings{
-					Errors:   report.Summary.Errors,
-					Warnings: report.Summary.Warnings,
-					Info:     report.Summary.Info,
-				}
-			}
-			// Summary might be all zeros; cross-check against findings array.
-			if len(report.Findings) > 0 {
-				for _, fd := range report.Findings {
-					switch strings.ToLower(fd.Severity) {
-					case "error":
-						f.Errors++
-					case "warning", "warn":
-						f.Warnings++
-					case "info":
-						f.Info++
-					}
-				}
-				return f
-			}
-			// Valid JSON with zero summary and no findings — clean scan.
+	// Output may contain log lines before the JSON blob (e.g. "INFO scanning...").
+	if report, ok := decodeTruthsayerReport(raw); ok {
+		// Prefer the summary counts when present.
+		if report.Summary.Errors > 0 || report.Summary.Warnings > 0 || report.Summary.Info > 0 {
 			return verdict.Findings{
 				Errors:   report.Summary.Errors,
 				Warnings: report.Summary.Warnings,
 				Info:     report.Summary.Info,
 			}
 		}
+		// Summary might be all zeros; cross-check against findings array.
+		if len(report.Findings) > 0 {
+			for _, fd := range report.Findings {
+				countSeverity(&f, fd.Severity)
+			}
+			return f
+		}
+		// Valid JSON with zero summary and no findings — clean scan.
+		return verdict.Findings{
+			Errors:   report.Summary.Errors,
+			Warnings: report.Summary.Warnings,
+			Info:     report.Summary.Info,
+		}
 	}
 
 	// Fallback: count severity prefixes in plain-text output.
@@ -1,7 +1,13 @@
 package gates
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"polis/gate/internal/verdict"
 )
 
 func TestParseTruthsayerOutput_JSON(t *testing.T) {
@@ -189,3 +195,228 @@ WARN minor.thing`
 		t.Errorf("expected 1 warning from fallback, got %d", f.Warnings)
 	}
 }
+
+func TestParseUnifiedZero(t *testing.T) {
+	output := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4,2 @@ func main() {
++	a()
++	b()
+@@ -10 +12 @@ func helper() {
+-	old()
++	new()
+@@ -20,3 +21,0 @@ func gone() {
+-	x
+-	y
+-	z
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package old
+-
+diff --git a/only_deletes.go b/only_deletes.go
+--- a/only_deletes.go
++++ b/only_deletes.go
+@@ -5 +4,0 @@
+-	dead()
+`
+	files := parseUnifiedZero(output)
+
+	got := files["main.go"]
+	want := []LineRange{{Start: 4, End: 5}, {Start: 12, End: 12}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("main.go ranges = %+v, want %+v", got, want)
+	}
+	if _, ok := files["old.go"]; ok {
+		t.Fatal("deleted file should not be listed")
+	}
+	if r, ok := files["only_deletes.go"]; !ok || len(r) != 0 {
+		t.Fatalf("deletion-only file should be listed without ranges, got %+v (listed=%v)", r, ok)
+	}
+}
+
+func TestParseUnifiedZero_AddedLineLooksLikeHeader(t *testing.T) {
+	// Added lines "++ counter" and "-- note" render as "+++ counter" and
+	// "--- note" inside the hunk.
+	output := `diff --git a/notes.md b/notes.md
+--- a/notes.md
++++ b/notes.md
+@@ -1,0 +2,2 @@
++++ counter
++++ b/other.md
+@@ -7 +9 @@
+--- note
++fixed
+`
+	files := parseUnifiedZero(output)
+	want := []LineRange{{Start: 2, End: 3}, {Start: 9, End: 9}}
+	got := files["notes.md"]
+	if len(files) != 1 || len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("files = %+v, want notes.md %+v", files, want)
+	}
+}
+
+func TestChangedLinesTouches(t *testing.T) {
+	c := ChangedLines{Files: map[string][]LineRange{
+		"main.go":         {{Start: 4, End: 5}},
+		"only_deletes.go": nil,
+	}}
+	tests := []struct {
+		file string
+		line int
+		want bool
+	}{
+		{"main.go", 4, true},
+		{"./main.go", 5, true},
+		{"main.go", 6, false},
+		{"main.go", 0, true},
+		{"only_deletes.go", 3, false},
+		{"only_deletes.go", 0, true},
+		{"other.go", 0, false},
+	}
+	for _, tt := range tests {
+		if got := c.Touches(tt.file, tt.line); got != tt.want {
+			t.Errorf("Touches(%q, %d) = %v, want %v", tt.file, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRunTruthsayerDiff_ScopesFindingsToChangedLines(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	gitRun(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tpanic(1)\n}\n"), 0o644)
+
+	orig := runCmdFunc
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name == "git" {
+			return orig(ctx, d, timeout, name, args...)
+		}
+		// truthsayer exits non-zero because of the pre-existing error.
+		return false, `{"findings":[
+			{"severity":"warning","file":"main.go","line":4},
+			{"severity":"error","file":"main.go","line":1},
+			{"severity":"error","file":"lib/old.go","line":9}
+		],"summary":{"errors":2,"warnings":1,"info":0}}`, nil
+	})
+
+	r := RunTruthsayerDiff(context.Background(), dir, 10, "main")
+	if !r.Pass {
+		t.Fatalf("pre-existing errors should not fail the diff, got %+v", r)
+	}
+	if r.Findings.New != 1 || r.Findings.Warnings != 1 || r.Findings.Errors != 0 || r.Findings.PreExisting != 2 {
+		t.Fatalf("unexpected findings: %+v", r.Findings)
+	}
+	if len(r.Issues) != 3 || r.Issues[0].PreExisting || !r.Issues[1].PreExisting || !r.Issues[2].PreExisting {
+		t.Fatalf("expected issues marked by diff scope, got %+v", r.Issues)
+	}
+	if !strings.Contains(r.Output, "new in diff; 2 pre-existing") {
+		t.Fatalf("unexpected output: %s", r.Output)
+	}
+}
+
+func TestRunTruthsayerDiff_NewErrorFails(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name == "git" && args[0] == "merge-base" {
+			return true, "abc123\n", nil
+		}
+		if name == "git" {
+			return true, "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,0 +2,3 @@\n", nil
+		}
+		return false, `{"findings":[{"severity":"error","file":"a.go","line":3},{"severity":"error","message":"no location"}],"summary":{"errors":2}}`, nil
+	})
+
+	r := RunTruthsayerDiff(context.Background(), t.TempDir(), 10, "")
+	if r.Pass {
+		t.Fatal("expected fail on new error")
+	}
+	if r.Findings.Errors != 2 || r.Findings.PreExisting != 0 {
+		t.Fatalf("unlocated findings should count as new, got %+v", r.Findings)
+	}
+}
+
+func TestRunTruthsayerDiff_UntrackedFileIsNew(t *testing.T) {
+	dir := t.TempDir()
+	gitRepo(t, dir, map[string]string{"a.go": "package a\n"})
+	os.WriteFile(filepath.Join(dir, "new.go"), []byte("package a\n\nfunc bad() {}\n"), 0o644)
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name == "git" {
+			return runCmdImpl(ctx, d, timeout, name, args...)
+		}
+		return false, `{"findings":[{"severity":"error","file":"new.go","line":3},{"severity":"error","file":"a.go","line":1}],"summary":{"errors":2}}`, nil
+	})
+
+	r := RunTruthsayerDiff(context.Background(), dir, 10, "main")
+	if r.Pass {
+		t.Fatalf("expected the untracked file's error to fail, got %+v", r)
+	}
+	if r.Findings.New != 1 || r.Findings.Errors != 1 || r.Findings.PreExisting != 1 {
+		t.Fatalf("unexpected findings: %+v", r.Findings)
+	}
+}
+
+func TestRunTruthsayerDiff_NoGitFallsBackToFullScan(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		if name == "git" {
+			return false, "fatal: not a git repository", nil
+		}
+		return false, `{"findings":[{"severity":"error","file":"a.go","line":3}],"summary":{"errors":1}}`, nil
+	})
+
+	r := RunTruthsayerDiff(context.Background(), t.TempDir(), 10, "")
+	if r.Pass {
+		t.Fatal("expected full-scan failure without a diff")
+	}
+	if r.Findings.PreExisting != 0 || !strings.Contains(r.Output, "full scan: no merge base") {
+		t.Fatalf("expected full-scan fallback, got %+v (%s)", r.Findings, r.Output)
+	}
+}
+
+func TestRunTruthsayer_CarriesIssues(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, `{"findings":[
+			{"rule":"trace-gaps.no-stderr-capture","severity":"error","file":"./cmd/gate/main.go","line":382,"column":5,"message":"exec.Command used without stderr capture"},
+			{"rule":"bad-defaults.magic-number","severity":"WARN","file":"internal/gates/lint.go","line":63,"message":"Magic number used directly"}
+		],"summary":{"errors":1,"warnings":1,"info":0}}`, nil
+	})
+
+	r := RunTruthsayer(context.Background(), t.TempDir(), 30)
+
+	if len(r.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", r.Issues)
+	}
+	want := verdict.Issue{
+		Rule:     "trace-gaps.no-stderr-capture",
+		Severity: "error",
+		File:     "cmd/gate/main.go",
+		Line:     382,
+		Column:   5,
+		Message:  "exec.Command used without stderr capture",
+	}
+	got := r.Issues[0]
+	if got.Fingerprint == "" {
+		t.Error("expected issue fingerprint")
+	}
+	got.Fingerprint = ""
+	if got != want {
+		t.Errorf("issue[0] = %+v, want %+v", got, want)
+	}
+	if r.Issues[1].Severity != "warning" || r.Issues[1].Column != 0 {
+		t.Errorf("unexpected issue[1]: %+v", r.Issues[1])
+	}
+}
+
+func TestRunTruthsayer_TextOutputHasNoIssues(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, "ERROR something.bad\n  file.go:1", nil
+	})
+
+	r := RunTruthsayer(context.Background(), t.TempDir(), 30)
+	if r.Findings.Errors != 1 || len(r.Issues) != 0 {
+		t.Fatalf("expected counts without issues, got %+v %+v", r.Findings, r.Issues)
+	}
+}
@@ -10,15 +10,12 @@ import (
 	"polis/gate/internal/verdict"
 )
 
-// ubsReport models the JSON output of `ubs --format=json`.
+// ubsReport models the JSON output of `ubs --format=json`. Findings may be
+// listed per scanner, at the top level, or both.
 type ubsReport struct {
-	Scanners []struct {
-		Critical int    `json:"critical"`
-		Warning  int    `json:"warning"`
-		Info     int    `json:"info"`
-		Language string `json:"language"`
-	} `json:"scanners"`
-	Totals struct {
+	Scanners []ubsScanner `json:"scanners"`
+	Findings []ubsFinding `json:"findings"`
+	Totals   struct {
 		Critical int `json:"critical"`
 		Warning  int `json:"warning"`
 		Info     int `json:"info"`
@@ -26,33 +23,62 @@ type ubsReport struct {
 	} `json:"totals"`
 }
 
+// ubsScanner is one language scanner's section of a ubs report.
+type ubsScanner struct {
+	Scanner  string       `json:"scanner"`
+	Critical int          `json:"critical"`
+	Warning  int          `json:"warning"`
+	Info     int          `json:"info"`
+	Language string       `json:"language"`
+	Findings []ubsFinding `json:"findings"`
+}
+
+// ubsFinding is one bug reported by a ubs scanner.
+type ubsFinding struct {
+	Scanner  string `json:"scanner"`
+	Language string `json:"language"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
 // RunUBS runs ubs build health check on the repo at dir.
 // UBS is optional — if not installed, the gate passes with skipped=true.
 // Pass criteria: no critical-level failures in output.
 func RunUBS(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
-	return runUBS(ctx, dir, timeoutSec, false)
+	return runUBS(ctx, dir, timeoutSec, nil)
 }
 
-// RunUBSDiff runs ubs in diff mode (changed files only).
-func RunUBSDiff(ctx context.Context, dir string, timeoutSec int) verdict.GateResult {
-	return runUBS(ctx, dir, timeoutSec, true)
+// RunUBSDiff scans the full tree, then scopes findings to the lines changed
+// since the merge base of base and HEAD, like RunTruthsayerDiff. Only
+// critical findings on changed lines fail the gate. Without a usable diff
+// (not a git repo, no merge base) it falls back to the full-scan verdict.
+func RunUBSDiff(ctx context.Context, dir string, timeoutSec int, base string) verdict.GateResult {
+	if timeoutSec <= 0 {
+		timeoutSec = 60
+	}
+	changed, err := DiffChangedLines(ctx, dir, base, timeoutSec)
+	if err != nil {
+		r := runUBS(ctx, dir, timeoutSec, nil)
+		if !r.Skipped {
+			r.Output += fmt.Sprintf(" (full scan: %v)", err)
+		}
+		return r
+	}
+	return runUBS(ctx, dir, timeoutSec, &changed)
 }
 
-func runUBS(ctx context.Context, dir string, timeoutSec int, diffMode bool) verdict.GateResult {
+// runUBS scans dir. A non-nil changed scopes findings to the diff.
+func runUBS(ctx context.Context, dir string, timeoutSec int, changed *ChangedLines) verdict.GateResult {
 	if timeoutSec <= 0 {
 		timeoutSec = 60
 	}
 
 	start := time.Now()
-	args := []string{"--format=json", "."}
-	if diffMode {
-		args = []string{"--diff", "--format=json", "."}
-	}
-	cmdPass, output, err := runCmd(ctx, dir, timeoutSec, "ubs", args...)
-	if diffMode && err == nil && !cmdPass {
-		// Diff mode can fail in non-git contexts; fall back to full scan.
-		cmdPass, output, err = runCmd(ctx, dir, timeoutSec, "ubs", "--format=json", ".")
-	}
+	cmdPass, output, err := runCmd(ctx, dir, timeoutSec, "ubs", "--format=json", ".")
 	dur := time.Since(start).Milliseconds()
 
 	if err != nil {
@@ -65,13 +91,41 @@ func runUBS(ctx context.Context, dir string, timeoutSec int, diffMode bool) verd
 		}
 	}
 
+	baseline, err := LoadBaseline(dir)
+	if err != nil {
+		return verdict.GateResult{Name: "ubs", Pass: false, Output: err.Error(), DurationMs: dur}
+	}
+
 	findings := parseUBSOutput(output)
 	pass := cmdPass && findings.Errors == 0
 
+	var issues []verdict.Issue
+	if report, ok := decodeUBSReport(output); ok {
+		issues = ubsIssues(report)
+		fingerprintIssues(dir, issues)
+	}
+
+	// A report that counts findings without listing them cannot be scoped.
+	listed := len(issues) > 0 || (findings.Errors == 0 && findings.Warnings == 0 && findings.Info == 0)
+	if changed != nil && listed {
+		return scopedUBSResult(issues, *changed, baseline, dur)
+	}
+
+	if suppressed := baseline.apply("ubs", issues); suppressed > 0 {
+		// A non-zero exit may only reflect baselined errors; it still
+		// counts when none of them were errors.
+		var baselinedErrors int
+		findings, baselinedErrors = subtractBaselined(findings, issues)
+		pass = (cmdPass || baselinedErrors > 0) && findings.Errors == 0
+	}
+
 	summary := fmt.Sprintf("critical=%d warning=%d info=%d", findings.Errors, findings.Warnings, findings.Info)
 	if !pass {
 		summary = fmt.Sprintf("critical=%d warning=%d info=%d (cmd_pass=%v)", findings.Errors, findings.Warnings, findings.Info, cmdPass)
 	}
+	if findings.Suppressed > 0 {
+		summary += fmt.Sprintf(" baselined=%d", findings.Suppressed)
+	}
 
 	return verdict.GateResult{
 		Name:       "ubs",
@@ -79,7 +133,84 @@ func runUBS(ctx context.Context, dir string, timeoutSec int, diffMode bool) verd
 		Output:     summary,
 		DurationMs: dur,
 		Findings:   &findings,
+		Issues:     issues,
+	}
+}
+
+// scopedUBSResult splits findings into new-in-diff and pre-existing.
+// Findings without a file cannot be placed and count as new. The exit code
+// is ignored: ubs fails on pre-existing findings too.
+func scopedUBSResult(issues []verdict.Issue, changed ChangedLines, baseline Baseline, dur int64) verdict.GateResult {
+	for i := range issues {
+		if issues[i].File != "" && !changed.Touches(issues[i].File, issues[i].Line) {
+			issues[i].PreExisting = true
+		}
+	}
+	baseline.apply("ubs", issues)
+	f := tallyIssues(issues, true)
+
+	summary := fmt.Sprintf("new in diff: critical=%d warning=%d info=%d; pre-existing=%d", f.Errors, f.Warnings, f.Info, f.PreExisting)
+	if f.Suppressed > 0 {
+		summary += fmt.Sprintf(" baselined=%d", f.Suppressed)
+	}
+
+	return verdict.GateResult{
+		Name:       "ubs",
+		Pass:       f.Errors == 0,
+		Output:     summary,
+		DurationMs: dur,
+		Findings:   &f,
+		Issues:     issues,
+	}
+}
+
+// ubsIssues flattens per-scanner and top-level findings. Per-scanner
+// findings inherit the scanner's name and language when they omit them.
+func ubsIssues(report ubsReport) []verdict.Issue {
+	var issues []verdict.Issue
+	for _, sc := range report.Scanners {
+		for _, fd := range sc.Findings {
+			if fd.Scanner == "" {
+				fd.Scanner = sc.Scanner
+			}
+			if fd.Language == "" {
+				fd.Language = sc.Language
+			}
+			issues = append(issues, fd.issue())
+		}
+	}
+	for _, fd := range report.Findings {
+		issues = append(issues, fd.issue())
 	}
+	return issues
+}
+
+func (fd ubsFinding) issue() verdict.Issue {
+	return verdict.Issue{
+		Severity: normalizeSeverity(fd.Severity),
+		File:     cleanIssuePath(fd.File),
+		Line:     fd.Line,
+		Column:   fd.Column,
+		Message:  fd.Message,
+		Scanner:  fd.Scanner,
+		Language: fd.Language,
+		Category: fd.Category,
+	}
+}
+
+// decodeUBSReport locates and decodes the JSON report in output, skipping
+// the banner lines ubs prints before it.
+func decodeUBSReport(output string) (ubsReport, bool) {
+	var report ubsReport
+	raw := strings.TrimSpace(output)
+	idx := strings.Index(raw, "{")
+	if idx < 0 {
+		return report, false
+	}
+	if err := json.NewDecoder(strings.NewReader(raw[idx:])).Decode(&report); err != nil {
+		return report, false
+	}
+	return report, true
 }
 
 // parseUBSOutput extracts finding counts from UBS JSON output.
@@ -93,36 +224,32 @@ func parseUBSOutput(output string) verdict.Findings {
 		return f
 	}
 
-	// Locate the start of the JSON object. UBS emits banner lines
-	// (e.g. "UBS Meta-Runner v5.0.7 ...") before the JSON blob.
-	if idx := strings.Index(raw, "{"); idx >= 0 {
-		var report ubsReport
-		dec := json.NewDecoder(strings.NewReader(raw[idx:]))
-		if err := dec.Decode(&report); err == nil {
-			// Prefer the totals when present.
-			if report.Totals.Critical > 0 || report.Totals.Warning > 0 || report.Totals.Info > 0 || report.Totals.Files > 0 {
-				return verdict.Findings{
-					Errors:   report.Totals.Critical,
-					Warnings: report.Totals.Warning,
-					Info:     report.Totals.Info,
-				}
-			}
-			// Totals are all zeros; cross-check by summing per-scanner counts.
-			if len(report.Scanners) > 0 {
-				for _, s := range report.Scanners {
-					f.Errors += s.Critical
-					f.Warnings += s.Warning
-					f.Info += s.Info
-				}
-				return f
-			}
-			// Valid JSON with zero totals and no scanners — clean scan.
+	// UBS emits banner lines (e.g. "UBS Meta-Runner v5.0.7 ...") before
+	// the JSON blob.
+	if report, ok := decodeUBSReport(raw); ok {
+		// Prefer the totals when present.
+		if report.Totals.Critical > 0 || report.Totals.Warning > 0 || report.Totals.Info > 0 || report.Totals.Files > 0 {
 			return verdict.Findings{
 				Errors:   report.Totals.Critical,
 				Warnings: report.Totals.Warning,
 				Info:     report.Totals.Info,
 			}
 		}
+		// Totals are all zeros; cross-check by summing per-scanner counts.
+		if len(report.Scanners) > 0 {
+			for _, s := range report.Scanners {
+				f.Errors += s.Critical
+				f.Warnings += s.Warning
+				f.Info += s.Info
+			}
+			return f
+		}
+		// Valid JSON with zero totals and no scanners — clean scan.
+		return verdict.Findings{
+			Errors:   report.Totals.Critical,
+			Warnings: report.Totals.Warning,
+			Info:     report.Totals.Info,
+		}
 	}
 
 	// Fallback: count icon prefixes in plain-text output.
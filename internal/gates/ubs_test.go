@@ -1,7 +1,10 @@
 package gates
 
 import (
+	"context"
 	"testing"
+
+	"polis/gate/internal/verdict"
 )
 
 func TestParseUBSOutput_WithErrors(t *testing.T) {
@@ -202,3 +205,70 @@ func TestParseUBSOutput_MalformedJSON(t *testing.T) {
 		t.Errorf("expected 1 warning from icon fallback, got %d", f.Warnings)
 	}
 }
+
+func TestUBSIssues_PerScannerAndTopLevel(t *testing.T) {
+	output := "UBS Meta-Runner v5.0.7\n" + `{
+  "scanners": [
+    {
+      "scanner": "ubs-golang",
+      "language": "golang",
+      "critical": 1,
+      "findings": [
+        {"severity": "critical", "file": "./internal/db/db.go", "line": 42, "category": "nil-deref", "message": "possible nil dereference"}
+      ]
+    },
+    {
+      "language": "python",
+      "warning": 1,
+      "findings": [
+        {"scanner": "ubs-py", "severity": "warning", "file": "tools/gen.py", "line": 3, "category": "resource-leak", "message": "file handle never closed"}
+      ]
+    }
+  ],
+  "findings": [
+    {"scanner": "ubs-js", "language": "javascript", "severity": "info", "file": "web/app.js", "line": 9, "column": 4, "category": "style", "message": "prefer const"}
+  ],
+  "totals": {"critical": 1, "warning": 1, "info": 1, "files": 3}
+}`
+
+	report, ok := decodeUBSReport(output)
+	if !ok {
+		t.Fatal("expected report to decode")
+	}
+	issues := ubsIssues(report)
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	want := verdict.Issue{
+		Severity: "error",
+		File:     "internal/db/db.go",
+		Line:     42,
+		Message:  "possible nil dereference",
+		Scanner:  "ubs-golang",
+		Language: "golang",
+		Category: "nil-deref",
+	}
+	if issues[0] != want {
+		t.Errorf("issue[0] = %+v, want %+v", issues[0], want)
+	}
+	if issues[1].Scanner != "ubs-py" || issues[1].Language != "python" || issues[1].Severity != "warning" {
+		t.Errorf("expected finding fields to override scanner defaults, got %+v", issues[1])
+	}
+	if issues[2].Language != "javascript" || issues[2].Column != 4 {
+		t.Errorf("unexpected top-level issue: %+v", issues[2])
+	}
+}
+
+func TestRunUBS_CarriesIssues(t *testing.T) {
+	mockRunCmd(t, func(ctx context.Context, d string, timeout int, name string, args ...string) (bool, string, error) {
+		return false, `{"scanners":[{"language":"golang","critical":1,"findings":[{"severity":"critical","file":"main.go","line":5,"category":"nil-deref","message":"boom"}]}],"totals":{"critical":1,"files":1}}`, nil
+	})
+
+	r := RunUBS(context.Background(), t.TempDir(), 30)
+	if r.Pass {
+		t.Fatal("expected fail on critical finding")
+	}
+	if len(r.Issues) != 1 || r.Issues[0].Language != "golang" || r.Issues[0].File != "main.go" {
+		t.Fatalf("expected attributed issue, got %+v", r.Issues)
+	}
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"polis/gate/internal/config"
+	"polis/gate/internal/gates"
+	"polis/gate/internal/verdict"
+)
+
+// BaselineResult is the outcome of regenerating a repo's findings baseline.
+type BaselineResult struct {
+	Path     string
+	Baseline gates.Baseline
+	// Scans holds the truthsayer and ubs results the baseline was built from.
+	Scans []verdict.GateResult
+	// Notes explains scans that were skipped or could not be baselined.
+	Notes []string
+}
+
+// WriteBaseline runs full truthsayer and ubs scans on the repo and writes
+// every finding to gates.BaselineFile, replacing the previous baseline.
+// It fails without writing when neither scanner is available.
+func WriteBaseline(ctx context.Context, repoPath string) (BaselineResult, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return BaselineResult{}, err
+	}
+	cfg, err := config.Load(absPath)
+	if err != nil {
+		return BaselineResult{}, err
+	}
+
+	res := BaselineResult{Path: filepath.Join(absPath, gates.BaselineFile)}
+	if _, err := gates.LoadBaseline(absPath); err != nil {
+		// Scanner gates refuse to run against a broken baseline; drop it
+		// so the scans below see every finding.
+		if err := os.Remove(res.Path); err != nil {
+			return BaselineResult{}, err
+		}
+		res.Notes = append(res.Notes, fmt.Sprintf("replaced %v", err))
+	}
+
+	ran := 0
+	for _, r := range []verdict.GateResult{
+		gates.RunTruthsayer(ctx, absPath, cfg.Truthsayer.TimeoutSec),
+		gates.RunUBS(ctx, absPath, cfg.UBS.TimeoutSec),
+	} {
+		if r.Skipped {
+			res.Notes = append(res.Notes, fmt.Sprintf("%s: %s", r.Name, r.Output))
+			continue
+		}
+		ran++
+		if f := r.Findings; f != nil && len(r.Issues) == 0 && f.Errors+f.Warnings+f.Info > 0 {
+			res.Notes = append(res.Notes, fmt.Sprintf("%s: %d findings reported without details cannot be baselined", r.Name, f.Errors+f.Warnings+f.Info))
+		}
+		res.Scans = append(res.Scans, r)
+	}
+	if ran == 0 {
+		return res, fmt.Errorf("no scanners available: install truthsayer or ubs")
+	}
+
+	res.Baseline = gates.NewBaseline(res.Scans...)
+	if err := res.Baseline.Write(absPath); err != nil {
+		return res, err
+	}
+	return res, nil
+}
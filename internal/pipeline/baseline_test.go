@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/gates"
+)
+
+// fakeTool installs an executable script named name on a PATH holding only
+// bin, so scanners resolve to the fake and nothing else. Scripts can only
+// use shell builtins.
+func fakeTool(t *testing.T, bin, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	t.Setenv("PATH", bin)
+}
+
+func TestWriteBaseline_RecordsScannerFindings(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\nvar x = 1\n"), 0o644)
+	fakeTool(t, t.TempDir(), "truthsayer", `echo '{"findings":[{"rule":"magic","severity":"error","file":"a.go","line":2}],"summary":{"errors":1}}'
+exit 1
+`)
+
+	res, err := WriteBaseline(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("WriteBaseline: %v", err)
+	}
+	if len(res.Baseline.Findings) != 1 || res.Baseline.Findings[0].Gate != "truthsayer" {
+		t.Fatalf("unexpected baseline: %+v", res.Baseline)
+	}
+	if len(res.Notes) != 1 || !strings.Contains(res.Notes[0], "ubs") {
+		t.Fatalf("expected note about missing ubs, got %v", res.Notes)
+	}
+
+	b, err := gates.LoadBaseline(dir)
+	if err != nil || len(b.Findings) != 1 {
+		t.Fatalf("expected baseline on disk, got %+v %v", b, err)
+	}
+
+	v := Run(context.Background(), dir, LevelDeep, "tester")
+	for _, g := range v.Gates {
+		if g.Name == "truthsayer" && (!g.Pass || g.Findings.Suppressed != 1) {
+			t.Fatalf("expected baselined truthsayer pass, got %+v", g)
+		}
+	}
+}
+
+func TestWriteBaseline_NoScanners(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", t.TempDir())
+
+	if _, err := WriteBaseline(context.Background(), dir); err == nil {
+		t.Fatal("expected error without scanners")
+	}
+	if _, err := os.Stat(filepath.Join(dir, gates.BaselineFile)); !os.IsNotExist(err) {
+		t.Fatal("baseline should not be written without scanners")
+	}
+}
+
+func TestWriteBaseline_ReplacesInvalidBaseline(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, gates.BaselineFile), []byte("garbage"), 0o644)
+	fakeTool(t, t.TempDir(), "ubs", `echo '{"totals":{"files":1}}'`)
+
+	res, err := WriteBaseline(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("WriteBaseline: %v", err)
+	}
+	if !strings.Contains(strings.Join(res.Notes, "\n"), "replaced invalid") {
+		t.Fatalf("expected replacement note, got %v", res.Notes)
+	}
+	if _, err := gates.LoadBaseline(dir); err != nil {
+		t.Fatalf("expected valid baseline, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+
+	"polis/gate/internal/cache"
+	"polis/gate/internal/config"
+	"polis/gate/internal/gates"
+	"polis/gate/internal/verdict"
+)
+
+// cacheInputs returns what a gate's result depends on besides the repo
+// state and level: its settings, the tools it may run, and for coverage the
+// reports it reads, which are usually ignored and so outside the repo
+// state. Gates that read state outside the repo, such as fragility with its
+// bead history, are not cacheable and always run. Neither are custom gates
+// and plugins, which may read anything.
+func cacheInputs(absPath, name string, cfg config.Config) (settings any, tools []string, ok bool) {
+	switch name {
+	case config.GateTests:
+		return cfg.Tests, []string{"go", "node", "npm", "python3", "pytest", "cargo", "bats"}, true
+	case config.GateLint:
+		return cfg.Lint, []string{"go", "golangci-lint", "staticcheck", "node", "npx", "ruff", "shellcheck"}, true
+	case config.GateTruthsayer:
+		return []any{cfg.Truthsayer, cfg.Diff}, []string{"truthsayer"}, true
+	case config.GateUBS:
+		return cfg.UBS, []string{"ubs"}, true
+	case config.GateRisk:
+		return []any{cfg.Risk, cfg.Diff}, nil, true
+	case config.GateCoverage:
+		reports := cache.FileDigest(absPath, gates.CoverageReports(absPath, cfg.Coverage.Reports)...)
+		return []any{cfg.Coverage, cfg.Diff, reports}, []string{"go", "node", "npm", "python3", "pytest", "cargo"}, true
+	case config.GateFormat:
+		return cfg.Format, []string{"gofmt", "node", "npx", "ruff", "cargo", "shfmt"}, true
+	}
+	return nil, nil, false
+}
+
+// withCache wraps the cacheable steps so they reuse the result stored for
+// the same repo state, level, settings, tool versions, and gate binary, and
+// store fresh results that finished on their own. With refresh every step
+// runs and replaces its entry.
+// Outside a git repo, or before the first commit, steps run uncached.
+func withCache(ctx context.Context, absPath, level string, cfg config.Config, steps []step, store *cache.Store, refresh bool) []step {
+	state, err := cache.ReadRepoState(ctx, absPath, cfg.Diff.Base)
+	if err != nil {
+		return steps
+	}
+	self := ""
+	if exe, err := os.Executable(); err == nil {
+		self = cache.ToolStamp(exe)[exe]
+	}
+
+	out := make([]step, len(steps))
+	for i, s := range steps {
+		out[i] = s
+		settings, tools, ok := cacheInputs(absPath, s.name, cfg)
+		if !ok {
+			continue
+		}
+		key := cache.Key(state, level, s.name, s.label, settings, cache.ToolStamp(tools...), self)
+		run := s.run
+		out[i].run = func(ctx context.Context) verdict.GateResult {
+			if !refresh {
+				if r, ok := store.Get(key); ok {
+					return r
+				}
+			}
+			ctx, timedOut := gates.WatchTimeouts(ctx)
+			r := run(ctx)
+			// A cancelled or timed-out run says nothing about the inputs.
+			if ctx.Err() == nil && !r.Cancelled && !timedOut() {
+				store.Put(key, r)
+			}
+			return r
+		}
+	}
+	return out
+}
+
+// allCached reports whether every result came from the cache.
+func allCached(results []verdict.GateResult) bool {
+	for _, r := range results {
+		if !r.Cached {
+			return false
+		}
+	}
+	return len(results) > 0
+}
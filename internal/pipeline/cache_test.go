@@ -0,0 +1,142 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/cache"
+)
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestRun_CachesGateResultsByTree(t *testing.T) {
+	dir := t.TempDir()
+	runs := filepath.Join(t.TempDir(), "runs")
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+
+[levels]
+quick = ["tests", "fragility"]
+
+[tests]
+command = ["sh", "-c", "echo run >> `+runs+`"]
+`), 0o644)
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644)
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.email", "gate-tests@example.com")
+	git(t, dir, "config", "user.name", "gate-tests")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "init")
+
+	opts := Options{Cache: cache.Open(t.TempDir())}
+	count := func() int {
+		data, _ := os.ReadFile(runs)
+		return strings.Count(string(data), "run")
+	}
+	check := func() (cachedTests, cachedVerdict bool) {
+		v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", opts)
+		if len(v.Gates) != 2 || !v.Gates[0].Pass {
+			t.Fatalf("unexpected verdict %+v", v)
+		}
+		if v.Gates[1].Cached {
+			t.Fatal("fragility depends on bead history and must not be cached")
+		}
+		return v.Gates[0].Cached, v.Cached
+	}
+
+	if cached, _ := check(); cached || count() != 1 {
+		t.Fatalf("first run: cached=%v runs=%d", cached, count())
+	}
+	if cached, all := check(); !cached || all || count() != 1 {
+		t.Fatalf("second run should reuse tests: cached=%v all=%v runs=%d", cached, all, count())
+	}
+
+	opts.RefreshCache = true
+	if cached, _ := check(); cached || count() != 2 {
+		t.Fatalf("refresh: cached=%v runs=%d", cached, count())
+	}
+	opts.RefreshCache = false
+
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644)
+	if cached, _ := check(); cached || count() != 3 {
+		t.Fatalf("dirty edit should miss: cached=%v runs=%d", cached, count())
+	}
+}
+
+func TestRun_CoverageCacheFollowsIgnoredReport(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n[levels]\nquick = [\"coverage\"]\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("lcov.info\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "app.py"), []byte("a = 1\n"), 0o644)
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.email", "gate-tests@example.com")
+	git(t, dir, "config", "user.name", "gate-tests")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "init")
+
+	opts := Options{Cache: cache.Open(t.TempDir())}
+	check := func(report string) (percent float64, cached bool) {
+		t.Helper()
+		if report != "" {
+			os.WriteFile(filepath.Join(dir, "lcov.info"), []byte(report), 0o644)
+		}
+		v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", opts)
+		g := v.Gates[0]
+		if g.Coverage == nil {
+			t.Fatalf("expected coverage, got %+v", g)
+		}
+		return g.Coverage.Percent, g.Cached
+	}
+
+	if p, cached := check("SF:app.py\nDA:1,1\nend_of_record\n"); p != 100 || cached {
+		t.Fatalf("first run: percent=%v cached=%v", p, cached)
+	}
+	if p, cached := check(""); p != 100 || !cached {
+		t.Fatalf("unchanged report should hit: percent=%v cached=%v", p, cached)
+	}
+	if p, cached := check("SF:app.py\nDA:1,0\nend_of_record\n"); p != 0 || cached {
+		t.Fatalf("regenerated report should miss: percent=%v cached=%v", p, cached)
+	}
+}
+
+func TestRun_TimedOutResultsAreNotCached(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n[levels]\nquick = [\"tests\"]\n[tests]\ncommand = [\"sleep\", \"5\"]\ntimeout = \"1s\"\n"), 0o644)
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.email", "gate-tests@example.com")
+	git(t, dir, "config", "user.name", "gate-tests")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "init")
+
+	store := cache.Open(t.TempDir())
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Cache: store})
+	if v.Gates[0].Pass || !strings.Contains(v.Gates[0].Output, "timeout after 1s") {
+		t.Fatalf("expected a timeout, got %+v", v.Gates[0])
+	}
+	if entries, _ := os.ReadDir(store.Dir()); len(entries) != 0 {
+		t.Fatalf("timed-out result was cached: %v", entries)
+	}
+}
+
+func TestRun_CacheSkippedOutsideGit(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n[levels]\nquick = [\"tests\"]\n[tests]\ncommand = [\"true\"]\n"), 0o644)
+	opts := Options{Cache: cache.Open(t.TempDir())}
+	for range 2 {
+		v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", opts)
+		if v.Cached || v.Gates[0].Cached {
+			t.Fatalf("expected no caching outside git, got %+v", v)
+		}
+	}
+}
@@ -2,17 +2,24 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"slices"
+	"sync"
+	"time"
 
+	"polis/gate/internal/bead"
+	"polis/gate/internal/cache"
+	"polis/gate/internal/config"
 	"polis/gate/internal/gates"
 	"polis/gate/internal/verdict"
 )
 
 // Level controls how thorough the gate check is.
 const (
-	LevelQuick    = "quick"
-	LevelStandard = "standard"
-	LevelDeep     = "deep"
+	LevelQuick    = config.LevelQuick
+	LevelStandard = config.LevelStandard
+	LevelDeep     = config.LevelDeep
 )
 
 // ValidLevel returns true if level is a known level string.
@@ -24,78 +31,420 @@ func ValidLevel(level string) bool {
 	return false
 }
 
+// step is one unit of work in the plan for a level. Lint contributes one
+// step per linter so linters run alongside the other gates.
+type step struct {
+	name  string // configured gate name
+	label string // result name, e.g. "lint:go vet"
+	run   func(ctx context.Context) verdict.GateResult
+	// optional steps only put a failure in review, so they never trip
+	// fail-fast.
+	optional bool
+}
+
+// Options tunes a pipeline run.
+type Options struct {
+	// Concurrency caps how many gates run at once. Zero uses gate.toml.
+	Concurrency int
+	// FailFast cancels the remaining gates as soon as one fails.
+	FailFast bool
+	// Cache, when set, reuses gate results stored for the same inputs and
+	// stores fresh ones.
+	Cache *cache.Store
+	// RefreshCache runs every gate even when a cached result exists; fresh
+	// results still replace the cached ones.
+	RefreshCache bool
+	// Snapshot runs the gates in a temporary git worktree instead of the
+	// live working tree, so untracked files, build artifacts, and edits
+	// made during the check cannot leak into the results.
+	Snapshot bool
+	// Rev is the commit to check. It implies Snapshot; empty uses the
+	// staged index on top of HEAD when snapshotting.
+	Rev string
+	// Base overrides [diff].base: the ref whose merge base with the checked
+	// commit starts the diff for diff-aware gates and risk scoring.
+	Base string
+}
+
 // Run executes the gate pipeline at the given level and returns a verdict.
+// Per-repo overrides are read from gate.toml in the repo root.
 func Run(ctx context.Context, repoPath, level, citizen string) verdict.Verdict {
+	return RunWithOptions(ctx, repoPath, level, citizen, Options{})
+}
+
+// RunWithOptions is Run with explicit options. Independent gates run
+// concurrently; results keep the configured plan order.
+func RunWithOptions(ctx context.Context, repoPath, level, citizen string, opts Options) verdict.Verdict {
+	start := time.Now()
+
 	absPath, err := filepath.Abs(repoPath)
 	if err != nil {
-		setupGates := []verdict.GateResult{{Name: "setup", Pass: false, Output: err.Error()}}
-		return verdict.Verdict{
-			Pass:     false,
-			Score:    verdict.ComputeScore(setupGates),
-			Level:    level,
-			Citizen:  citizen,
-			Repo:     repoPath,
-			ExitCode: verdict.ExitFail,
-			Gates:    setupGates,
-		}
+		return failedVerdict(repoPath, level, citizen, "setup", err.Error())
 	}
 
 	repoName := filepath.Base(absPath)
-	var results []verdict.GateResult
-
-	// Quick: tests + lint
-	testResult := gates.RunTests(ctx, absPath, 120)
-	results = append(results, testResult)
-
-	lintResults := gates.RunLint(ctx, absPath, 60)
-	results = append(results, lintResults...)
-
-	// Standard: + truthsayer + ubs
-	if level == LevelStandard || level == LevelDeep {
-		if level == LevelStandard {
-			// PR-friendly gate: changed-lines/files focus.
-			tsResult := gates.RunTruthsayerCI(ctx, absPath, 60)
-			results = append(results, tsResult)
-
-			ubsResult := gates.RunUBSDiff(ctx, absPath, 60)
-			results = append(results, ubsResult)
-		} else {
-			// Deep gate: full scans.
-			tsResult := gates.RunTruthsayer(ctx, absPath, 60)
-			results = append(results, tsResult)
-
-			ubsResult := gates.RunUBS(ctx, absPath, 60)
-			results = append(results, ubsResult)
+
+	if opts.Base != "" {
+		if _, err := runGit(ctx, absPath, "rev-parse", "--verify", "-q", opts.Base+"^{commit}"); err != nil {
+			return failedVerdict(repoName, level, citizen, "base", fmt.Sprintf("unknown base ref %s", opts.Base))
 		}
 	}
 
-	// Deep: + risk scoring (placeholder for now)
-	if level == LevelDeep {
-		riskResult := verdict.GateResult{Name: "risk", Pass: true, Output: "risk scoring not yet implemented", DurationMs: 0}
-		results = append(results, riskResult)
+	if opts.Snapshot || opts.Rev != "" {
+		snap, err := newSnapshot(ctx, absPath, opts.Rev)
+		if err != nil {
+			return failedVerdict(repoName, level, citizen, "snapshot", err.Error())
+		}
+		defer snap.cleanup()
+		absPath = snap.dir
 	}
 
-	// Compute overall pass/fail
-	allPass := true
+	cfg, err := config.Load(absPath)
+	if err != nil {
+		return failedVerdict(repoName, level, citizen, "config", err.Error())
+	}
+	if opts.Base != "" {
+		cfg.Diff.Base = opts.Base
+	}
+	cfg.AddDiscoveredPlugins(gates.DiscoverPlugins())
+	head, base := revisions(ctx, absPath, cfg.Diff.Base)
+
+	limit := opts.Concurrency
+	if limit <= 0 {
+		limit = cfg.Concurrency
+	}
+
+	steps := plan(ctx, absPath, repoName, level, head, base, cfg)
+	if opts.Cache != nil {
+		steps = withCache(ctx, absPath, level, cfg, steps, opts.Cache, opts.RefreshCache)
+	}
+	results := runSteps(ctx, steps, limit, opts.FailFast)
+	for i := range results {
+		applyReviewPolicy(&results[i], steps[i].name, cfg)
+	}
+
+	// Fragility reports the areas the change touches; record them so fail
+	// beads carry area labels for future fragility lookups.
+	var areas []string
 	for _, r := range results {
-		if !r.Pass {
-			allPass = false
-			break
+		for _, af := range r.Fragility {
+			areas = append(areas, af.Area)
 		}
 	}
 
-	exitCode := verdict.ExitPass
-	if !allPass {
-		exitCode = verdict.ExitFail
+	exitCode := verdict.ExitCodeFor(results)
+
+	return verdict.Verdict{
+		Pass:          exitCode != verdict.ExitFail,
+		Score:         verdict.ComputeScore(results),
+		Level:         level,
+		Citizen:       citizen,
+		Repo:          repoName,
+		Head:          head,
+		Base:          base,
+		Gates:         results,
+		Areas:         areas,
+		ExitCode:      exitCode,
+		ReviewReasons: verdict.ReviewReasons(results),
+		DurationMs:    time.Since(start).Milliseconds(),
+		Cached:        allCached(results),
 	}
+}
+
+// revisions resolves the commit under check and the merge base the
+// diff-aware gates compare it against. Either is empty when it cannot be
+// resolved, e.g. outside git or without a base branch.
+func revisions(ctx context.Context, absPath, baseRef string) (head, base string) {
+	head, err := runGit(ctx, absPath, "rev-parse", "--verify", "-q", "HEAD")
+	if err != nil {
+		return "", ""
+	}
+	base, err = gates.MergeBase(ctx, absPath, baseRef, int(gitTimeout/time.Second))
+	if err != nil {
+		return head, ""
+	}
+	return head, base
+}
+
+// runSteps runs steps with at most limit in flight and returns their results
+// in step order. With failFast, the first failing required step cancels the
+// rest: steps not yet started, and steps that fail after their context was
+// cancelled, are reported as cancelled.
+func runSteps(ctx context.Context, steps []step, limit int, failFast bool) []verdict.GateResult {
+	if limit <= 0 {
+		limit = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ff := &failFaster{enabled: failFast, cancel: cancel}
+
+	results := make([]verdict.GateResult, len(steps))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, s := range steps {
+		sem <- struct{}{}
+		if t := ff.tripped(); t != "" {
+			<-sem
+			results[i] = verdict.GateResult{Name: s.label}
+			results[i].MarkCancelled(cancelReason(t))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r := s.run(ctx)
+			ff.finish(&r, s.optional, ctx.Err() != nil)
+			results[i] = r
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// failFaster applies fail-fast to completed steps.
+type failFaster struct {
+	enabled bool
+	cancel  context.CancelFunc
+
+	mu      sync.Mutex
+	trigger string
+}
+
+// finish records a completed step: the first required failure trips
+// fail-fast. A later failure is relabelled as cancelled only when the step's
+// context was cancelled before it returned, since then the failure is most
+// likely the cancellation itself; a step that failed on its own keeps its
+// failure.
+func (f *failFaster) finish(r *verdict.GateResult, optional, interrupted bool) {
+	if !f.enabled {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case f.trigger != "" && !r.Pass && interrupted:
+		r.MarkCancelled(cancelReason(f.trigger))
+	case f.trigger == "" && !r.Pass && !optional:
+		f.trigger = r.Name
+		f.cancel()
+	}
+}
+
+// tripped returns the step that tripped fail-fast, if any.
+func (f *failFaster) tripped() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.trigger
+}
+
+func cancelReason(trigger string) string {
+	return fmt.Sprintf("cancelled: fail-fast after %s failed", trigger)
+}
+
+// applyReviewPolicy escalates a passing result to review when its step is
+// required but was skipped, or when a scan reports more warnings than the
+// configured review threshold. A failing optional custom gate or plugin is
+// downgraded to review.
+func applyReviewPolicy(r *verdict.GateResult, stepName string, cfg config.Config) {
+	extra, required := extraGate(stepName, cfg)
+	if r.Skipped {
+		for _, name := range cfg.Required {
+			if name == stepName {
+				r.MarkReview("required gate skipped")
+			}
+		}
+		if extra && required {
+			r.MarkReview("required gate skipped")
+		}
+		return
+	}
+	if extra && !required && !r.Pass && !r.Cancelled {
+		r.Pass = true
+		r.MarkReview("optional gate failed")
+		return
+	}
+
+	var maxWarnings int
+	switch stepName {
+	case config.GateTruthsayer:
+		maxWarnings = cfg.Truthsayer.ReviewWarnings
+	case config.GateUBS:
+		maxWarnings = cfg.UBS.ReviewWarnings
+	}
+	if maxWarnings > 0 && r.Findings != nil && r.Findings.Warnings > maxWarnings {
+		r.MarkReview(fmt.Sprintf("%d warnings exceed review_warnings=%d", r.Findings.Warnings, maxWarnings))
+	}
+}
+
+// extraGate reports whether stepName is a custom gate or plugin, and if so
+// whether it is required.
+func extraGate(stepName string, cfg config.Config) (extra, required bool) {
+	if g, ok := cfg.CustomGate(stepName); ok {
+		return true, g.Required
+	}
+	if p, ok := cfg.Plugin(stepName); ok {
+		return true, p.Required
+	}
+	return false, false
+}
+
+// plan returns the steps configured for level, in configured order,
+// followed by the custom gates and then the enabled plugins that run at
+// level. Plugins are told the head and base commits.
+func plan(ctx context.Context, absPath, repoName, level, head, base string, cfg config.Config) []step {
+	var steps []step
+	for _, name := range cfg.Levels[level] {
+		steps = append(steps, newSteps(ctx, name, absPath, repoName, level, cfg)...)
+	}
+	for _, g := range cfg.Custom {
+		if slices.Contains(g.Levels, level) {
+			steps = append(steps, customStep(absPath, g))
+		}
+	}
+	for _, p := range cfg.Plugins {
+		if !p.Disabled && slices.Contains(p.Levels, level) {
+			req := gates.PluginRequest{Repo: absPath, Level: level, Base: base, Head: head, TimeoutSec: p.TimeoutSec}
+			steps = append(steps, pluginStep(p, req))
+		}
+	}
+	return steps
+}
+
+func customStep(absPath string, g config.CustomGate) step {
+	check := gates.CustomCheck{Name: g.Name, Command: g.Command, Dir: g.Dir, Parser: g.Parser}
+	return step{name: g.Name, label: g.Name, optional: !g.Required, run: func(ctx context.Context) verdict.GateResult {
+		return gates.RunCustom(ctx, absPath, g.TimeoutSec, check)
+	}}
+}
+
+func pluginStep(p config.Plugin, req gates.PluginRequest) step {
+	plugin := gates.Plugin{Name: p.Name, Path: p.Path}
+	return step{name: p.Name, label: p.Name, optional: !p.Required, run: func(ctx context.Context) verdict.GateResult {
+		return gates.RunPlugin(ctx, plugin, req)
+	}}
+}
+
+func newSteps(ctx context.Context, name, absPath, repoName, level string, cfg config.Config) []step {
+	one := func(fn func(ctx context.Context) verdict.GateResult) []step {
+		return []step{{name: name, label: name, run: fn}}
+	}
+
+	switch name {
+	case config.GateTests:
+		opts := gates.TestOptions{
+			Command: cfg.Tests.Command,
+			JUnit:   cfg.Tests.JUnit,
+			Retries: cfg.Tests.Retries,
+		}
+		// A configured command, or a lone project at the root, is the
+		// classic single tests gate. Monorepos get one step per project.
+		var projects []gates.TestProject
+		if len(cfg.Tests.Command) == 0 {
+			projects = gates.DetectTestProjects(ctx, absPath)
+		}
+		if len(projects) == 0 || (len(projects) == 1 && projects[0].Dir == ".") {
+			return one(func(ctx context.Context) verdict.GateResult {
+				if len(projects) == 1 {
+					r := gates.RunTestProject(ctx, absPath, cfg.Tests.TimeoutSec, projects[0], opts)
+					r.Name = name
+					return r
+				}
+				return gates.RunTestSuite(ctx, absPath, cfg.Tests.TimeoutSec, opts)
+			})
+		}
+		steps := make([]step, 0, len(projects))
+		for _, p := range projects {
+			steps = append(steps, step{name: name, label: p.Label(), run: func(ctx context.Context) verdict.GateResult {
+				return gates.RunTestProject(ctx, absPath, cfg.Tests.TimeoutSec, p, opts)
+			}})
+		}
+		return steps
+	case config.GateLint:
+		add := make([]gates.CustomLinter, 0, len(cfg.Lint.Add))
+		for _, l := range cfg.Lint.Add {
+			add = append(add, gates.CustomLinter{Name: l.Name, Command: l.Command})
+		}
+		specs := gates.AdjustLinters(gates.DetectLinters(ctx, absPath), cfg.Lint.Remove, add)
+		if len(specs) == 0 {
+			return one(func(ctx context.Context) verdict.GateResult {
+				return gates.RunLinters(ctx, absPath, cfg.Lint.TimeoutSec, nil)[0]
+			})
+		}
+		steps := make([]step, 0, len(specs))
+		for i := range specs {
+			spec := specs[i : i+1]
+			steps = append(steps, step{name: name, label: "lint:" + specs[i].Name(), run: func(ctx context.Context) verdict.GateResult {
+				return gates.RunLinters(ctx, absPath, cfg.Lint.TimeoutSec, spec)[0]
+			}})
+		}
+		return steps
+	case config.GateTruthsayer:
+		return one(func(ctx context.Context) verdict.GateResult {
+			if level == LevelDeep {
+				// Deep gate: full scan.
+				return gates.RunTruthsayer(ctx, absPath, cfg.Truthsayer.TimeoutSec)
+			}
+			// PR-friendly gate: findings scoped to the changed lines.
+			return gates.RunTruthsayerDiff(ctx, absPath, cfg.Truthsayer.TimeoutSec, cfg.Diff.Base)
+		})
+	case config.GateUBS:
+		return one(func(ctx context.Context) verdict.GateResult {
+			if level == LevelDeep {
+				return gates.RunUBS(ctx, absPath, cfg.UBS.TimeoutSec)
+			}
+			// PR-friendly gate: findings scoped to the changed lines.
+			return gates.RunUBSDiff(ctx, absPath, cfg.UBS.TimeoutSec, cfg.Diff.Base)
+		})
+	case config.GateRisk:
+		return one(func(ctx context.Context) verdict.GateResult {
+			return gates.RunRisk(ctx, absPath, cfg.Risk.TimeoutSec, gates.RiskOptions{
+				Base:      cfg.Diff.Base,
+				FailAt:    cfg.Risk.FailAt,
+				ReviewAt:  cfg.Risk.ReviewAt,
+				Sensitive: cfg.Risk.Sensitive,
+			})
+		})
+	case config.GateFragility:
+		return one(func(ctx context.Context) verdict.GateResult {
+			return gates.RunFragility(ctx, absPath, cfg.Fragility.TimeoutSec, gates.FragilityOptions{
+				Base:       cfg.Diff.Base,
+				WindowDays: cfg.Fragility.WindowDays,
+				Threshold:  cfg.Fragility.Threshold,
+				MinSamples: cfg.Fragility.MinSamples,
+				GateFailures: func(area string) int {
+					return bead.AreaFailures(repoName, area)
+				},
+			})
+		})
+	case config.GateCoverage:
+		return one(func(ctx context.Context) verdict.GateResult {
+			return gates.RunCoverage(ctx, absPath, cfg.Coverage.TimeoutSec, gates.CoverageOptions{
+				Base:       cfg.Diff.Base,
+				Min:        cfg.Coverage.Min,
+				ChangedMin: cfg.Coverage.ChangedMin,
+				Reports:    cfg.Coverage.Reports,
+			})
+		})
+	case config.GateFormat:
+		return one(func(ctx context.Context) verdict.GateResult {
+			return gates.RunFormat(ctx, absPath, cfg.Format.TimeoutSec)
+		})
+	}
+	return nil
+}
 
+// failedVerdict reports a pipeline that could not start, as a single
+// failing gate named after the stage that broke.
+func failedVerdict(repo, level, citizen, gate, detail string) verdict.Verdict {
+	setupGates := []verdict.GateResult{{Name: gate, Pass: false, Output: detail}}
 	return verdict.Verdict{
-		Pass:     allPass,
-		Score:    verdict.ComputeScore(results),
+		Pass:     false,
+		Score:    verdict.ComputeScore(setupGates),
 		Level:    level,
 		Citizen:  citizen,
-		Repo:     repoName,
-		Gates:    results,
-		ExitCode: exitCode,
+		Repo:     repo,
+		ExitCode: verdict.ExitFail,
+		Gates:    setupGates,
 	}
 }
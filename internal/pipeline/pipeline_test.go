@@ -2,9 +2,17 @@ package pipeline
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"polis/gate/internal/config"
+	"polis/gate/internal/verdict"
 )
 
 func TestValidLevel(t *testing.T) {
@@ -88,6 +96,21 @@ func TestRun_DeepLevel_IncludesRisk(t *testing.T) {
 	}
 }
 
+func TestRun_DeepLevel_IncludesFragility(t *testing.T) {
+	dir := t.TempDir()
+	v := Run(context.Background(), dir, LevelDeep, "tester")
+
+	for _, g := range v.Gates {
+		if g.Name == "fragility" {
+			if !g.Skipped {
+				t.Errorf("fragility should skip outside git, got %+v", g)
+			}
+			return
+		}
+	}
+	t.Error("deep level should include fragility gate")
+}
+
 func TestRun_GoProject_RunsGoTest(t *testing.T) {
 	dir := t.TempDir()
 	// Create a minimal Go project that passes tests
@@ -109,3 +132,447 @@ func TestRun_GoProject_RunsGoTest(t *testing.T) {
 		t.Error("expected tests gate in results")
 	}
 }
+
+func TestRun_MonorepoRunsOneTestsGatePerProject(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n[levels]\nquick = [\"tests\"]\n"), 0644)
+	for _, svc := range []string{"svc", "api"} {
+		os.MkdirAll(filepath.Join(dir, svc), 0755)
+		os.WriteFile(filepath.Join(dir, svc, "go.mod"), []byte("module "+svc+"\n\ngo 1.21\n"), 0644)
+		os.WriteFile(filepath.Join(dir, svc, "main.go"), []byte("package main\nfunc main() {}\n"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, "api", "main_test.go"), []byte("package main\nimport \"testing\"\nfunc TestFail(t *testing.T) { t.Fatal(\"no\") }\n"), 0644)
+
+	v := Run(context.Background(), dir, LevelQuick, "tester")
+
+	if len(v.Gates) != 2 || v.Gates[0].Name != "tests:go:./api" || v.Gates[1].Name != "tests:go:./svc" {
+		t.Fatalf("expected one gate per project, got %+v", v.Gates)
+	}
+	if v.Gates[0].Pass || !v.Gates[1].Pass {
+		t.Fatalf("expected api to fail and svc to pass, got %+v", v.Gates)
+	}
+	if len(v.Gates[0].Tests) != 1 || v.Gates[0].Tests[0].Name != "TestFail" {
+		t.Fatalf("expected api tests to run in ./api, got %+v", v.Gates[0].Tests)
+	}
+}
+
+func TestRun_GateTomlSelectsLevelGates(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n\n[levels]\nstandard = [\"lint\"]\n"), 0644)
+
+	v := Run(context.Background(), dir, LevelStandard, "tester")
+	if len(v.Gates) != 1 || v.Gates[0].Name != "lint" {
+		t.Fatalf("expected only lint gate, got %+v", v.Gates)
+	}
+}
+
+func TestRun_GateTomlOverridesTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n\n[tests]\ncommand = [\"false\"]\n"), 0644)
+
+	v := Run(context.Background(), dir, LevelQuick, "tester")
+	if v.Pass {
+		t.Fatalf("expected configured failing test command to fail the verdict: %+v", v.Gates)
+	}
+}
+
+func TestRun_InvalidGateToml(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 9\n"), 0644)
+
+	v := Run(context.Background(), dir, LevelQuick, "tester")
+	if v.Pass || v.ExitCode != 1 {
+		t.Fatalf("expected fail for invalid gate.toml, got %+v", v)
+	}
+	if len(v.Gates) != 1 || v.Gates[0].Name != "config" {
+		t.Fatalf("expected single config gate, got %+v", v.Gates)
+	}
+}
+
+func TestApplyReviewPolicy(t *testing.T) {
+	cfg := config.Default()
+	cfg.Required = []string{"truthsayer"}
+	cfg.UBS.ReviewWarnings = 2
+
+	skipped := verdict.GateResult{Name: "truthsayer", Pass: true, Skipped: true}
+	applyReviewPolicy(&skipped, "truthsayer", cfg)
+	if !skipped.Review || skipped.ReviewReason != "required gate skipped" {
+		t.Errorf("expected required skip to need review, got %+v", skipped)
+	}
+
+	optional := verdict.GateResult{Name: "ubs", Pass: true, Skipped: true}
+	applyReviewPolicy(&optional, "ubs", cfg)
+	if optional.Review {
+		t.Errorf("optional skipped gate should not need review")
+	}
+
+	noisy := verdict.GateResult{Name: "ubs", Pass: true, Findings: &verdict.Findings{Warnings: 3}}
+	applyReviewPolicy(&noisy, "ubs", cfg)
+	if !noisy.Review {
+		t.Errorf("expected warnings above threshold to need review")
+	}
+
+	quiet := verdict.GateResult{Name: "ubs", Pass: true, Findings: &verdict.Findings{Warnings: 2}}
+	applyReviewPolicy(&quiet, "ubs", cfg)
+	if quiet.Review {
+		t.Errorf("warnings at threshold should not need review")
+	}
+}
+
+func TestRun_RequiredSkippedGateIsReview(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\nrequired = [\"risk\"]\n\n[levels]\nquick = [\"risk\"]\n"), 0644)
+
+	v := Run(context.Background(), dir, LevelQuick, "tester")
+	if v.ExitCode != verdict.ExitReview || !v.Pass {
+		t.Fatalf("expected review verdict, got %+v", v)
+	}
+	if len(v.ReviewReasons) != 1 || v.ReviewReasons[0] != "risk: required gate skipped" {
+		t.Fatalf("unexpected review reasons: %v", v.ReviewReasons)
+	}
+}
+
+func TestRun_CustomGates(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "db"), 0o755)
+	os.WriteFile(filepath.Join(dir, "db", "001.sql"), []byte("drop table users;\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+
+[levels]
+quick = []
+standard = []
+
+[[custom]]
+name = "migrations"
+command = ["sh", "-c", "test -e 001.sql && echo '[{\"file\":\"db/001.sql\",\"line\":1,\"rule\":\"M1\",\"message\":\"drops a table\"}]'"]
+dir = "db"
+parser = "json"
+
+[[custom]]
+name = "compat"
+command = ["sh", "-c", "echo '1..1'; echo 'not ok 1 - removed endpoint'"]
+levels = ["standard"]
+required = false
+parser = "tap"
+
+[[custom]]
+name = "license"
+command = ["gate-test-no-such-tool"]
+levels = ["standard"]
+`), 0o644)
+
+	quick := Run(context.Background(), dir, LevelQuick, "tester")
+	if len(quick.Gates) != 1 || quick.Gates[0].Name != "migrations" {
+		t.Fatalf("expected only migrations at quick, got %+v", quick.Gates)
+	}
+	mig := quick.Gates[0]
+	if quick.Pass || mig.Pass || len(mig.Issues) != 1 || mig.Issues[0].Rule != "M1" {
+		t.Fatalf("expected migrations to fail with its finding, got %+v", mig)
+	}
+
+	v := Run(context.Background(), dir, LevelStandard, "tester")
+	if len(v.Gates) != 3 {
+		t.Fatalf("expected three custom gates, got %+v", v.Gates)
+	}
+	compat, license := v.Gates[1], v.Gates[2]
+	if !compat.Pass || !compat.Review || compat.ReviewReason != "optional gate failed" || len(compat.Tests) != 1 {
+		t.Fatalf("expected optional failure in review, got %+v", compat)
+	}
+	if !license.Skipped || !license.Review || license.ReviewReason != "required gate skipped" {
+		t.Fatalf("expected missing required tool in review, got %+v", license)
+	}
+}
+
+func TestRun_Plugins(t *testing.T) {
+	dir, first := snapshotRepo(t)
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	reqFile := filepath.Join(t.TempDir(), "request.json")
+	os.WriteFile(filepath.Join(bin, "gate-plugin-apicompat"), []byte("#!/bin/sh\ncat > "+reqFile+"\necho '{\"protocol\":1,\"pass\":true,\"output\":\"compatible\"}'\n"), 0o755)
+	os.WriteFile(filepath.Join(bin, "gate-plugin-flaky"), []byte("#!/bin/sh\necho 'oops'\n"), 0o755)
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+
+[levels]
+quick = []
+
+[[plugin]]
+name = "apicompat"
+
+[[plugin]]
+name = "flaky"
+required = false
+
+[[plugin]]
+name = "missing"
+`), 0o644)
+
+	v := Run(context.Background(), dir, LevelQuick, "tester")
+	if len(v.Gates) != 3 {
+		t.Fatalf("expected three plugin gates, got %+v", v.Gates)
+	}
+	api, flaky, missing := v.Gates[0], v.Gates[1], v.Gates[2]
+	if !api.Pass || api.Output != "compatible" {
+		t.Fatalf("unexpected apicompat result %+v", api)
+	}
+	if !flaky.Pass || flaky.ReviewReason != "optional gate failed" || !strings.Contains(flaky.Output, "invalid response") {
+		t.Fatalf("expected optional plugin failure in review, got %+v", flaky)
+	}
+	if !missing.Skipped || missing.ReviewReason != "required gate skipped" {
+		t.Fatalf("expected missing required plugin in review, got %+v", missing)
+	}
+	if v.ExitCode != verdict.ExitReview {
+		t.Fatalf("exit code = %d, want review", v.ExitCode)
+	}
+
+	data, _ := os.ReadFile(reqFile)
+	var req map[string]any
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("request is not JSON: %s", data)
+	}
+	if req["protocol"] != float64(1) || req["level"] != LevelQuick || req["head"] != first || req["base"] != first || req["repo"] == "" {
+		t.Fatalf("unexpected request %s", data)
+	}
+}
+
+func TestRun_DiscoversPluginsOnPath(t *testing.T) {
+	dir, _ := snapshotRepo(t)
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	os.WriteFile(filepath.Join(bin, "gate-plugin-sbom"), []byte("#!/bin/sh\necho '{\"protocol\":1,\"pass\":false,\"output\":\"unlicensed dep\"}'\n"), 0o755)
+	os.WriteFile(filepath.Join(bin, "gate-plugin-apicompat"), []byte("#!/bin/sh\nexit 1\n"), 0o755)
+	os.WriteFile(filepath.Join(bin, "gate-plugin-license"), []byte("#!/bin/sh\necho '{\"protocol\":1,\"pass\":false,\"output\":\"GPL\"}'\n"), 0o755)
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+
+[levels]
+quick = []
+
+[[plugin]]
+name = "apicompat"
+enabled = false
+
+[[plugin]]
+name = "license"
+`), 0o644)
+
+	v := Run(context.Background(), dir, LevelQuick, "tester")
+	if len(v.Gates) != 2 || v.Gates[0].Name != "license" || v.Gates[1].Name != "sbom" {
+		t.Fatalf("expected the declared plugin then the discovered one, without the disabled one, got %+v", v.Gates)
+	}
+	if v.Gates[0].Pass {
+		t.Fatalf("expected the required plugin to fail, got %+v", v.Gates[0])
+	}
+	if sbom := v.Gates[1]; !sbom.Pass || sbom.ReviewReason != "optional gate failed" {
+		t.Fatalf("expected a discovered plugin to be optional, got %+v", sbom)
+	}
+}
+
+func TestRunSteps_KeepsOrderAndRespectsLimit(t *testing.T) {
+	var inFlight, peak int32
+	var steps []step
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("g%d", i)
+		delay := time.Duration(6-i) * 5 * time.Millisecond
+		steps = append(steps, step{name: name, run: func(ctx context.Context) verdict.GateResult {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(delay)
+			atomic.AddInt32(&inFlight, -1)
+			return verdict.GateResult{Name: name, Pass: true}
+		}})
+	}
+
+	results := runSteps(context.Background(), steps, 2, false)
+
+	for i, r := range results {
+		if want := fmt.Sprintf("g%d", i); r.Name != want {
+			t.Fatalf("results[%d] = %s, want %s", i, r.Name, want)
+		}
+	}
+	if peak > 2 {
+		t.Fatalf("peak concurrency %d exceeds limit 2", peak)
+	}
+	if peak < 2 {
+		t.Fatalf("expected steps to overlap, peak concurrency %d", peak)
+	}
+}
+
+func TestRun_ReportsTotalDuration(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\nconcurrency = 1\n\n[levels]\nquick = [\"tests\"]\n\n[tests]\ncommand = [\"sleep\", \"0.05\"]\n"), 0644)
+
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{})
+	if !v.Pass {
+		t.Fatalf("expected pass, got %+v", v)
+	}
+	if v.DurationMs < 50 || v.DurationMs < v.Gates[0].DurationMs {
+		t.Fatalf("total duration %dms should cover gate duration %dms", v.DurationMs, v.Gates[0].DurationMs)
+	}
+}
+
+func TestRunSteps_FailFastCancelsRemaining(t *testing.T) {
+	steps := []step{
+		{name: "tests", label: "tests", run: func(ctx context.Context) verdict.GateResult {
+			return verdict.GateResult{Name: "tests", Pass: false}
+		}},
+		{name: "lint", label: "lint:go vet", run: func(ctx context.Context) verdict.GateResult {
+			t.Error("lint should not start after fail-fast tripped")
+			return verdict.GateResult{Name: "lint:go vet", Pass: true}
+		}},
+	}
+
+	results := runSteps(context.Background(), steps, 1, true)
+
+	if results[0].Cancelled || results[0].Pass {
+		t.Fatalf("trigger should stay a plain failure, got %+v", results[0])
+	}
+	if !results[1].Cancelled || results[1].Name != "lint:go vet" {
+		t.Fatalf("expected lint cancelled, got %+v", results[1])
+	}
+	if !strings.Contains(results[1].Output, "after tests failed") {
+		t.Fatalf("unexpected cancel reason: %q", results[1].Output)
+	}
+}
+
+func TestRunSteps_FailFastCancelsRunningGate(t *testing.T) {
+	steps := []step{
+		{name: "ubs", label: "ubs", run: func(ctx context.Context) verdict.GateResult {
+			select {
+			case <-ctx.Done():
+				return verdict.GateResult{Name: "ubs", Pass: false, Output: "killed"}
+			case <-time.After(5 * time.Second):
+				return verdict.GateResult{Name: "ubs", Pass: true}
+			}
+		}},
+		{name: "tests", label: "tests", run: func(ctx context.Context) verdict.GateResult {
+			return verdict.GateResult{Name: "tests", Pass: false}
+		}},
+	}
+
+	start := time.Now()
+	results := runSteps(context.Background(), steps, 2, true)
+
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("fail-fast did not cancel the running gate")
+	}
+	if !results[0].Cancelled {
+		t.Fatalf("expected running ubs cancelled, got %+v", results[0])
+	}
+	if results[1].Cancelled || results[1].Pass {
+		t.Fatalf("expected tests failure kept, got %+v", results[1])
+	}
+}
+
+func TestFailFaster_KeepsFailuresThatWereNotInterrupted(t *testing.T) {
+	cancelled := false
+	ff := &failFaster{enabled: true, cancel: func() { cancelled = true }}
+
+	trigger := verdict.GateResult{Name: "tests", Pass: false}
+	ff.finish(&trigger, false, false)
+	if !cancelled || ff.tripped() != "tests" || trigger.Cancelled {
+		t.Fatalf("expected tests to trip fail-fast, got %+v", trigger)
+	}
+
+	// Finished failing before the cancellation reached it.
+	done := verdict.GateResult{Name: "lint:go vet", Pass: false, Output: "vet: unreachable code"}
+	ff.finish(&done, false, false)
+	if done.Cancelled || done.Output != "vet: unreachable code" {
+		t.Fatalf("expected the real failure kept, got %+v", done)
+	}
+
+	killed := verdict.GateResult{Name: "ubs", Pass: false, Output: "signal: killed"}
+	ff.finish(&killed, false, true)
+	if !killed.Cancelled || !strings.Contains(killed.Output, "after tests failed") {
+		t.Fatalf("expected the interrupted gate cancelled, got %+v", killed)
+	}
+}
+
+func TestRunSteps_WithoutFailFastRunsEverything(t *testing.T) {
+	ran := false
+	steps := []step{
+		{name: "tests", label: "tests", run: func(ctx context.Context) verdict.GateResult {
+			return verdict.GateResult{Name: "tests", Pass: false}
+		}},
+		{name: "ubs", label: "ubs", run: func(ctx context.Context) verdict.GateResult {
+			ran = true
+			return verdict.GateResult{Name: "ubs", Pass: true}
+		}},
+	}
+
+	results := runSteps(context.Background(), steps, 1, false)
+
+	if !ran || results[1].Cancelled {
+		t.Fatalf("expected ubs to run without fail-fast, got %+v", results[1])
+	}
+}
+
+func TestRun_FailFastKeepsPartialVerdict(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+concurrency = 2
+
+[levels]
+quick = ["tests", "lint"]
+
+[tests]
+command = ["sh", "-c", "sleep 0.1; exit 1"]
+
+[[lint.add]]
+name = "slow"
+command = ["sleep", "10"]
+`), 0644)
+
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{FailFast: true})
+
+	if v.ExitCode != verdict.ExitFail || len(v.Gates) != 2 {
+		t.Fatalf("expected failing verdict with 2 gates, got %+v", v)
+	}
+	if v.Gates[0].Name != "tests" || v.Gates[0].Cancelled {
+		t.Fatalf("expected tests failure first, got %+v", v.Gates[0])
+	}
+	if v.Gates[1].Name != "lint:slow" || !v.Gates[1].Cancelled {
+		t.Fatalf("expected lint:slow cancelled, got %+v", v.Gates[1])
+	}
+	if v.DurationMs > 5000 {
+		t.Fatalf("fail-fast run took %dms", v.DurationMs)
+	}
+}
+
+func TestRun_FailFastIgnoresOptionalFailures(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+concurrency = 2
+
+[levels]
+quick = ["tests"]
+
+[tests]
+command = ["sh", "-c", "sleep 0.5"]
+
+[[custom]]
+name = "advisory"
+command = ["false"]
+required = false
+`), 0644)
+
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{FailFast: true})
+
+	if v.ExitCode != verdict.ExitReview || len(v.Gates) != 2 {
+		t.Fatalf("expected review verdict with 2 gates, got %+v", v)
+	}
+	if tests := v.Gates[0]; !tests.Pass || tests.Cancelled {
+		t.Fatalf("optional failure cancelled the required gate: %+v", tests)
+	}
+	if adv := v.Gates[1]; !adv.Pass || adv.ReviewReason != "optional gate failed" {
+		t.Fatalf("expected optional failure in review, got %+v", adv)
+	}
+}
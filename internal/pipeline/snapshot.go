@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitTimeout bounds each git call the pipeline makes itself, such as setting
+// up or removing a snapshot.
+const gitTimeout = 2 * time.Minute
+
+// snapshot is a temporary git worktree holding a pristine copy of a repo.
+type snapshot struct {
+	repo string // the repo the worktree belongs to
+	tmp  string // temp dir holding the worktree
+	dir  string // the worktree
+}
+
+// newSnapshot checks out rev, or the staged index when rev is empty, into a
+// temporary worktree of the repo at absPath. Untracked files, build
+// artifacts, and unstaged edits stay behind. The worktree is named after the
+// repo so tools that look at the directory name see the same one.
+func newSnapshot(ctx context.Context, absPath, rev string) (*snapshot, error) {
+	commit, tree := rev, ""
+	if rev == "" {
+		// The staged index becomes the worktree's index on top of HEAD, so
+		// diff-aware gates still see staged changes as the change.
+		commit = "HEAD"
+		var err error
+		if tree, err = runGit(ctx, absPath, "write-tree"); err != nil {
+			return nil, err
+		}
+	}
+	sha, err := runGit(ctx, absPath, "rev-parse", "--verify", "-q", commit+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("cannot snapshot %s: not a commit", commit)
+	}
+
+	tmp, err := os.MkdirTemp("", "gate-snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare temp dir: %v", err)
+	}
+	s := &snapshot{repo: absPath, tmp: tmp, dir: filepath.Join(tmp, filepath.Base(absPath))}
+	args := []string{"worktree", "add", "--detach", "--quiet"}
+	if tree != "" {
+		args = append(args, "--no-checkout")
+	}
+	if _, err := runGit(ctx, absPath, append(args, s.dir, sha)...); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if tree != "" {
+		if _, err := runGit(ctx, s.dir, "read-tree", "--reset", "-u", tree); err != nil {
+			s.cleanup()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// cleanup removes the worktree and its registration in the repo. It runs
+// even when the check was cancelled.
+func (s *snapshot) cleanup() {
+	runGit(context.Background(), s.repo, "worktree", "remove", "--force", s.dir)
+	os.RemoveAll(s.tmp)
+	runGit(context.Background(), s.repo, "worktree", "prune")
+}
+
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s", strings.Join(args[:min(2, len(args))], " "), trimGitOutput(string(out), err))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func trimGitOutput(out string, err error) string {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return err.Error()
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// snapshotRepo commits a.txt ("committed") and a quick-level tests gate
+// that prints it and fails if u.txt exists.
+func snapshotRepo(t *testing.T) (dir, first string) {
+	t.Helper()
+	dir = t.TempDir()
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte(`[gate]
+schema_version = 1
+
+[levels]
+quick = ["tests"]
+
+[tests]
+command = ["sh", "-c", "cat a.txt; test ! -e u.txt"]
+`), 0o644)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("committed\n"), 0o644)
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.email", "gate-tests@example.com")
+	git(t, dir, "config", "user.name", "gate-tests")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "init")
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, strings.TrimSpace(string(out))
+}
+
+func worktreeCount(t *testing.T, dir string) int {
+	t.Helper()
+	out, err := exec.Command("git", "-C", dir, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Count(string(out), "worktree ")
+}
+
+func TestRun_SnapshotUsesStagedIndex(t *testing.T) {
+	dir, _ := snapshotRepo(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("staged\n"), 0o644)
+	git(t, dir, "add", "a.txt")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("unstaged\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "u.txt"), []byte("untracked\n"), 0o644)
+
+	live := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{})
+	if live.Gates[0].Pass {
+		t.Fatalf("live run should see the untracked file, got %+v", live.Gates[0])
+	}
+
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Snapshot: true})
+	if !v.Pass || strings.TrimSpace(v.Gates[0].Output) != "staged" {
+		t.Fatalf("expected the staged content without untracked files, got %+v", v.Gates)
+	}
+	if v.Repo != filepath.Base(dir) {
+		t.Fatalf("repo = %q, want %q", v.Repo, filepath.Base(dir))
+	}
+	if n := worktreeCount(t, dir); n != 1 {
+		t.Fatalf("snapshot worktree left behind: %d worktrees", n)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(data) != "unstaged\n" {
+		t.Fatalf("live tree changed: %q", data)
+	}
+}
+
+func TestRun_SnapshotChecksOutRev(t *testing.T) {
+	dir, first := snapshotRepo(t)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("second\n"), 0o644)
+	git(t, dir, "commit", "-q", "-am", "second")
+
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Snapshot: true, Rev: first})
+	if !v.Pass || strings.TrimSpace(v.Gates[0].Output) != "committed" {
+		t.Fatalf("expected the first commit's content, got %+v", v.Gates)
+	}
+	if n := worktreeCount(t, dir); n != 1 {
+		t.Fatalf("snapshot worktree left behind: %d worktrees", n)
+	}
+}
+
+func TestRun_SnapshotBadRev(t *testing.T) {
+	dir, _ := snapshotRepo(t)
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Snapshot: true, Rev: "no-such-ref"})
+	if v.Pass || len(v.Gates) != 1 || v.Gates[0].Name != "snapshot" {
+		t.Fatalf("expected a failing snapshot gate, got %+v", v)
+	}
+	if !strings.Contains(v.Gates[0].Output, "no-such-ref") {
+		t.Fatalf("unexpected output %q", v.Gates[0].Output)
+	}
+}
+
+func TestRun_RevRecordsHeadAndBase(t *testing.T) {
+	dir, first := snapshotRepo(t)
+	revParse := func() string {
+		out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("second\n"), 0o644)
+	git(t, dir, "commit", "-q", "-am", "second")
+	second := revParse()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("third\n"), 0o644)
+	git(t, dir, "commit", "-q", "-am", "third")
+
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Rev: second})
+	if !v.Pass || strings.TrimSpace(v.Gates[0].Output) != "second" {
+		t.Fatalf("expected the second commit's content, got %+v", v.Gates)
+	}
+	if v.Head != second || v.Base != first {
+		t.Fatalf("head/base = %s/%s, want %s/%s", v.Head, v.Base, second, first)
+	}
+	if n := worktreeCount(t, dir); n != 1 {
+		t.Fatalf("rev worktree left behind: %d worktrees", n)
+	}
+
+	v = RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Base: second})
+	if v.Head != revParse() || v.Base != second {
+		t.Fatalf("head/base = %s/%s, want HEAD/%s", v.Head, v.Base, second)
+	}
+}
+
+func TestRun_UnknownBase(t *testing.T) {
+	dir, _ := snapshotRepo(t)
+	v := RunWithOptions(context.Background(), dir, LevelQuick, "tester", Options{Base: "no-such-base"})
+	if v.Pass || len(v.Gates) != 1 || v.Gates[0].Name != "base" {
+		t.Fatalf("expected a failing base gate, got %+v", v)
+	}
+	if !strings.Contains(v.Gates[0].Output, "no-such-base") {
+		t.Fatalf("unexpected output %q", v.Gates[0].Output)
+	}
+}
+
+// ubsRepo commits a.txt on main, then on feature a second commit adding
+// line 2 to b.txt and a third touching only a.txt. The fake ubs always
+// reports a critical finding on b.txt:2, and like the real tool finds
+// nothing with --diff in a clean tree.
+func ubsRepo(t *testing.T) (dir, second string) {
+	t.Helper()
+	dir = t.TempDir()
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "ubs"), []byte(`#!/bin/sh
+if [ "$1" = "--diff" ]; then echo '{"totals":{"files":0}}'; exit 0; fi
+echo '{"findings":[{"severity":"critical","file":"b.txt","line":2,"message":"bug"}],"totals":{"critical":1,"files":1}}'
+exit 1
+`), 0o755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	os.WriteFile(filepath.Join(dir, "gate.toml"), []byte("[gate]\nschema_version = 1\n\n[levels]\nstandard = [\"ubs\"]\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\n"), 0o644)
+	git(t, dir, "init", "-q", "-b", "main")
+	git(t, dir, "config", "user.email", "gate-tests@example.com")
+	git(t, dir, "config", "user.name", "gate-tests")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "init")
+	git(t, dir, "checkout", "-q", "-b", "feature")
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("b\nbug\n"), 0o644)
+	git(t, dir, "commit", "-q", "-am", "second")
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\nmore\n"), 0o644)
+	git(t, dir, "commit", "-q", "-am", "third")
+	return dir, strings.TrimSpace(string(out))
+}
+
+func TestRun_RevScopesUBSToItsRange(t *testing.T) {
+	dir, second := ubsRepo(t)
+
+	v := RunWithOptions(context.Background(), dir, LevelStandard, "tester", Options{Rev: second})
+	if v.Pass || v.Gates[0].Findings == nil || v.Gates[0].Findings.New != 1 {
+		t.Fatalf("expected the finding added by --rev to fail ubs, got %+v", v.Gates)
+	}
+
+	v = RunWithOptions(context.Background(), dir, LevelStandard, "tester", Options{Base: second})
+	if !v.Pass || v.Gates[0].Findings == nil || v.Gates[0].Findings.PreExisting != 1 {
+		t.Fatalf("expected the finding before --base to be pre-existing, got %+v", v.Gates)
+	}
+}
+
+func TestRun_SnapshotScansUBSAgainstBase(t *testing.T) {
+	dir, _ := ubsRepo(t)
+	v := RunWithOptions(context.Background(), dir, LevelStandard, "tester", Options{Snapshot: true})
+	if v.Pass || v.Gates[0].Findings == nil || v.Gates[0].Findings.New != 1 {
+		t.Fatalf("expected the pristine snapshot to still judge the branch's finding, got %+v", v.Gates)
+	}
+}
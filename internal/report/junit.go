@@ -0,0 +1,160 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+
+	"polis/gate/internal/verdict"
+)
+
+// JUnitSuites is the root of a JUnit XML document.
+type JUnitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []JUnitSuite `xml:"testsuite"`
+}
+
+// JUnitSuite groups test cases: the gates of a check, or the individual
+// tests run by one gate.
+type JUnitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []JUnitCase `xml:"testcase"`
+}
+
+// JUnitCase is one gate or one test.
+type JUnitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure carries the captured output of a failed gate or test.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// JUnitSkipped explains a skipped or cancelled gate.
+type JUnitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// JUnit converts a verdict into a JUnit document. The first suite has one
+// test case per gate; each gate that reports individual tests adds a suite
+// named after the gate holding those tests.
+func JUnit(v verdict.Verdict) JUnitSuites {
+	doc := JUnitSuites{Name: fmt.Sprintf("%s gate %s", v.Repo, v.Level), Time: seconds(v.DurationMs)}
+
+	gates := JUnitSuite{Name: doc.Name, Time: seconds(v.DurationMs)}
+	var nested []JUnitSuite
+	for _, g := range v.Gates {
+		gates.add(gateCase(v, g))
+		if len(g.Tests) == 0 {
+			continue
+		}
+		suite := JUnitSuite{Name: g.Name, Time: seconds(g.DurationMs)}
+		for _, tc := range g.Tests {
+			suite.add(testCase(g.Name, tc))
+		}
+		nested = append(nested, suite)
+	}
+
+	doc.Suites = append([]JUnitSuite{gates}, nested...)
+	for _, s := range doc.Suites {
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Skipped += s.Skipped
+	}
+	return doc
+}
+
+// WriteJUnit encodes the JUnit document for v to w.
+func WriteJUnit(w io.Writer, v verdict.Verdict) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(JUnit(v)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJUnitFile writes the JUnit document for v to path.
+func WriteJUnitFile(path string, v verdict.Verdict) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteJUnit(f, v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *JUnitSuite) add(c JUnitCase) {
+	s.Tests++
+	if c.Failure != nil {
+		s.Failures++
+	}
+	if c.Skipped != nil {
+		s.Skipped++
+	}
+	s.Cases = append(s.Cases, c)
+}
+
+func gateCase(v verdict.Verdict, g verdict.GateResult) JUnitCase {
+	c := JUnitCase{Name: g.Name, ClassName: "gate." + v.Level, Time: seconds(g.DurationMs)}
+	switch {
+	case g.Skipped, g.Cancelled:
+		c.Skipped = &JUnitSkipped{Message: g.Output}
+	case !g.Pass:
+		c.Failure = &JUnitFailure{Message: g.Name + " failed", Body: g.Output}
+	default:
+		c.SystemOut = g.Output
+		if g.Review {
+			c.SystemOut = "needs review: " + g.ReviewReason + "\n" + g.Output
+		}
+	}
+	return c
+}
+
+func testCase(gate string, tc verdict.TestCase) JUnitCase {
+	class := gate
+	if tc.Suite != "" {
+		class = tc.Suite
+	}
+	c := JUnitCase{Name: tc.Name, ClassName: class, Time: seconds(tc.DurationMs)}
+	switch tc.Status {
+	case verdict.TestFail:
+		c.Failure = &JUnitFailure{Message: tc.Name + " failed", Body: tc.Output}
+	case verdict.TestSkip:
+		c.Skipped = &JUnitSkipped{Message: tc.Output}
+	default:
+		c.SystemOut = tc.Output
+		if tc.Flaky {
+			c.SystemOut = fmt.Sprintf("flaky: passed on attempt %d", tc.Attempts)
+		}
+	}
+	return c
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
@@ -0,0 +1,87 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+func TestJUnit_GateCasesAndNestedTests(t *testing.T) {
+	v := verdict.Verdict{
+		Repo:       "relay",
+		Level:      "quick",
+		DurationMs: 2500,
+		Gates: []verdict.GateResult{
+			{Name: "tests", Pass: false, DurationMs: 2000, Output: "FAIL relay/store", Tests: []verdict.TestCase{
+				{Name: "TestOpen", Suite: "relay/store", Status: verdict.TestPass, DurationMs: 10},
+				{Name: "TestClose", Suite: "relay/store", Status: verdict.TestFail, DurationMs: 20, Output: "store_test.go:9: closed twice"},
+				{Name: "TestSlow", Status: verdict.TestSkip, Output: "short mode"},
+			}},
+			{Name: "lint:go vet", Pass: true, DurationMs: 300},
+			{Name: "truthsayer", Pass: true, Skipped: true, Output: "truthsayer not available (skipped)"},
+			{Name: "risk", Pass: true, Review: true, ReviewReason: "risk score 50", Output: "score=50"},
+		},
+	}
+
+	doc := JUnit(v)
+
+	if doc.Tests != 7 || doc.Failures != 2 || doc.Skipped != 2 || doc.Time != "2.500" {
+		t.Fatalf("unexpected totals: tests=%d failures=%d skipped=%d time=%s", doc.Tests, doc.Failures, doc.Skipped, doc.Time)
+	}
+	if len(doc.Suites) != 2 {
+		t.Fatalf("expected gate suite plus tests suite, got %d", len(doc.Suites))
+	}
+
+	gates := doc.Suites[0]
+	if gates.Name != "relay gate quick" || len(gates.Cases) != 4 {
+		t.Fatalf("unexpected gate suite: %+v", gates)
+	}
+	if c := gates.Cases[0]; c.Failure == nil || c.Failure.Body != "FAIL relay/store" || c.Time != "2.000" {
+		t.Errorf("expected failing tests case with output, got %+v", c)
+	}
+	if c := gates.Cases[2]; c.Skipped == nil || !strings.Contains(c.Skipped.Message, "not available") {
+		t.Errorf("expected skipped truthsayer, got %+v", c)
+	}
+	if c := gates.Cases[3]; c.Failure != nil || !strings.Contains(c.SystemOut, "needs review: risk score 50") {
+		t.Errorf("expected review note on passing risk, got %+v", c)
+	}
+
+	tests := doc.Suites[1]
+	if tests.Name != "tests" || tests.Tests != 3 || tests.Failures != 1 || tests.Skipped != 1 {
+		t.Fatalf("unexpected tests suite: %+v", tests)
+	}
+	if c := tests.Cases[1]; c.ClassName != "relay/store" || c.Failure == nil || c.Failure.Body != "store_test.go:9: closed twice" {
+		t.Errorf("unexpected failed test case: %+v", c)
+	}
+	if c := tests.Cases[2]; c.ClassName != "tests" || c.Skipped == nil {
+		t.Errorf("expected skipped test classed under its gate, got %+v", c)
+	}
+}
+
+func TestWriteJUnitFile_ValidXML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gate.xml")
+	v := verdict.Verdict{Repo: "r", Level: "quick", Gates: []verdict.GateResult{{Name: "tests", Pass: false, Output: "<boom> & more"}}}
+	if err := WriteJUnitFile(path, v); err != nil {
+		t.Fatalf("WriteJUnitFile: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("<?xml")) {
+		t.Fatalf("expected XML header, got %q", data[:20])
+	}
+	var doc JUnitSuites
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if doc.Suites[0].Cases[0].Failure.Body != "<boom> & more" {
+		t.Fatalf("expected escaped output to round-trip, got %+v", doc.Suites[0].Cases[0])
+	}
+}
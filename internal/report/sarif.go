@@ -0,0 +1,264 @@
+// Package report renders gate check verdicts in formats other tools ingest.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"polis/gate/internal/verdict"
+)
+
+// SARIF identifiers for the 2.1.0 log format.
+const (
+	SARIFVersion = "2.1.0"
+	SARIFSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// maxMessageLines caps how much gate output goes into a single result.
+const maxMessageLines = 40
+
+// SARIFLog is the root of a SARIF document.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is the output of one tool; gate emits one run per gate result.
+type SARIFRun struct {
+	Tool        SARIFTool         `json:"tool"`
+	Invocations []SARIFInvocation `json:"invocations"`
+	Results     []SARIFResult     `json:"results"`
+	Properties  map[string]any    `json:"properties,omitempty"`
+}
+
+// SARIFTool names the gate that produced a run.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver describes the tool and the rules its results refer to.
+type SARIFDriver struct {
+	Name  string      `json:"name"`
+	Rules []SARIFRule `json:"rules,omitempty"`
+}
+
+// SARIFRule is a rule a result can reference by ID.
+type SARIFRule struct {
+	ID string `json:"id"`
+}
+
+// SARIFInvocation records whether the gate ran to completion.
+type SARIFInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []SARIFNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+// SARIFNotification explains a skipped or cancelled gate.
+type SARIFNotification struct {
+	Level   string       `json:"level"`
+	Message SARIFMessage `json:"message"`
+}
+
+// SARIFResult is one finding or failure.
+type SARIFResult struct {
+	RuleID              string             `json:"ruleId"`
+	Level               string             `json:"level"`
+	Message             SARIFMessage       `json:"message"`
+	Locations           []SARIFLocation    `json:"locations,omitempty"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints,omitempty"`
+	BaselineState       string             `json:"baselineState,omitempty"`
+	Suppressions        []SARIFSuppression `json:"suppressions,omitempty"`
+	Properties          map[string]any     `json:"properties,omitempty"`
+}
+
+// SARIFMessage is plain-text message content.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFLocation points a result at a file region.
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation is a file and optional region.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation is a repo-relative file URI.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRegion is the start of the flagged code.
+type SARIFRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// SARIFSuppression marks a result accepted outside the scan, e.g. by the
+// findings baseline.
+type SARIFSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// SARIF converts a verdict into a SARIF log with one run per gate. Issues
+// become located results; a failing gate with no issues becomes one result
+// per failing test, or a single result carrying its output.
+func SARIF(v verdict.Verdict) SARIFLog {
+	log := SARIFLog{Schema: SARIFSchema, Version: SARIFVersion, Runs: []SARIFRun{}}
+	for _, g := range v.Gates {
+		log.Runs = append(log.Runs, sarifRun(v, g))
+	}
+	return log
+}
+
+// WriteSARIF encodes the SARIF log for v to w.
+func WriteSARIF(w io.Writer, v verdict.Verdict) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(SARIF(v))
+}
+
+func sarifRun(v verdict.Verdict, g verdict.GateResult) SARIFRun {
+	run := SARIFRun{
+		Tool:        SARIFTool{Driver: SARIFDriver{Name: g.Name}},
+		Invocations: []SARIFInvocation{{ExecutionSuccessful: !g.Skipped && !g.Cancelled}},
+		Results:     []SARIFResult{},
+		Properties: map[string]any{
+			"repo":       v.Repo,
+			"level":      v.Level,
+			"pass":       g.Pass,
+			"durationMs": g.DurationMs,
+		},
+	}
+	switch {
+	case g.Skipped:
+		run.Properties["skipped"] = true
+		run.Invocations[0].ToolExecutionNotifications = []SARIFNotification{{Level: "note", Message: SARIFMessage{Text: g.Output}}}
+		return run
+	case g.Cancelled:
+		run.Properties["cancelled"] = true
+		run.Invocations[0].ToolExecutionNotifications = []SARIFNotification{{Level: "warning", Message: SARIFMessage{Text: g.Output}}}
+		return run
+	}
+	if g.Review {
+		run.Properties["review"] = g.ReviewReason
+	}
+
+	rules := map[string]bool{}
+	for _, is := range g.Issues {
+		r := issueResult(g.Name, is)
+		rules[r.RuleID] = true
+		run.Results = append(run.Results, r)
+	}
+	if len(run.Results) == 0 && !g.Pass {
+		for _, tc := range g.Tests {
+			if tc.Status != verdict.TestFail {
+				continue
+			}
+			id := g.Name + "/failed"
+			rules[id] = true
+			run.Results = append(run.Results, SARIFResult{
+				RuleID:     id,
+				Level:      "error",
+				Message:    SARIFMessage{Text: messageText(tc.Name+" failed", tc.Output)},
+				Properties: map[string]any{"test": tc.Name, "suite": tc.Suite},
+			})
+		}
+	}
+	if len(run.Results) == 0 && !g.Pass {
+		id := g.Name + "/failed"
+		rules[id] = true
+		run.Results = append(run.Results, SARIFResult{
+			RuleID:  id,
+			Level:   "error",
+			Message: SARIFMessage{Text: messageText(g.Name+" failed", g.Output)},
+		})
+	}
+
+	for id := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, SARIFRule{ID: id})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+	return run
+}
+
+func issueResult(gate string, is verdict.Issue) SARIFResult {
+	id := is.Rule
+	if id == "" {
+		id = is.Category
+	}
+	if id == "" {
+		id = gate
+	}
+	text := is.Message
+	if text == "" {
+		text = id
+	}
+
+	r := SARIFResult{
+		RuleID:  id,
+		Level:   sarifLevel(is.Severity),
+		Message: SARIFMessage{Text: text},
+	}
+	if is.File != "" {
+		loc := SARIFLocation{PhysicalLocation: SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: is.File}}}
+		if is.Line > 0 {
+			loc.PhysicalLocation.Region = &SARIFRegion{StartLine: is.Line, StartColumn: is.Column}
+		}
+		r.Locations = []SARIFLocation{loc}
+	}
+	if is.Fingerprint != "" {
+		r.PartialFingerprints = map[string]string{"gate/v1": is.Fingerprint}
+	}
+	if is.PreExisting {
+		r.BaselineState = "unchanged"
+	}
+	if is.Baselined {
+		r.Suppressions = []SARIFSuppression{{Kind: "external", Justification: "accepted in .gate-baseline.json"}}
+	}
+	props := map[string]any{}
+	for k, val := range map[string]string{"scanner": is.Scanner, "language": is.Language, "category": is.Category} {
+		if val != "" {
+			props[k] = val
+		}
+	}
+	if len(props) > 0 {
+		r.Properties = props
+	}
+	return r
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	case "info":
+		return "note"
+	}
+	return "warning"
+}
+
+// messageText joins a headline with the tail of a gate's output.
+func messageText(headline, output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return headline
+	}
+	lines := strings.Split(output, "\n")
+	if len(lines) > maxMessageLines {
+		lines = lines[len(lines)-maxMessageLines:]
+	}
+	return headline + "\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"polis/gate/internal/verdict"
+)
+
+func TestSARIF_OneRunPerGate(t *testing.T) {
+	v := verdict.Verdict{
+		Repo:  "relay",
+		Level: "standard",
+		Gates: []verdict.GateResult{
+			{Name: "tests", Pass: false, Output: "--- FAIL: TestX\nFAIL"},
+			{Name: "lint:go vet", Pass: true},
+			{Name: "truthsayer", Pass: false, Issues: []verdict.Issue{
+				{Rule: "trace-gaps.no-stderr", Severity: "error", File: "cmd/main.go", Line: 12, Column: 3, Message: "no stderr", Fingerprint: "abc"},
+				{Rule: "magic", Severity: "info", File: "old.go", Line: 4, PreExisting: true, Baselined: true},
+			}},
+			{Name: "ubs", Pass: true, Skipped: true, Output: "ubs not available (skipped)"},
+			{Name: "risk", Pass: false, Cancelled: true, Output: "cancelled: fail-fast after tests failed"},
+		},
+	}
+
+	log := SARIF(v)
+
+	if log.Version != "2.1.0" || len(log.Runs) != 5 {
+		t.Fatalf("unexpected log: version=%s runs=%d", log.Version, len(log.Runs))
+	}
+
+	tests := log.Runs[0]
+	if tests.Tool.Driver.Name != "tests" || len(tests.Results) != 1 || tests.Results[0].RuleID != "tests/failed" {
+		t.Fatalf("expected failing tests result, got %+v", tests)
+	}
+	if !strings.Contains(tests.Results[0].Message.Text, "--- FAIL: TestX") {
+		t.Errorf("expected test output in message, got %q", tests.Results[0].Message.Text)
+	}
+
+	if len(log.Runs[1].Results) != 0 {
+		t.Errorf("passing gate should have no results, got %+v", log.Runs[1].Results)
+	}
+
+	ts := log.Runs[2]
+	if len(ts.Results) != 2 || len(ts.Tool.Driver.Rules) != 2 {
+		t.Fatalf("expected 2 truthsayer results and rules, got %+v", ts)
+	}
+	r := ts.Results[0]
+	region := r.Locations[0].PhysicalLocation.Region
+	if r.Level != "error" || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "cmd/main.go" || region.StartLine != 12 || region.StartColumn != 3 {
+		t.Errorf("unexpected located result: %+v", r)
+	}
+	if r.PartialFingerprints["gate/v1"] != "abc" {
+		t.Errorf("expected fingerprint, got %+v", r.PartialFingerprints)
+	}
+	old := ts.Results[1]
+	if old.Level != "note" || old.BaselineState != "unchanged" || len(old.Suppressions) != 1 {
+		t.Errorf("expected suppressed pre-existing note, got %+v", old)
+	}
+
+	for _, run := range log.Runs[3:] {
+		if run.Invocations[0].ExecutionSuccessful || len(run.Invocations[0].ToolExecutionNotifications) != 1 {
+			t.Errorf("expected unsuccessful invocation with note for %s, got %+v", run.Tool.Driver.Name, run.Invocations)
+		}
+	}
+}
+
+func TestWriteSARIF_ValidJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, verdict.Verdict{Gates: []verdict.GateResult{{Name: "tests", Pass: true}}}); err != nil {
+		t.Fatalf("WriteSARIF: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc["$schema"] != SARIFSchema || doc["version"] != SARIFVersion {
+		t.Fatalf("unexpected header: %v", doc)
+	}
+	runs := doc["runs"].([]any)
+	if results := runs[0].(map[string]any)["results"]; results == nil {
+		t.Fatal("results must be an array, not null")
+	}
+}
+
+func TestSARIF_FailingTests(t *testing.T) {
+	v := verdict.Verdict{Repo: "relay", Level: "quick", Gates: []verdict.GateResult{{
+		Name: "tests", Pass: false, Output: "go test: 1 passed, 2 failed",
+		Tests: []verdict.TestCase{
+			{Name: "TestOpen", Suite: "relay/store", Status: verdict.TestPass},
+			{Name: "TestClose", Suite: "relay/store", Status: verdict.TestFail, Output: "closed twice"},
+			{Name: "TestFlush", Suite: "relay/store", Status: verdict.TestFail},
+		},
+	}}}
+
+	results := SARIF(v).Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("expected one result per failing test, got %+v", results)
+	}
+	if r := results[0]; r.RuleID != "tests/failed" || r.Message.Text != "TestClose failed\nclosed twice" || r.Properties["suite"] != "relay/store" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
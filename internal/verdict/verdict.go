@@ -1,43 +1,221 @@
 package verdict
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // GateResult is the outcome of a single gate check.
 type GateResult struct {
-	Name       string    `json:"name"`
-	Pass       bool      `json:"pass"`
-	Skipped    bool      `json:"skipped,omitempty"`
-	Output     string    `json:"output,omitempty"`
-	DurationMs int64     `json:"duration_ms"`
-	Findings   *Findings `json:"findings,omitempty"`
+	Name         string          `json:"name"`
+	Pass         bool            `json:"pass"`
+	Skipped      bool            `json:"skipped,omitempty"`
+	Cancelled    bool            `json:"cancelled,omitempty"` // stopped by fail-fast before finishing
+	Review       bool            `json:"review,omitempty"`    // passed, but needs a human look
+	ReviewReason string          `json:"review_reason,omitempty"`
+	Output       string          `json:"output,omitempty"`
+	DurationMs   int64           `json:"duration_ms"`
+	Findings     *Findings       `json:"findings,omitempty"`
+	Issues       []Issue         `json:"issues,omitempty"`
+	Tests        []TestCase      `json:"tests,omitempty"`
+	Packages     []PackageResult `json:"packages,omitempty"`
+	Risk         *RiskReport     `json:"risk,omitempty"`
+	Fragility    []AreaFragility `json:"fragility,omitempty"`
+	Coverage     *CoverageReport `json:"coverage,omitempty"`
+	Cached       bool            `json:"cached,omitempty"` // reused from an earlier run of the same inputs
 }
 
-// Findings holds counts of issues by severity.
+// Findings holds counts of issues by severity. In changed-lines mode the
+// severity counts cover only findings new in the diff.
 type Findings struct {
 	Errors   int `json:"errors"`
 	Warnings int `json:"warnings"`
 	Info     int `json:"info"`
+	// New counts findings on lines touched by the diff (changed-lines mode).
+	New int `json:"new,omitempty"`
+	// PreExisting counts findings outside the diff (changed-lines mode).
+	PreExisting int `json:"pre_existing,omitempty"`
+	// Suppressed counts findings accepted by the repo baseline; they are
+	// not in the severity counts.
+	Suppressed int `json:"suppressed,omitempty"`
 }
 
-// Verdict is the final output of a gate check run.
+// Issue is one finding reported by a scanner, located well enough for a
+// reviewer or tool to annotate the code.
+type Issue struct {
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Scanner, Language, and Category attribute findings from multi-scanner
+	// tools such as ubs.
+	Scanner  string `json:"scanner,omitempty"`
+	Language string `json:"language,omitempty"`
+	Category string `json:"category,omitempty"`
+	// PreExisting marks a finding outside the diff (changed-lines mode).
+	PreExisting bool `json:"pre_existing,omitempty"`
+	// Fingerprint identifies the finding across line shifts; Baselined
+	// marks one accepted by the repo baseline.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Baselined   bool   `json:"baselined,omitempty"`
+}
+
+// Location formats the issue position as file:line:column, omitting the
+// parts that are unknown.
+func (i Issue) Location() string {
+	switch {
+	case i.File == "":
+		return ""
+	case i.Line <= 0:
+		return i.File
+	case i.Column <= 0:
+		return fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+}
+
+// Test case statuses.
+const (
+	TestPass = "pass"
+	TestFail = "fail"
+	TestSkip = "skip"
+)
+
+// TestCase is one test reported by the tests gate.
+type TestCase struct {
+	Name string `json:"name"`
+	// Suite groups the test: a Go package, test file, or class.
+	Suite      string `json:"suite,omitempty"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Output     string `json:"output,omitempty"`
+	// Attempts counts runs when failing tests were retried; Flaky marks a
+	// test that failed and then passed on retry.
+	Attempts int  `json:"attempts,omitempty"`
+	Flaky    bool `json:"flaky,omitempty"`
+}
+
+// PackageResult is one package's outcome in a go test run. Status uses
+// the test case statuses.
+type PackageResult struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// FlakyTests lists "<gate>: <suite> <test>" for every test that passed only
+// on retry.
+func FlakyTests(gates []GateResult) []string {
+	var names []string
+	for _, g := range gates {
+		for _, tc := range g.Tests {
+			if tc.Flaky {
+				names = append(names, g.Name+": "+strings.TrimSpace(tc.Suite+" "+tc.Name))
+			}
+		}
+	}
+	return names
+}
+
+// RiskReport is the structured breakdown behind the risk gate score.
+type RiskReport struct {
+	Score        int             `json:"score"`
+	Base         string          `json:"base,omitempty"`
+	Files        int             `json:"files"`
+	LinesAdded   int             `json:"lines_added"`
+	LinesDeleted int             `json:"lines_deleted"`
+	Categories   map[string]int  `json:"categories"`
+	Sensitive    []SensitivePath `json:"sensitive,omitempty"`
+	Factors      []RiskFactor    `json:"factors,omitempty"`
+}
+
+// SensitivePath is a changed file that touches a sensitive area.
+type SensitivePath struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// RiskFactor is one contribution to the risk score.
+type RiskFactor struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+	Detail string `json:"detail"`
+}
+
+// AreaFragility is the history of one directory touched by the change.
+type AreaFragility struct {
+	Area         string  `json:"area"`
+	Commits      int     `json:"commits"`
+	Fixes        int     `json:"fixes"`
+	Reverts      int     `json:"reverts"`
+	GateFailures int     `json:"gate_failures"`
+	Score        float64 `json:"score"`
+	Fragile      bool    `json:"fragile"`
+}
+
+// CoverageReport is the measurement behind the coverage gate.
+type CoverageReport struct {
+	// Source is where the numbers came from: go, cobertura, or lcov, or
+	// e.g. go+lcov when a Go profile and a report were merged.
+	Source string `json:"source"`
+	// Unit is what was counted: statements for Go, lines otherwise, and
+	// statements+lines when both were merged.
+	Unit     string            `json:"unit"`
+	Percent  float64           `json:"percent"`
+	Covered  int               `json:"covered"`
+	Total    int               `json:"total"`
+	Min      float64           `json:"min,omitempty"`
+	Changed  *ChangedCoverage  `json:"changed,omitempty"`
+	Packages []PackageCoverage `json:"packages,omitempty"`
+}
+
+// ChangedCoverage is coverage of the lines changed since the merge base.
+type ChangedCoverage struct {
+	Base    string  `json:"base,omitempty"`
+	Percent float64 `json:"percent"`
+	Covered int     `json:"covered"`
+	Total   int     `json:"total"`
+	Min     float64 `json:"min,omitempty"`
+}
+
+// PackageCoverage is the coverage of one package or directory.
+type PackageCoverage struct {
+	Name    string  `json:"name"`
+	Percent float64 `json:"percent"`
+	Covered int     `json:"covered"`
+	Total   int     `json:"total"`
+}
+
+// Verdict is the final output of a gate check run. Pass is true when no
+// gate failed; a verdict that needs review passes with ExitCode ExitReview.
 type Verdict struct {
-	Pass     bool         `json:"pass"`
-	Score    float64      `json:"score"`
-	Level    string       `json:"level"`
-	Citizen  string       `json:"citizen"`
-	Repo     string       `json:"repo"`
-	Gates    []GateResult `json:"gates"`
-	ExitCode int          `json:"exit_code"`
-	Bead     string       `json:"bead,omitempty"`
+	Pass          bool         `json:"pass"`
+	Score         float64      `json:"score"`
+	Level         string       `json:"level"`
+	Citizen       string       `json:"citizen"`
+	Repo          string       `json:"repo"`
+	Head          string       `json:"head,omitempty"` // commit checked
+	Base          string       `json:"base,omitempty"` // merge base diff-aware gates compared against
+	Gates         []GateResult `json:"gates"`
+	Areas         []string     `json:"areas,omitempty"`
+	ReviewReasons []string     `json:"review_reasons,omitempty"`
+	ExitCode      int          `json:"exit_code"`
+	DurationMs    int64        `json:"duration_ms"`      // wall clock for the whole run
+	Cached        bool         `json:"cached,omitempty"` // every gate came from the cache
+	Bead          string       `json:"bead,omitempty"`
 }
 
 // ComputeScore calculates a quality score from gate results.
-// The score is the ratio of passing gates to applicable (non-skipped) gates.
+// The score is the ratio of passing gates to applicable (non-skipped,
+// non-cancelled) gates.
 // If all gates are skipped, the score is 1.0 (nothing to fail on).
 func ComputeScore(gates []GateResult) float64 {
 	var applicable, passed int
 	for _, g := range gates {
-		if g.Skipped {
+		if g.Skipped || g.Cancelled {
 			continue
 		}
 		applicable++
@@ -51,6 +229,61 @@ func ComputeScore(gates []GateResult) float64 {
 	return float64(passed) / float64(applicable)
 }
 
+// ExitCodeFor aggregates gate results: any failing gate means ExitFail,
+// otherwise any gate flagged for review means ExitReview.
+func ExitCodeFor(gates []GateResult) int {
+	code := ExitPass
+	for _, g := range gates {
+		if !g.Pass {
+			return ExitFail
+		}
+		if g.Review {
+			code = ExitReview
+		}
+	}
+	return code
+}
+
+// ReviewReasons lists "<gate>: <reason>" for every gate flagged for review.
+func ReviewReasons(gates []GateResult) []string {
+	var reasons []string
+	for _, g := range gates {
+		if !g.Review {
+			continue
+		}
+		reason := g.ReviewReason
+		if reason == "" {
+			reason = "needs review"
+		}
+		reasons = append(reasons, g.Name+": "+reason)
+	}
+	return reasons
+}
+
+// MarkReview flags a passing gate for review. Reasons accumulate; a failing
+// gate is left alone since failure already outranks review.
+func (g *GateResult) MarkReview(reason string) {
+	if !g.Pass {
+		return
+	}
+	g.Review = true
+	if g.ReviewReason == "" {
+		g.ReviewReason = reason
+	} else {
+		g.ReviewReason += "; " + reason
+	}
+}
+
+// MarkCancelled records that fail-fast stopped the gate before it finished.
+// A cancelled gate neither passes nor counts toward the score.
+func (g *GateResult) MarkCancelled(reason string) {
+	g.Cancelled = true
+	g.Pass = false
+	g.Review = false
+	g.ReviewReason = ""
+	g.Output = reason
+}
+
 // ExitPass means all gates passed.
 const ExitPass = 0
 
@@ -116,3 +116,107 @@ func TestComputeScore_OneOfThreeFails(t *testing.T) {
 		t.Errorf("expected ~%.4f, got %f", want, score)
 	}
 }
+
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		gates []GateResult
+		want  int
+	}{
+		{"empty", nil, ExitPass},
+		{"all pass", []GateResult{{Pass: true}, {Pass: true}}, ExitPass},
+		{"review", []GateResult{{Pass: true}, {Pass: true, Review: true}}, ExitReview},
+		{"fail beats review", []GateResult{{Pass: true, Review: true}, {Pass: false}}, ExitFail},
+	}
+	for _, tt := range tests {
+		if got := ExitCodeFor(tt.gates); got != tt.want {
+			t.Errorf("%s: ExitCodeFor = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarkReview(t *testing.T) {
+	g := GateResult{Name: "ubs", Pass: true}
+	g.MarkReview("too many warnings")
+	g.MarkReview("required gate skipped")
+	if !g.Review {
+		t.Fatal("expected review")
+	}
+	if g.ReviewReason != "too many warnings; required gate skipped" {
+		t.Fatalf("unexpected reason: %q", g.ReviewReason)
+	}
+
+	failed := GateResult{Name: "tests", Pass: false}
+	failed.MarkReview("ignored")
+	if failed.Review {
+		t.Fatal("failing gate should not be marked for review")
+	}
+}
+
+func TestReviewReasons(t *testing.T) {
+	reasons := ReviewReasons([]GateResult{
+		{Name: "tests", Pass: true},
+		{Name: "risk", Pass: true, Review: true, ReviewReason: "risk score 50"},
+		{Name: "fragility", Pass: true, Review: true},
+	})
+	if len(reasons) != 2 {
+		t.Fatalf("expected 2 reasons, got %v", reasons)
+	}
+	if reasons[0] != "risk: risk score 50" || reasons[1] != "fragility: needs review" {
+		t.Fatalf("unexpected reasons: %v", reasons)
+	}
+}
+
+func TestComputeScore_IgnoresCancelled(t *testing.T) {
+	gates := []GateResult{
+		{Name: "tests", Pass: false},
+		{Name: "lint", Pass: true},
+		{Name: "ubs", Pass: false, Cancelled: true},
+	}
+	if score := ComputeScore(gates); score != 0.5 {
+		t.Fatalf("expected 0.5, got %f", score)
+	}
+}
+
+func TestMarkCancelled(t *testing.T) {
+	g := GateResult{Name: "ubs", Pass: true, Review: true, ReviewReason: "noisy", Output: "partial"}
+	g.MarkCancelled("cancelled: fail-fast after tests failed")
+	if !g.Cancelled || g.Pass || g.Review || g.ReviewReason != "" {
+		t.Fatalf("unexpected cancelled result: %+v", g)
+	}
+	if g.Output != "cancelled: fail-fast after tests failed" {
+		t.Fatalf("unexpected output: %q", g.Output)
+	}
+}
+
+func TestIssueLocation(t *testing.T) {
+	tests := []struct {
+		issue Issue
+		want  string
+	}{
+		{Issue{}, ""},
+		{Issue{File: "a.go"}, "a.go"},
+		{Issue{File: "a.go", Line: 3}, "a.go:3"},
+		{Issue{File: "a.go", Line: 3, Column: 7}, "a.go:3:7"},
+	}
+	for _, tt := range tests {
+		if got := tt.issue.Location(); got != tt.want {
+			t.Errorf("Location(%+v) = %q, want %q", tt.issue, got, tt.want)
+		}
+	}
+}
+
+func TestFlakyTests(t *testing.T) {
+	gates := []GateResult{
+		{Name: "tests", Tests: []TestCase{
+			{Name: "TestA", Suite: "p", Status: TestPass},
+			{Name: "TestB", Suite: "p", Status: TestPass, Flaky: true},
+			{Name: "test_c", Status: TestPass, Flaky: true},
+		}},
+		{Name: "lint:go vet"},
+	}
+	got := FlakyTests(gates)
+	if len(got) != 2 || got[0] != "tests: p TestB" || got[1] != "tests: test_c" {
+		t.Fatalf("unexpected flaky tests: %v", got)
+	}
+}